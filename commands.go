@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	"notion-to-astro-go/converter"
+)
+
+// runSchema handles the `schema` subcommand
+func runSchema(args []string, env map[string]string) error {
+	if len(args) == 0 || args[0] != "dump" {
+		return errors.New("usage: notion-to-astro-go schema dump [-type blog|diary] [-database ID] [-format table|json]")
+	}
+
+	flags := flag.NewFlagSet("schema dump", flag.ExitOnError)
+	dbType := flags.String("type", "blog", "Database type to dump: 'blog', 'diary' or the name of a database in the config file")
+	databaseID := flags.String("database", "", "Database ID to dump (overrides -type)")
+	format := flags.String("format", "table", "Output format: 'table' or 'json'")
+	configPath := flags.String("config", converter.DefaultConfigFile, "Path to the config file")
+	flags.Parse(args[1:])
+
+	opts := converter.Options{Type: *dbType, ConfigFile: *configPath, Env: env}
+	return converter.DumpSchema(context.Background(), opts, *databaseID, *format, os.Stdout)
+}
+
+// runScaffold handles the `scaffold` subcommand
+func runScaffold(args []string, env map[string]string) error {
+	if len(args) == 0 || args[0] != "astro" {
+		return errors.New("usage: notion-to-astro-go scaffold astro [-dir DIR] [-force]")
+	}
+
+	flags := flag.NewFlagSet("scaffold astro", flag.ExitOnError)
+	dir := flags.String("dir", ".", "Root directory of the Astro project")
+	force := flags.Bool("force", false, "Overwrite existing files")
+	configPath := flags.String("config", converter.DefaultConfigFile, "Path to the config file")
+	flags.Parse(args[1:])
+
+	return converter.ScaffoldAstro(converter.Options{ConfigFile: *configPath, Env: env}, *dir, *force, os.Stdout)
+}
+
+// runVerify handles the `verify` subcommand
+func runVerify(args []string, env map[string]string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	configPath := flags.String("config", converter.DefaultConfigFile, "Path to the config file")
+	flags.Parse(args)
+
+	return converter.Verify(context.Background(), converter.Options{ConfigFile: *configPath, Env: env}, os.Stdout)
+}
+
+// runClean handles the `clean` subcommand
+func runClean(args []string, env map[string]string) error {
+	flags := flag.NewFlagSet("clean", flag.ExitOnError)
+	emptyTrash := flags.Bool("empty-trash", false, "Permanently delete the files moved to the trash by PRUNE_MODE=trash")
+	flags.Parse(args)
+
+	if !*emptyTrash {
+		return errors.New("usage: notion-to-astro-go clean --empty-trash")
+	}
+	return converter.EmptyTrash(os.Stdout)
+}
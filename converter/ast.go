@@ -0,0 +1,242 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/mdrender"
+)
+
+// astVersion is bumped whenever the json-ast format changes incompatibly
+const astVersion = 1
+
+// PageAST is the normalized representation of a converted page written by -format json-ast
+type PageAST struct {
+	Version     int         `json:"version"`
+	Page        ASTPage     `json:"page"`
+	Frontmatter Frontmatter `json:"frontmatter"`
+	Blocks      []ASTBlock  `json:"blocks"`
+	Assets      []ASTAsset  `json:"assets"`
+}
+
+// ASTPage identifies the source Notion page
+type ASTPage struct {
+	ID             string `json:"id"`
+	URL            string `json:"url,omitempty"`
+	CreatedTime    string `json:"createdTime"`
+	LastEditedTime string `json:"lastEditedTime"`
+}
+
+// ASTBlock is a single block with its nested blocks, such as the items of a nested list, the
+// body of a toggle or the rows of a table. Only the fields relevant to Type are set:
+// level for headings, checked for to_do, language for code, icon for callout,
+// url for bookmark, embed and external video, expression for equation, cells for table_row, and
+// asset (an index into PageAST.Assets) for image and uploaded video. The rich text of media
+// blocks is their caption.
+type ASTBlock struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	RichText   []ASTRichRun   `json:"richText,omitempty"`
+	Level      int            `json:"level,omitempty"`
+	Checked    *bool          `json:"checked,omitempty"`
+	Language   string         `json:"language,omitempty"`
+	Icon       string         `json:"icon,omitempty"`
+	URL        string         `json:"url,omitempty"`
+	Expression string         `json:"expression,omitempty"`
+	Asset      *int           `json:"asset,omitempty"`
+	Cells      [][]ASTRichRun `json:"cells,omitempty"`
+	Children   []ASTBlock     `json:"children,omitempty"`
+}
+
+// ASTRichRun is a run of text sharing the same annotations
+type ASTRichRun struct {
+	Text          string `json:"text"`
+	Href          string `json:"href,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Code          bool   `json:"code,omitempty"`
+	Equation      bool   `json:"equation,omitempty"` // Text is a LaTeX expression
+	Color         string `json:"color,omitempty"`
+}
+
+// ASTAsset is a file referenced by the page. Path is the site path of the
+// downloaded copy, or empty when the download failed.
+type ASTAsset struct {
+	Type      string `json:"type"`
+	BlockID   string `json:"blockId"`
+	SourceURL string `json:"sourceUrl"`
+	Path      string `json:"path,omitempty"`
+}
+
+// RenderedBlocks keeps the nested blocks fetched and the files saved while a page is converted
+// to markdown, so its json-ast has the same blocks and file names without fetching or
+// downloading them again. A nil *RenderedBlocks keeps nothing.
+type RenderedBlocks struct {
+	mu       sync.Mutex
+	children map[notionapi.BlockID][]notionapi.Block
+	assets   map[string]string // Site paths of the saved images and videos by source URL
+}
+
+// newRenderedBlocks creates an empty record of the blocks of a page
+func newRenderedBlocks() *RenderedBlocks {
+	return &RenderedBlocks{children: map[notionapi.BlockID][]notionapi.Block{}, assets: map[string]string{}}
+}
+
+// AddChildren records the nested blocks of blockID
+func (r *RenderedBlocks) AddChildren(blockID notionapi.BlockID, children []notionapi.Block) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.children[blockID] = children
+}
+
+// Children returns the nested blocks recorded for blockID
+func (r *RenderedBlocks) Children(blockID notionapi.BlockID) []notionapi.Block {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.children[blockID]
+}
+
+// AddAsset records the site path of the file saved for sourceURL
+func (r *RenderedBlocks) AddAsset(sourceURL, path string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assets[sourceURL] = path
+}
+
+// Asset returns the site path of the file saved for sourceURL, or "" if it was not saved
+func (r *RenderedBlocks) Asset(sourceURL string) string {
+	if r == nil {
+		return ""
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.assets[sourceURL]
+}
+
+// buildPageAST converts the blocks of a page into its json-ast representation. The nested
+// blocks and the paths of the files are those recorded in config.Rendered by the markdown
+// conversion of the page, which already resolved them with the image options of the run.
+func buildPageAST(page notionapi.Page, frontmatter Frontmatter, blocks []notionapi.Block, config runConfig) PageAST {
+	ast := PageAST{
+		Version: astVersion,
+		Page: ASTPage{
+			ID:             page.ID.String(),
+			URL:            page.URL,
+			CreatedTime:    page.CreatedTime.Format(time.RFC3339),
+			LastEditedTime: page.LastEditedTime.Format(time.RFC3339),
+		},
+		Frontmatter: frontmatter,
+		Blocks:      []ASTBlock{},
+		Assets:      []ASTAsset{},
+	}
+
+	ast.Blocks = appendASTBlocks(&ast, ast.Blocks, blocks, config)
+	return ast
+}
+
+// appendASTBlocks appends the json-ast of blocks and their nested blocks to nodes, adding their
+// files to ast.Assets
+func appendASTBlocks(ast *PageAST, nodes []ASTBlock, blocks []notionapi.Block, config runConfig) []ASTBlock {
+	for _, block := range blocks {
+		node := ASTBlock{ID: block.GetID().String(), Type: string(block.GetType())}
+
+		switch b := block.(type) {
+		case *notionapi.ParagraphBlock:
+			node.RichText = astRichText(b.Paragraph.RichText)
+		case *notionapi.Heading1Block:
+			node.Level, node.RichText = 1, astRichText(b.Heading1.RichText)
+		case *notionapi.Heading2Block:
+			node.Level, node.RichText = 2, astRichText(b.Heading2.RichText)
+		case *notionapi.Heading3Block:
+			node.Level, node.RichText = 3, astRichText(b.Heading3.RichText)
+		case *notionapi.BulletedListItemBlock:
+			node.RichText = astRichText(b.BulletedListItem.RichText)
+		case *notionapi.NumberedListItemBlock:
+			node.RichText = astRichText(b.NumberedListItem.RichText)
+		case *notionapi.ToDoBlock:
+			checked := b.ToDo.Checked
+			node.Checked, node.RichText = &checked, astRichText(b.ToDo.RichText)
+		case *notionapi.CodeBlock:
+			node.Language, node.RichText = b.Code.Language, astRichText(b.Code.RichText)
+		case *notionapi.ToggleBlock:
+			node.RichText = astRichText(b.Toggle.RichText)
+		case *notionapi.QuoteBlock:
+			node.RichText = astRichText(b.Quote.RichText)
+		case *notionapi.CalloutBlock:
+			node.Icon, node.RichText = mdrender.CalloutIcon(b.Callout.Icon), astRichText(b.Callout.RichText)
+		case *notionapi.ImageBlock:
+			asset := ASTAsset{Type: "image", BlockID: node.ID, SourceURL: b.Image.GetURL(), Path: config.Rendered.Asset(b.Image.GetURL())}
+			index := len(ast.Assets)
+			node.Asset = &index
+			ast.Assets = append(ast.Assets, asset)
+		case *notionapi.EquationBlock:
+			node.Expression = b.Equation.Expression
+		case *notionapi.BookmarkBlock:
+			node.URL, node.RichText = b.Bookmark.URL, astRichText(b.Bookmark.Caption)
+		case *notionapi.EmbedBlock:
+			node.URL, node.RichText = b.Embed.URL, astRichText(b.Embed.Caption)
+		case *notionapi.VideoBlock:
+			node.RichText = astRichText(b.Video.Caption)
+			if b.Video.Type == notionapi.FileTypeExternal && b.Video.External != nil {
+				node.URL = b.Video.External.URL
+			} else if b.Video.File != nil {
+				asset := ASTAsset{Type: "video", BlockID: node.ID, SourceURL: b.Video.File.URL, Path: config.Rendered.Asset(b.Video.File.URL)}
+				index := len(ast.Assets)
+				node.Asset = &index
+				ast.Assets = append(ast.Assets, asset)
+			}
+		case *notionapi.TableRowBlock:
+			for _, cell := range b.TableRow.Cells {
+				node.Cells = append(node.Cells, astRichText(cell))
+			}
+		case *notionapi.UnsupportedBlock:
+			node.Type = "unsupported"
+		}
+
+		if children := config.Rendered.Children(block.GetID()); len(children) > 0 {
+			node.Children = appendASTBlocks(ast, nil, children, config)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// astRichText converts Notion rich text into runs
+func astRichText(richText []notionapi.RichText) []ASTRichRun {
+	runs := make([]ASTRichRun, 0, len(richText))
+	for _, rt := range richText {
+		run := ASTRichRun{Text: rt.PlainText, Href: rt.Href, Equation: rt.Equation != nil}
+		if a := rt.Annotations; a != nil {
+			run.Bold, run.Italic, run.Strikethrough, run.Underline, run.Code = a.Bold, a.Italic, a.Strikethrough, a.Underline, a.Code
+			if a.Color != "" && a.Color != "default" {
+				run.Color = string(a.Color)
+			}
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// encodePageAST encodes the json-ast of a page
+func encodePageAST(ast PageAST) ([]byte, error) {
+	data, err := json.MarshalIndent(ast, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json-ast: %v", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,151 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestBuildPageAST(t *testing.T) {
+	blocks := []notionapi.Block{
+		headingBlock("Intro"),
+		&notionapi.ParagraphBlock{
+			BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeParagraph},
+			Paragraph: notionapi.Paragraph{RichText: []notionapi.RichText{
+				{PlainText: "Hello ", Annotations: &notionapi.Annotations{Color: "default"}},
+				{PlainText: "world", Href: "https://example.com", Annotations: &notionapi.Annotations{Bold: true, Color: "red"}},
+			}},
+		},
+		&notionapi.ToDoBlock{
+			BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeToDo},
+			ToDo:       notionapi.ToDo{RichText: richText("Ship it"), Checked: true},
+		},
+		&notionapi.UnsupportedBlock{},
+	}
+
+	ast := buildPageAST(*titledPage("page", "Title"), Frontmatter{Title: "Title"}, blocks, runConfig{})
+
+	if ast.Version != astVersion || ast.Page.ID != "page" || ast.Frontmatter.Title != "Title" {
+		t.Errorf("unexpected page header: %+v", ast)
+	}
+
+	checked := true
+	expected := []ASTBlock{
+		{Type: "heading_2", Level: 2, RichText: []ASTRichRun{{Text: "Intro"}}},
+		{Type: "paragraph", RichText: []ASTRichRun{
+			{Text: "Hello "},
+			{Text: "world", Href: "https://example.com", Bold: true, Color: "red"},
+		}},
+		{Type: "to_do", Checked: &checked, RichText: []ASTRichRun{{Text: "Ship it"}}},
+		{Type: "unsupported"},
+	}
+	if !reflect.DeepEqual(ast.Blocks, expected) {
+		t.Errorf("buildPageAST() blocks = %+v, want %+v", ast.Blocks, expected)
+	}
+
+	data, err := encodePageAST(ast)
+	if err != nil {
+		t.Fatalf("encodePageAST() error = %v", err)
+	}
+	var decoded PageAST
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("encoded json-ast is invalid: %v", err)
+	}
+	if len(decoded.Assets) != 0 {
+		t.Errorf("expected no assets, got %+v", decoded.Assets)
+	}
+}
+
+func TestBuildPageASTNestedBlocks(t *testing.T) {
+	item := bulletedBlock("Parent").(*notionapi.BulletedListItemBlock)
+	item.ID, item.HasChildren = "item", true
+	toggle := &notionapi.ToggleBlock{
+		BasicBlock: notionapi.BasicBlock{ID: "toggle", Type: notionapi.BlockTypeToggle, HasChildren: true},
+		Toggle:     notionapi.Toggle{RichText: richText("More")},
+	}
+	table := &notionapi.TableBlock{
+		BasicBlock: notionapi.BasicBlock{ID: "table", Type: notionapi.BlockTypeTableBlock, HasChildren: true},
+		Table:      notionapi.Table{TableWidth: 2, HasColumnHeader: true},
+	}
+	row := &notionapi.TableRowBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeTableRowBlock},
+		TableRow:   notionapi.TableRow{Cells: [][]notionapi.RichText{richText("a"), richText("b")}},
+	}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page":   {item, toggle, table},
+		"item":   {bulletedBlock("Child")},
+		"toggle": {paragraphBlock("Hidden")},
+		"table":  {row},
+	}}}
+
+	// The json-ast has the nested blocks fetched by the markdown conversion
+	config := runConfig{LineBreakStyle: "spaces", Rendered: newRenderedBlocks()}
+	_, blocks, err := retrievePageContent(client, "page", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast := buildPageAST(*titledPage("page", "Title"), Frontmatter{Title: "Title"}, blocks, config)
+
+	expected := []ASTBlock{
+		{ID: "item", Type: "bulleted_list_item", RichText: []ASTRichRun{{Text: "Parent"}}, Children: []ASTBlock{
+			{Type: "bulleted_list_item", RichText: []ASTRichRun{{Text: "Child"}}},
+		}},
+		{ID: "toggle", Type: "toggle", RichText: []ASTRichRun{{Text: "More"}}, Children: []ASTBlock{
+			{Type: "paragraph", RichText: []ASTRichRun{{Text: "Hidden"}}},
+		}},
+		{ID: "table", Type: "table", Children: []ASTBlock{
+			{Type: "table_row", Cells: [][]ASTRichRun{{{Text: "a"}}, {{Text: "b"}}}},
+		}},
+	}
+	if !reflect.DeepEqual(ast.Blocks, expected) {
+		t.Errorf("buildPageAST() blocks = %+v, want %+v", ast.Blocks, expected)
+	}
+}
+
+func TestBuildPageASTAssetPaths(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	imageURL := server.URL + "/photo.png"
+	block := &notionapi.ImageBlock{
+		BasicBlock: notionapi.BasicBlock{ID: "image", Type: notionapi.BlockTypeImage},
+		Image:      notionapi.Image{Type: "external", External: &notionapi.FileObject{URL: imageURL}},
+	}
+	config := runConfig{
+		LineBreakStyle:  "spaces",
+		ImagesDir:       t.TempDir(),
+		ImagesURLPrefix: "/images",
+		ImageFilename:   "{index}-{name}",
+		Manifest:        &Manifest{Files: map[string]ManifestEntry{}, seen: map[string]bool{}},
+		Rendered:        newRenderedBlocks(),
+	}
+	markdown, blocks, err := retrievePageContent(newFakeClient(block), "page", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast := buildPageAST(*titledPage("page", "Title"), Frontmatter{Title: "Title"}, blocks, config)
+
+	// The asset has the name the markdown links to, also when the name counts the images
+	if len(ast.Assets) != 1 || ast.Assets[0].Path != "/images/1-photo.png" || !strings.Contains(markdown, "(/images/1-photo.png)") {
+		t.Errorf("assets = %+v, markdown = %q", ast.Assets, markdown)
+	}
+	if downloads != 1 {
+		t.Errorf("downloaded the image %d times, want once", downloads)
+	}
+}
@@ -0,0 +1,57 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BannedContentFileConfig lists patterns that must never appear in generated content
+type BannedContentFileConfig struct {
+	Patterns []string `yaml:"patterns,omitempty"` // Regular expressions (Go syntax)
+	Strict   bool     `yaml:"strict,omitempty"`   // Skip pages that match instead of only warning
+}
+
+// ContentFilter checks generated content against banned patterns such as internal code
+// names, email addresses or TODO markers. A nil *ContentFilter finds nothing.
+type ContentFilter struct {
+	patterns []*regexp.Regexp
+	strict   bool
+}
+
+// newContentFilter compiles the banned patterns; no patterns yield a nil filter
+func newContentFilter(patterns []string, strict bool) (*ContentFilter, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	filter := &ContentFilter{strict: strict}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid banned content pattern %q: %v", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, re)
+	}
+	return filter, nil
+}
+
+// Find returns a description of every banned pattern found in content, with its line
+func (f *ContentFilter) Find(content string) []string {
+	if f == nil {
+		return nil
+	}
+	var found []string
+	for i, line := range strings.Split(content, "\n") {
+		for _, re := range f.patterns {
+			if match := re.FindString(line); match != "" {
+				found = append(found, fmt.Sprintf("line %d: %q matches %s", i+1, match, re))
+			}
+		}
+	}
+	return found
+}
+
+// Strict reports whether pages with banned content are skipped
+func (f *ContentFilter) Strict() bool {
+	return f != nil && f.strict
+}
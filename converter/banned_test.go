@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestContentFilterFind(t *testing.T) {
+	filter, err := newContentFilter([]string{`(?i)project\s+falcon`, `TODO:`}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "---\ntitle: Release notes\n---\n\nShipped as part of Project Falcon.\nTODO: remove before publishing\n"
+	expected := []string{
+		`line 5: "Project Falcon" matches (?i)project\s+falcon`,
+		`line 6: "TODO:" matches TODO:`,
+	}
+	if got := filter.Find(content); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Find() = %q, want %q", got, expected)
+	}
+	if !filter.Strict() {
+		t.Error("expected a strict filter")
+	}
+
+	var none *ContentFilter
+	if got := none.Find(content); got != nil {
+		t.Errorf("nil filter found %q", got)
+	}
+	if _, err := newContentFilter([]string{"("}, false); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestStrictBannedContentFailsPage(t *testing.T) {
+	dir := t.TempDir()
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Shipped as part of Project Falcon")},
+	}}}
+	filter, err := newContentFilter([]string{`(?i)project\s+falcon`}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := newRunReport()
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, BannedContent: filter, Report: report}
+
+	if err := processPage(client, *titledPage("page", "Release notes"), config); err != nil {
+		t.Fatalf("processPage() error = %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("page with banned content was written: %v", files)
+	}
+	if err := report.Err(); !errors.Is(err, ErrPageSkipped) || ExitCode(err) != exitPartial {
+		t.Errorf("report error = %v (exit code %d), want a failed page", err, ExitCode(err))
+	}
+}
@@ -0,0 +1,200 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/notion"
+	"notion-to-astro-go/internal/writer"
+)
+
+// BodyCache keeps the converted markdown body of each page in a directory, so a page whose
+// blocks did not change (only its title, tags or other properties did) is regenerated
+// without converting its blocks and downloading its images again.
+// A nil *BodyCache caches nothing.
+type BodyCache struct {
+	dir       string
+	workspace *writer.Workspace // Workspace of the run the cache is read from and written to
+	logger    *slog.Logger      // Logger of the run
+}
+
+// CachedBody is the cached body of a page
+type CachedBody struct {
+	PageLastEditedTime   string   `json:"pageLastEditedTime"`
+	BlocksLastEditedTime string   `json:"blocksLastEditedTime"` // Latest last_edited_time and count of the top-level blocks
+	Content              string   `json:"content"`
+	Assets               []string `json:"assets,omitempty"` // Images referenced by the body
+	Settings             string   `json:"settings"`         // Hash of the conversion settings the body was rendered with
+}
+
+// newBodyCache creates a body cache in dir, read and written through workspace; an empty dir
+// disables the cache
+func newBodyCache(dir string, workspace *writer.Workspace, logger *slog.Logger) *BodyCache {
+	if dir == "" {
+		return nil
+	}
+	return &BodyCache{dir: dir, workspace: workspace, logger: logger}
+}
+
+// Load returns the cached body of pageID
+func (c *BodyCache) Load(pageID string) (CachedBody, bool) {
+	var body CachedBody
+	if c == nil {
+		return body, false
+	}
+	data, err := c.workspace.ReadFile(c.path(pageID))
+	if err != nil {
+		return body, false
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		logf(c.logger, slog.LevelWarn, "Ignoring unreadable body cache for page %s: %v", pageID, err)
+		return body, false
+	}
+	return body, true
+}
+
+// Store writes the cached body of pageID
+func (c *BodyCache) Store(pageID string, body CachedBody) error {
+	if c == nil {
+		return nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode body cache: %v", err)
+	}
+	if err := c.workspace.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create body cache directory: %v", err)
+	}
+	if err := c.workspace.WriteFile(c.path(pageID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write body cache: %v", err)
+	}
+	return nil
+}
+
+func (c *BodyCache) path(pageID string) string {
+	return filepath.Join(c.dir, pageID+".json")
+}
+
+// retrievePageBody returns the markdown body of a page, reusing the cached body when the page
+// or its top-level blocks were not edited since it was cached with the same conversion
+// settings. The blocks are nil when the cached body is used without fetching them. -force
+// converts every body again.
+func retrievePageBody(client *notionapi.Client, page notionapi.Page, config runConfig) (string, []notionapi.Block, error) {
+	// json-ast is built from the blocks themselves, so it always needs them
+	if config.BodyCache == nil || config.OutputFormat == "json-ast" {
+		return retrievePageContent(client, page.ID, config)
+	}
+
+	pageID := page.ID.String()
+	pageEdited := page.LastEditedTime.UTC().Format(time.RFC3339)
+	settings := conversionSettings(config)
+	cached, ok := config.BodyCache.Load(pageID)
+	ok = ok && !config.Force && cached.Settings == settings
+	if ok && cached.PageLastEditedTime == pageEdited && reuseCachedAssets(cached, pageID, config) {
+		config.logInfo("Page %s was not edited; using the cached body", pageID)
+		return cached.Content, nil, nil
+	}
+
+	blocks, err := notion.FetchBlockChildren(config.context(), client, notionapi.BlockID(page.ID), config.Logger)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to retrieve page content: %w", err)
+	}
+	blocksEdited := blocksLastEditedTime(blocks)
+	// Editing a nested block does not change its parent, so only pages without nested blocks can
+	// be compared by their top-level blocks
+	if ok && !hasNestedBlocks(blocks) && cached.BlocksLastEditedTime == blocksEdited && reuseCachedAssets(cached, pageID, config) {
+		config.logInfo("Only the properties of page %s changed; using the cached body", pageID)
+		cached.PageLastEditedTime = pageEdited
+		if err := config.BodyCache.Store(pageID, cached); err != nil {
+			config.logWarn("Failed to update body cache: %v", err)
+		}
+		return cached.Content, blocks, nil
+	}
+
+	content, err := renderBlocks(client, page.ID, blocks, config)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to retrieve page content: %w", err)
+	}
+	body := CachedBody{
+		PageLastEditedTime:   pageEdited,
+		BlocksLastEditedTime: blocksEdited,
+		Content:              content,
+		Assets:               config.Manifest.SeenFiles(pageID),
+		Settings:             settings,
+	}
+	if err := config.BodyCache.Store(pageID, body); err != nil {
+		config.logWarn("Failed to update body cache: %v", err)
+	}
+	return content, blocks, nil
+}
+
+// reuseCachedAssets records the images of a cached body in the manifest.
+// It reports false if one of them no longer exists, so the body is converted again.
+func reuseCachedAssets(cached CachedBody, pageID string, config runConfig) bool {
+	for _, asset := range cached.Assets {
+		if _, err := config.Workspace.Stat(filepath.FromSlash(asset)); err != nil {
+			return false
+		}
+	}
+	for _, asset := range cached.Assets {
+		if err := config.Manifest.RecordFile(filepath.FromSlash(asset), pageID); err != nil {
+			config.logWarn("Failed to record image in manifest: %v", err)
+		}
+	}
+	return true
+}
+
+// blocksLastEditedTime returns the latest last_edited_time of blocks
+func blocksLastEditedTime(blocks []notionapi.Block) string {
+	var latest time.Time
+	for _, block := range blocks {
+		if edited := block.GetLastEditedTime(); edited != nil && edited.After(latest) {
+			latest = *edited
+		}
+	}
+	return fmt.Sprintf("%s/%d", latest.UTC().Format(time.RFC3339), len(blocks))
+}
+
+// hasNestedBlocks reports whether one of blocks has child blocks
+func hasNestedBlocks(blocks []notionapi.Block) bool {
+	for _, block := range blocks {
+		if block.GetHasChildren() {
+			return true
+		}
+	}
+	return false
+}
+
+// existingPageBody returns the body of the markdown file exported for pageID by a previous run,
+// so -frontmatter-only can keep it. The images of the page stay in the manifest since the body
+// still references them.
+func existingPageBody(config runConfig, pageID string) (string, bool) {
+	if !config.FrontmatterOnly {
+		return "", false
+	}
+	for path, id := range config.Manifest.Pages() {
+		if ext := filepath.Ext(path); id != pageID || (ext != ".md" && ext != ".mdx") {
+			continue
+		}
+		data, err := config.Workspace.ReadFile(filepath.FromSlash(path))
+		if err != nil {
+			continue
+		}
+		// Generated files start with "---\n<frontmatter>---\n\n"
+		content := string(data)
+		end := strings.Index(content, "\n---\n")
+		if !strings.HasPrefix(content, "---\n") || end < 0 {
+			continue
+		}
+		config.Manifest.KeepAssets(pageID)
+		return strings.TrimPrefix(content[end+len("\n---\n"):], "\n"), true
+	}
+	config.logDebug("No exported file of page %s; converting its blocks", pageID)
+	return "", false
+}
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// countingBlockService serves one page of blocks and counts the requests
+type countingBlockService struct {
+	notionapi.BlockService
+	blocks   []notionapi.Block
+	requests int
+}
+
+func (f *countingBlockService) GetChildren(context.Context, notionapi.BlockID, *notionapi.Pagination) (*notionapi.GetChildrenResponse, error) {
+	f.requests++
+	return &notionapi.GetChildrenResponse{Results: f.blocks}, nil
+}
+
+// editedParagraph returns a paragraph block last edited at edited
+func editedParagraph(text string, edited time.Time) notionapi.Block {
+	block := paragraphBlock(text).(*notionapi.ParagraphBlock)
+	block.LastEditedTime = &edited
+	return block
+}
+
+func TestRetrievePageBodyCache(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := &countingBlockService{blocks: []notionapi.Block{editedParagraph("First", created)}}
+	client := &notionapi.Client{Block: service}
+	config := runConfig{LineBreakStyle: "spaces", BodyCache: newBodyCache(t.TempDir(), nil, nil)}
+	page := notionapi.Page{ID: "page", LastEditedTime: created}
+
+	body := func() string {
+		t.Helper()
+		content, _, err := retrievePageBody(client, page, config)
+		if err != nil {
+			t.Fatalf("retrievePageBody() error = %v", err)
+		}
+		return content
+	}
+
+	if got := body(); got != "First  \n\n" {
+		t.Fatalf("unexpected body %q", got)
+	}
+
+	// Unchanged page: the blocks are not requested
+	body()
+	if service.requests != 1 {
+		t.Errorf("expected the unchanged page to use the cache, got %d requests", service.requests)
+	}
+
+	// Only a property changed: the blocks are requested but not converted again
+	page.LastEditedTime = created.Add(time.Hour)
+	service.blocks = []notionapi.Block{editedParagraph("Not converted", created)}
+	if got := body(); got != "First  \n\n" || service.requests != 2 {
+		t.Errorf("expected the cached body after a property change, got %q (%d requests)", got, service.requests)
+	}
+
+	// An edited block is converted again
+	page.LastEditedTime = created.Add(2 * time.Hour)
+	service.blocks = []notionapi.Block{editedParagraph("Second", created.Add(2*time.Hour))}
+	if got := body(); got != "Second  \n\n" {
+		t.Errorf("expected the edited body, got %q", got)
+	}
+
+	// Changed rendering settings convert the unchanged page again
+	service.blocks = []notionapi.Block{editedParagraph("Line\nbreak", created.Add(2*time.Hour))}
+	config.LineBreakStyle = "br"
+	if got := body(); got != "Line<br/>\nbreak  \n\n" {
+		t.Errorf("expected the body rendered with the new settings, got %q", got)
+	}
+	requests := service.requests
+	body()
+	if service.requests != requests {
+		t.Errorf("expected the body cached with the new settings to be used, got %d requests", service.requests-requests)
+	}
+
+	// -force converts the unchanged page again
+	config.Force = true
+	service.blocks = []notionapi.Block{editedParagraph("Forced", created.Add(2*time.Hour))}
+	if got := body(); got != "Forced  \n\n" {
+		t.Errorf("expected the body converted again with -force, got %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/notion"
+)
+
+// budgetBlockService fails like a block request refused by the request budget
+type budgetBlockService struct {
+	notionapi.BlockService
+}
+
+func (budgetBlockService) GetChildren(context.Context, notionapi.BlockID, *notionapi.Pagination) (*notionapi.GetChildrenResponse, error) {
+	return nil, fmt.Errorf("Get \"https://api.notion.com/v1/blocks/page/children\": %w", notion.ErrRequestBudgetExceeded)
+}
+
+func TestProcessPageStopsAtBudget(t *testing.T) {
+	transport := exceededTransport(t)
+
+	client := &notionapi.Client{Block: budgetBlockService{}}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), ContentErrorPolicy: "placeholder", Report: newRunReport(), Notion: transport}
+	err := processPage(client, *titledPage("page", "Title"), config)
+	if ExitCode(err) != exitBudget {
+		t.Errorf("processPage() = %v, want the budget error", err)
+	}
+	// The run stops without writing a placeholder over the page
+	if files, _ := filepath.Glob(filepath.Join(config.BlogOutputDir, "*.md")); len(files) != 0 {
+		t.Errorf("expected no files, got %v", files)
+	}
+	if _, err := os.Stat(config.BlogOutputDir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exceededTransport returns a Notion transport whose request budget is used up
+func exceededTransport(t *testing.T) *notion.Transport {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport := notion.NewTransport(nil, 0, 1, 1, nil)
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 2; i++ {
+		if resp, err := client.Get(server.URL); err == nil {
+			resp.Body.Close()
+		}
+	}
+	if !transport.Exceeded() {
+		t.Fatal("expected the request budget to be used up")
+	}
+	return transport
+}
+
+func TestConvertStoppedAtBudgetSavesManifest(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("pages.txt", []byte("11111111111111111111111111111111\n22222222222222222222222222222222\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The budget covers the page and the blocks of the first page only
+	err := Convert(context.Background(), Options{
+		PagesFile:   "pages.txt",
+		MaxRequests: 2,
+		Token:       "secret",
+		ConfigData:  []byte("blog:\n  databaseId: blog-db\n  outputDir: content\n"),
+		Env:         map[string]string{"MANIFEST_FILE": "manifest.json"},
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Transport:   newFakeNotionAPI(1),
+	})
+	if ExitCode(err) != exitBudget {
+		t.Fatalf("Convert() = %v, want the budget error", err)
+	}
+	// The page written before the run stopped is recorded for the next run
+	if data, err := os.ReadFile("manifest.json"); err != nil || !strings.Contains(string(data), "Hello.md") {
+		t.Errorf("manifest = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,28 @@
+package converter
+
+import (
+	"sort"
+	"strings"
+)
+
+// canonicalTags returns tags sorted and without duplicates, so reordering the options of a
+// page in Notion does not change the export
+func canonicalTags(tags []string) []string {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, tag := range sorted {
+		if i == 0 || tag != sorted[i-1] {
+			unique = append(unique, tag)
+		}
+	}
+	return unique
+}
+
+// canonicalText normalizes the whitespace of a generated file: line endings become \n, and
+// the file ends with exactly one newline
+func canonicalText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.TrimRight(text, " \t\n") + "\n"
+}
@@ -0,0 +1,76 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestCanonicalText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"a  \r\nb\r\n\r\n", "a  \nb\n"},
+		{"a\rb", "a\nb\n"},
+		{"a  \n\n\n", "a\n"},
+		{"a", "a\n"},
+	}
+
+	for _, tt := range tests {
+		if result := canonicalText(tt.text); result != tt.expected {
+			t.Errorf("canonicalText(%q) = %q, want %q", tt.text, result, tt.expected)
+		}
+	}
+}
+
+func TestImageSource(t *testing.T) {
+	const signed = "https://prod-files-secure.s3.us-west-2.amazonaws.com/ws/1234/photo.png?X-Amz-Date=20240501T090000Z&X-Amz-Signature=abc"
+	if source := imageSource(signed, false); source != "https://prod-files-secure.s3.us-west-2.amazonaws.com/ws/1234/photo.png" {
+		t.Errorf("imageSource() = %q", source)
+	}
+	// The query of an external image may select the image
+	if source := imageSource("https://example.com/image?id=1", true); source != "https://example.com/image?id=1" {
+		t.Errorf("imageSource() = %q", source)
+	}
+}
+
+func TestCanonicalOutputIsStable(t *testing.T) {
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Hello\r\nworld")},
+	}}}
+	export := func(tags ...string) string {
+		dir := t.TempDir()
+		page := titledPage("page", "Stable")
+		options := make([]notionapi.Option, len(tags))
+		for i, tag := range tags {
+			options[i] = notionapi.Option{Name: tag}
+		}
+		page.Properties["tags"] = &notionapi.MultiSelectProperty{MultiSelect: options}
+		processPage(client, *page, runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, CanonicalOutput: true})
+
+		data, err := os.ReadFile(filepath.Join(dir, "Stable.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	first, second := export("go", "astro", "notion"), export("notion", "go", "astro", "go")
+	if first != second {
+		t.Errorf("exports differ:\n%s\n---\n%s", first, second)
+	}
+	if !strings.Contains(first, "\ntags: [\"astro\", \"go\", \"notion\"]\n") {
+		t.Errorf("expected sorted tags in:\n%s", first)
+	}
+}
+
+func TestCanonicalTags(t *testing.T) {
+	if tags := canonicalTags([]string{"b", "a", "b"}); !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("canonicalTags() = %v", tags)
+	}
+}
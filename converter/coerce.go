@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+
+	"notion-to-astro-go/internal/mdrender"
+)
+
+// PropertyError reports a Notion property value that cannot be converted to the type of its
+// frontmatter field. The page is skipped instead of being written with invalid frontmatter.
+type PropertyError struct {
+	Page     string // Title of the page
+	Property string
+	Value    string
+	Reason   string
+}
+
+func (e *PropertyError) Error() string {
+	return fmt.Sprintf("page %q: property %q (value %q): %s", e.Page, e.Property, e.Value, e.Reason)
+}
+
+// propertyText returns the value of a property as plain text
+func propertyText(prop notionapi.Property) string {
+	switch p := prop.(type) {
+	case *notionapi.TitleProperty:
+		return mdrender.PlainText(p.Title)
+	case *notionapi.RichTextProperty:
+		return mdrender.PlainText(p.RichText)
+	case *notionapi.SelectProperty:
+		return p.Select.Name
+	case *notionapi.NumberProperty:
+		return strconv.FormatFloat(p.Number, 'f', -1, 64)
+	case *notionapi.DateProperty:
+		if p.Date != nil && p.Date.Start != nil {
+			return p.Date.Start.String()
+		}
+	case *notionapi.FormulaProperty:
+		switch p.Formula.Type {
+		case notionapi.FormulaTypeString:
+			return p.Formula.String
+		case notionapi.FormulaTypeNumber:
+			return strconv.FormatFloat(p.Formula.Number, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// coerceNumber converts a number property, or a number stored as text, to a number.
+// ok is false when the property is empty.
+func coerceNumber(title, name string, prop notionapi.Property) (number float64, ok bool, err error) {
+	switch p := prop.(type) {
+	case *notionapi.NumberProperty:
+		return p.Number, true, nil
+	case *notionapi.FormulaProperty:
+		if p.Formula.Type == notionapi.FormulaTypeNumber {
+			return p.Formula.Number, true, nil
+		}
+	}
+
+	text := propertyText(prop)
+	if text == "" {
+		return 0, false, nil
+	}
+	number, parseErr := strconv.ParseFloat(text, 64)
+	if parseErr != nil {
+		return 0, false, &PropertyError{Page: title, Property: name, Value: text, Reason: "expected a number"}
+	}
+	return number, true, nil
+}
+
+// coerceDate converts a date property, or a date stored as text, to YYYY-MM-DD
+func coerceDate(title, name string, prop notionapi.Property) (string, error) {
+	var date *notionapi.DateObject
+	switch p := prop.(type) {
+	case *notionapi.DateProperty:
+		date = p.Date
+	case *notionapi.FormulaProperty:
+		date = p.Formula.Date
+	}
+	if date != nil && date.Start != nil {
+		return time.Time(*date.Start).Format("2006-01-02"), nil
+	}
+
+	text := propertyText(prop)
+	if text == "" {
+		return "", &PropertyError{Page: title, Property: name, Reason: "empty date"}
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006/01/02"} {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed.Format("2006-01-02"), nil
+		}
+	}
+	return "", &PropertyError{Page: title, Property: name, Value: text, Reason: "expected a date (YYYY-MM-DD)"}
+}
+
+// yamlString formats a free-text frontmatter value, quoting it only when it would otherwise
+// be invalid YAML or be read as another type (e.g. a title of "2024" or "Q&A: part 1")
+func yamlString(value string) string {
+	if strings.ContainsAny(value, "\n\r") {
+		return strconv.Quote(value)
+	}
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return strconv.Quote(value)
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
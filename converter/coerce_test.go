@@ -0,0 +1,68 @@
+package converter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestCoerceNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		prop     notionapi.Property
+		expected float64
+		ok       bool
+		err      string
+	}{
+		{name: "Number", prop: &notionapi.NumberProperty{Number: 2}, expected: 2, ok: true},
+		{name: "Number stored as text", prop: &notionapi.RichTextProperty{RichText: richText(" 3 ")}, expected: 3, ok: true},
+		{name: "Empty text", prop: &notionapi.RichTextProperty{}},
+		{name: "Text", prop: &notionapi.RichTextProperty{RichText: richText("first")}, err: `page "Guide": property "order" (value "first"): expected a number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, ok, err := coerceNumber("Guide", "order", tt.prop)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("coerceNumber() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || number != tt.expected || ok != tt.ok {
+				t.Errorf("coerceNumber() = %v, %v, %v; want %v, %v", number, ok, err, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCoerceDate(t *testing.T) {
+	start := notionapi.Date(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	if date, err := coerceDate("Post", "publishedAt", &notionapi.DateProperty{Date: &notionapi.DateObject{Start: &start}}); err != nil || date != "2024-05-01" {
+		t.Errorf("coerceDate() = %q, %v", date, err)
+	}
+	if date, err := coerceDate("Post", "publishedAt", &notionapi.RichTextProperty{RichText: richText("2024/05/01")}); err != nil || date != "2024-05-01" {
+		t.Errorf("coerceDate() of text = %q, %v", date, err)
+	}
+
+	_, err := coerceDate("Post", "publishedAt", &notionapi.DateProperty{})
+	if err == nil || err.Error() != `page "Post": property "publishedAt" (value ""): empty date` {
+		t.Errorf("expected an empty date error, got %v", err)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":   "Hello World",
+		"日本語のタイトル":      "日本語のタイトル",
+		"Q&A: part 1":   `'Q&A: part 1'`,
+		"2024":          `"2024"`,
+		"# not comment": `'# not comment'`,
+	}
+	for value, expected := range tests {
+		if got := yamlString(value); got != expected {
+			t.Errorf("yamlString(%q) = %s, want %s", value, got, expected)
+		}
+	}
+}
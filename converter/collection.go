@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/mdrender"
+	"notion-to-astro-go/internal/writer"
+)
+
+// Collection holds the metadata of a database, written to <COLLECTION_METADATA_DIR>/<type>/collection.json
+// so index and archive pages can show collection-level information without hardcoding it.
+// A nil *Collection records nothing.
+type Collection struct {
+	mu sync.Mutex
+
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Icon        string         `json:"icon,omitempty"`
+	NotionURL   string         `json:"notionUrl,omitempty"`
+	PostCount   int            `json:"postCount"`
+	DraftCount  int            `json:"draftCount"`
+	Tags        map[string]int `json:"tags"`
+	UpdatedAt   string         `json:"updatedAt,omitempty"` // Latest last_edited_time of the exported pages
+}
+
+// newCollection starts the metadata of a database
+func newCollection(dbType string, database *notionapi.Database) *Collection {
+	collection := &Collection{Type: dbType, Tags: map[string]int{}}
+	if database != nil {
+		collection.Title = mdrender.PlainText(database.Title)
+		collection.Description = mdrender.PlainText(database.Description)
+		collection.Icon = mdrender.CalloutIcon(database.Icon)
+		collection.NotionURL = database.URL
+	}
+	return collection
+}
+
+// AddPage counts an exported page and its tags
+func (c *Collection) AddPage(page notionapi.Page, frontmatter Frontmatter) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.PostCount++
+	if frontmatter.Draft {
+		c.DraftCount++
+	}
+	for _, tag := range frontmatter.Tags {
+		c.Tags[tag]++
+	}
+	if edited := page.LastEditedTime.UTC().Format(time.RFC3339); edited > c.UpdatedAt {
+		c.UpdatedAt = edited
+	}
+}
+
+// Save writes collection.json into dir/<type>/ and returns its path
+func (c *Collection) Save(dir string, workspace *writer.Workspace) (string, error) {
+	if c == nil || dir == "" {
+		return "", nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode collection metadata: %v", err)
+	}
+	path := filepath.Join(dir, strings.ToLower(c.Type), "collection.json")
+	if err := workspace.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create collection metadata directory: %v", err)
+	}
+	if err := workspace.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write collection metadata %s: %v", path, err)
+	}
+	return path, nil
+}
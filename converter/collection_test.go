@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestCollectionSave(t *testing.T) {
+	emoji := notionapi.Emoji("📝")
+	database := &notionapi.Database{
+		Title:       richText("My Blog"),
+		Description: richText("Notes on Go"),
+		Icon:        &notionapi.Icon{Type: "emoji", Emoji: &emoji},
+	}
+	collection := newCollection("blog", database)
+	edited := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	collection.AddPage(notionapi.Page{LastEditedTime: edited}, Frontmatter{Tags: []string{"go", "astro"}})
+	collection.AddPage(notionapi.Page{LastEditedTime: edited.Add(-time.Hour)}, Frontmatter{Tags: []string{"go"}, Draft: true})
+
+	dir := t.TempDir()
+	path, err := collection.Save(dir, nil)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if path != filepath.Join(dir, "blog", "collection.json") {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "type": "blog",
+  "title": "My Blog",
+  "description": "Notes on Go",
+  "icon": "📝",
+  "postCount": 2,
+  "draftCount": 1,
+  "tags": {
+    "astro": 1,
+    "go": 2
+  },
+  "updatedAt": "2024-05-01T09:00:00Z"
+}
+`
+	if string(data) != expected {
+		t.Errorf("collection.json =\n%s\nwant\n%s", data, expected)
+	}
+}
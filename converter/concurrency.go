@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jomei/notionapi"
+)
+
+// processPages calls process for each page with up to concurrency pages in parallel and
+// returns the number of pages. Everything process shares between pages must be safe for
+// concurrent use; the run-wide objects on runConfig are. An error returned by process stops the
+// run: the remaining pages are received but not processed, and the first error is returned.
+func processPages(pages <-chan notionapi.Page, concurrency int, process func(count int, page notionapi.Page) error) (int, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	count := 0
+	var firstErr error
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range pages {
+				mu.Lock()
+				stopped := firstErr != nil
+				count++
+				n := count
+				mu.Unlock()
+				if stopped {
+					continue
+				}
+				if err := process(n, page); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return count, firstErr
+}
+
+// processDatabases calls process for each database name in parallel and returns the errors
+// of the databases that failed when all of them are done
+func processDatabases(names []string, process func(name string) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = process(name)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
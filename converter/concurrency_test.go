@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestProcessPages(t *testing.T) {
+	pages := make(chan notionapi.Page)
+	go func() {
+		for i := 0; i < 20; i++ {
+			pages <- notionapi.Page{}
+		}
+		close(pages)
+	}()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	seen := map[int]bool{}
+	count, err := processPages(pages, 4, func(n int, page notionapi.Page) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		seen[n] = true
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 20 || len(seen) != 20 {
+		t.Errorf("processed %d pages with %d distinct numbers, want 20", count, len(seen))
+	}
+	if maxRunning < 2 || maxRunning > 4 {
+		t.Errorf("%d pages ran at the same time, want between 2 and 4", maxRunning)
+	}
+}
+
+func TestProcessPagesStopsOnError(t *testing.T) {
+	pages := make(chan notionapi.Page)
+	go func() {
+		for i := 0; i < 10; i++ {
+			pages <- notionapi.Page{}
+		}
+		close(pages)
+	}()
+
+	stop := errors.New("stop")
+	var mu sync.Mutex
+	processed := 0
+	count, err := processPages(pages, 1, func(n int, page notionapi.Page) error {
+		mu.Lock()
+		defer mu.Unlock()
+		processed++
+		if n == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("processPages() = %v, want %v", err, stop)
+	}
+	// The remaining pages are received so the query can finish, but not processed
+	if count != 10 || processed != 3 {
+		t.Errorf("received %d pages and processed %d, want 10 and 3", count, processed)
+	}
+}
+
+func TestProcessDatabasesErrors(t *testing.T) {
+	err := processDatabases([]string{"blog", "diary", "notes"}, func(name string) error {
+		if name == "diary" {
+			return notionError("failed to get %s database", name)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "failed to get diary database" || ExitCode(err) != exitNotion {
+		t.Errorf("processDatabases() = %v, want the error of the diary database", err)
+	}
+}
+
+func TestProcessPagesConcurrently(t *testing.T) {
+	manifest, err := loadManifest(t.TempDir()+"/manifest.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := newRunReport()
+	dir := t.TempDir()
+	config := runConfig{
+		DatabaseType:      "blog",
+		LineBreakStyle:    "spaces",
+		BlogOutputDir:     dir,
+		EmitAdjacentPosts: true,
+		Manifest:          manifest,
+		Report:            report,
+		Collection:        newCollection("blog", nil),
+		Navigation:        &Navigation{},
+	}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{}}}
+
+	pages := make(chan notionapi.Page)
+	go func() {
+		for _, title := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+			pages <- *titledPage(title, title)
+		}
+		close(pages)
+	}()
+	if _, err := processPages(pages, 4, func(_ int, page notionapi.Page) error {
+		return processPage(client, page, config)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Navigation.Apply(config); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(report.ExportedPages()); got != 8 {
+		t.Errorf("exported %d pages, want 8", got)
+	}
+	if config.Collection.PostCount != 8 || len(manifest.Pages()) != 8 {
+		t.Errorf("collection counted %d pages and manifest %d, want 8", config.Collection.PostCount, len(manifest.Pages()))
+	}
+}
+
+func TestProcessDatabases(t *testing.T) {
+	// Each database waits until all of them have started, which only finishes when they run in parallel
+	names := []string{"blog", "diary", "notes"}
+	var started sync.WaitGroup
+	started.Add(len(names))
+	var mu sync.Mutex
+	var done []string
+	finished := make(chan struct{})
+	go func() {
+		processDatabases(names, func(name string) error {
+			started.Done()
+			started.Wait()
+			mu.Lock()
+			done = append(done, name)
+			mu.Unlock()
+			return nil
+		})
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("databases were not processed in parallel")
+	}
+	if len(done) != len(names) {
+		t.Errorf("processed %v, want all of %v", done, names)
+	}
+}
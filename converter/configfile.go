@@ -0,0 +1,160 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the config file looked up in the working directory
+const DefaultConfigFile = "notion-to-astro.yaml"
+
+// FileConfig is the on-disk configuration (notion-to-astro.yaml).
+// Environment variables take precedence over values set here.
+type FileConfig struct {
+	Blog            DatabaseFileConfig `yaml:"blog,omitempty"`
+	Diary           DatabaseFileConfig `yaml:"diary,omitempty"`
+	Pages           PageTreeFileConfig `yaml:"pages,omitempty"`
+	ImagesDir       string             `yaml:"imagesDir,omitempty"`
+	ImagesURLPrefix string             `yaml:"imagesUrlPrefix,omitempty"`
+	ImagesPerPage   bool               `yaml:"imagesPerPage,omitempty"`
+	ImagesColocated bool               `yaml:"imagesColocated,omitempty"`
+	ImageFilename   string             `yaml:"imageFilename,omitempty"`
+	ImageUserAgent  string             `yaml:"imageUserAgent,omitempty"`
+	ImageHeaders    map[string]string  `yaml:"imageHeaders,omitempty"` // Extra headers of image downloads, e.g. Referer
+	SkipPages       []string           `yaml:"skipPages,omitempty"`    // Page IDs or URLs that are never exported
+	Layouts         map[string]string  `yaml:"layouts,omitempty"`      // Layouts of the values of the template property
+
+	BannedContent BannedContentFileConfig `yaml:"bannedContent,omitempty"`
+
+	// Additional databases, selected with -type <name>
+	Databases []DatabaseDefinition `yaml:"databases,omitempty"`
+}
+
+// PageTreeFileConfig holds the settings for exporting a page hierarchy
+type PageTreeFileConfig struct {
+	RootPageID   string `yaml:"rootPageId,omitempty"`
+	OutputDir    string `yaml:"outputDir,omitempty"`
+	ImagesSubdir string `yaml:"imagesSubdir,omitempty"`
+}
+
+// DatabaseFileConfig holds the settings for a single Notion database
+type DatabaseFileConfig struct {
+	DatabaseID   string          `yaml:"databaseId,omitempty"`
+	OutputDir    string          `yaml:"outputDir,omitempty"`
+	ImagesSubdir string          `yaml:"imagesSubdir,omitempty"` // Subdirectory of imagesDir for this database
+	Properties   PropertyMapping `yaml:"properties,omitempty"`
+	Required     []string        `yaml:"required,omitempty"`     // Properties that must be filled for a page to be exported
+	Filename     string          `yaml:"filename,omitempty"`     // File name pattern with {title}, {slug}, {date} and {id}, without extension
+	SlugStrategy string          `yaml:"slugStrategy,omitempty"` // "property" (default), "transliterated", "title" or "id"
+	Filter       QueryFilter     `yaml:"filter,omitempty"`       // Notion API filter the pages must also match, e.g. the filter of a view
+	Sorts        []QuerySort     `yaml:"sorts,omitempty"`        // Order the pages are queried in, e.g. the sorts of a view
+}
+
+// noProperty disables a query condition in a PropertyMapping when the database has no such checkbox
+const noProperty = "-"
+
+// PropertyMapping maps frontmatter fields and query conditions to Notion property names.
+// Empty values fall back to the built-in property names.
+type PropertyMapping struct {
+	Title        string `yaml:"title,omitempty"`
+	Tags         string `yaml:"tags,omitempty"`
+	ID           string `yaml:"id,omitempty"`
+	Weather      string `yaml:"weather,omitempty"`
+	NoIndex      string `yaml:"noindex,omitempty"`
+	Author       string `yaml:"author,omitempty"`
+	Label        string `yaml:"label,omitempty"`
+	Order        string `yaml:"order,omitempty"`
+	RedirectFrom string `yaml:"redirectFrom,omitempty"`
+	PublishedAt  string `yaml:"publishedAt,omitempty"`
+	Slug         string `yaml:"slug,omitempty"`
+	Layout       string `yaml:"layout,omitempty"`    // Select choosing the layout of the page (LAYOUT_FIELD)
+	Published    string `yaml:"published,omitempty"` // Checkbox that must be unchecked for a page to be exported ("-" for none)
+	Done         string `yaml:"done,omitempty"`      // Checkbox that must be checked for a page to be exported ("-" for none)
+	NoExport     string `yaml:"noExport,omitempty"`  // Checkbox that excludes a page when checked ("-" for none)
+}
+
+// publishedProperty returns the name of the published checkbox, or "" if the database has none
+func (m PropertyMapping) publishedProperty() string {
+	return checkboxProperty(m.Published, "published")
+}
+
+// doneProperty returns the name of the done checkbox, or "" if the database has none
+func (m PropertyMapping) doneProperty() string {
+	return checkboxProperty(m.Done, "done")
+}
+
+// noExportProperty returns the name of the no-export checkbox, or "" if the database has none
+func (m PropertyMapping) noExportProperty() string {
+	return checkboxProperty(m.NoExport, "no-export")
+}
+
+// checkboxProperty returns the configured checkbox name, defaultName if none is configured,
+// or "" if the condition is disabled
+func checkboxProperty(configured, defaultName string) string {
+	switch configured {
+	case "":
+		return defaultName
+	case noProperty:
+		return ""
+	}
+	return configured
+}
+
+// loadFileConfig reads the config file; a missing file yields an empty config
+func loadFileConfig(path string) (FileConfig, error) {
+	var fileConfig FileConfig
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fileConfig, nil
+	}
+	if err != nil {
+		return fileConfig, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+	return parseFileConfig(data, path)
+}
+
+// parseFileConfig parses the YAML content of the config file name
+func parseFileConfig(data []byte, name string) (FileConfig, error) {
+	var fileConfig FileConfig
+	if err := yaml.Unmarshal(data, &fileConfig); err != nil {
+		return fileConfig, fmt.Errorf("failed to parse config file %s: %v", name, err)
+	}
+	return fileConfig, nil
+}
+
+// SaveFileConfig writes the config file as YAML
+func SaveFileConfig(path string, fileConfig FileConfig) error {
+	data, err := yaml.Marshal(fileConfig)
+	if err != nil {
+		return fmt.Errorf("failed to encode config file: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// lookupProperty returns the configured property if set, otherwise the first
+// fallback name present on the page
+func lookupProperty(properties notionapi.Properties, configured string, fallbacks ...string) (notionapi.Property, bool) {
+	_, prop, ok := lookupNamedProperty(properties, configured, fallbacks...)
+	return prop, ok
+}
+
+// lookupNamedProperty is lookupProperty that also returns the name the property was found under
+func lookupNamedProperty(properties notionapi.Properties, configured string, fallbacks ...string) (string, notionapi.Property, bool) {
+	if configured != "" {
+		prop, ok := properties[configured]
+		return configured, prop, ok
+	}
+	for _, name := range fallbacks {
+		if prop, ok := properties[name]; ok {
+			return name, prop, true
+		}
+	}
+	return "", nil, false
+}
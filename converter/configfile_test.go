@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestLookupProperty(t *testing.T) {
+	properties := notionapi.Properties{
+		"Name": &notionapi.TitleProperty{},
+		"Tags": &notionapi.MultiSelectProperty{},
+	}
+
+	tests := []struct {
+		name       string
+		configured string
+		fallbacks  []string
+		found      bool
+	}{
+		{name: "Fallback name present", fallbacks: []string{"title", "Title", "Name"}, found: true},
+		{name: "No fallback present", fallbacks: []string{"title", "Title"}, found: false},
+		{name: "Configured name present", configured: "Tags", fallbacks: []string{"tags"}, found: true},
+		{name: "Configured name ignores fallbacks", configured: "Labels", fallbacks: []string{"Tags"}, found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, found := lookupProperty(properties, tt.configured, tt.fallbacks...)
+			if found != tt.found {
+				t.Errorf("lookupProperty() found = %v, want %v", found, tt.found)
+			}
+		})
+	}
+}
+
+func TestDatabaseQueryFilter(t *testing.T) {
+	properties := func(filter notionapi.AndCompoundFilter) []string {
+		var names []string
+		for _, condition := range filter {
+			names = append(names, condition.(notionapi.PropertyFilter).Property)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name    string
+		mapping PropertyMapping
+		want    []string
+	}{
+		{"defaults", PropertyMapping{}, []string{"published", "done"}},
+		{"renamed", PropertyMapping{Published: "公開済み", Done: "Ready"}, []string{"公開済み", "Ready"}},
+		{"no done checkbox", PropertyMapping{Done: "-"}, []string{"published"}},
+		{"no conditions", PropertyMapping{Published: "-", Done: "-"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := properties(databaseQueryFilter(tt.mapping))
+			if len(got) != len(tt.want) {
+				t.Fatalf("filter properties = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("filter properties = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package converter exports Notion databases and pages as Markdown content collections for
+// Astro. The notion-to-astro-go command parses its flags into Options and runs Convert; other
+// Go programs and static site tooling can run the same conversion, and the work of the
+// subcommands with DumpSchema, Verify and ScaffoldAstro. ExitCode maps the returned errors to
+// the exit codes of the command.
+//
+// The package is the facade of the conversion. It reads the configuration, walks the pages and
+// their blocks and keeps the state of a run; the packages under internal send the Notion API
+// requests (notion), render rich text and blocks as Markdown (mdrender), detect, resize and
+// re-encode images (images) and write the files of a run (writer).
+package converter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Options selects what Convert exports, like the flags of the command line tool. The Notion
+// token, the databases and the output directories are configured as for the command line tool,
+// by the config file and the settings in Env, unless Token and ConfigData give them. Convert
+// reads neither the .env file nor the environment of the process. The zero value exports all
+// databases in the configuration.
+type Options struct {
+	Type            string   // "blog", "diary", "pages", "all" or the name of a database in the config file (-type, default: all)
+	ConfigFile      string   // Path to the config file (-config, default: notion-to-astro.yaml)
+	Format          string   // "markdown" or "json-ast" (-format, default: markdown)
+	RootPage        string   // Root page ID to export with its child pages; implies Type "pages" (-root-page)
+	Page            string   // Export only the page with this ID (-page)
+	PagesFile       string   // Export only the pages listed in this file (-pages-file)
+	Output          string   // Output file of Page; "-" writes the result to stdout (-output)
+	RefreshImages   bool     // Revalidate already downloaded external images (-refresh-images)
+	Prune           bool     // Remove files of pages that are no longer exported (-prune)
+	SyncDeletions   bool     // Remove files of pages archived, deleted or unpublished in Notion (-sync-deletions)
+	CheckLinks      bool     // Report broken and relative links in exported pages (-check-links)
+	MarkPublished   bool     // Check the published checkbox of each exported page in Notion (-mark-published)
+	Force           bool     // Export also the pages that did not change since the last run (-force)
+	FrontmatterOnly bool     // Keep the bodies of exported pages and regenerate their frontmatter (-frontmatter-only)
+	Concurrency     int      // Number of pages processed in parallel; 0 uses CONCURRENCY (-concurrency)
+	MaxRequests     int      // Stop after this many Notion API requests; 0 uses NOTION_MAX_REQUESTS (-max-requests)
+	Skip            []string // Page IDs or URLs that are never exported, in addition to SKIP_PAGES (-skip)
+	Verbose         bool     // Also log the debug messages of each block, image and step (-verbose)
+	Quiet           bool     // Log only warnings and errors (-quiet)
+	LogFormat       string   // "text" or "json"; "" uses LOG_FORMAT (-log-format)
+	StagingDir      string   // Directory of the changes staged with STAGED_WRITES; "" uses STAGING_DIR (-staging-dir)
+
+	SlugStrategy SlugStrategy // Decides the slugs of the pages of every database instead of their slugStrategy; nil uses the named ones
+
+	Env        map[string]string // Settings by environment variable name, as NOTION_API_TOKEN or IMAGES_DIR; nil uses none
+	Token      string            // Notion API token; "" uses NOTION_API_TOKEN or the token saved by login
+	ConfigData []byte            // Content of the config file in YAML, used instead of reading ConfigFile
+	Logger     *slog.Logger      // Receives the messages of the run instead of os.Stdout and os.Stderr; Verbose, Quiet and LogFormat are ignored
+	Stdout     io.Writer         // Receives the page exported with Output "-"; nil writes to os.Stdout
+	Transport  http.RoundTripper // Carries the requests to the Notion API; nil uses http.DefaultTransport
+}
+
+// Convert exports the pages selected by opts and the configuration, as the command line tool
+// does without a subcommand. Messages are logged to opts.Logger, or else to os.Stdout and
+// os.Stderr according to Verbose, Quiet and LogFormat. Each call has its own rate limit and
+// request budget, so Convert may run for several projects at once, provided they do not share
+// output directories or state files. With STAGED_WRITES each call stages its changes in a
+// workspace of its own under the staging directory.
+//
+// Canceling ctx stops the run: no page starts after it, and the pages in progress get 30
+// seconds to finish before their Notion requests are canceled. A run stopped early, by ctx,
+// the request budget or another error, keeps the pages it wrote and records them in the
+// manifest and the sync state unless STAGED_WRITES discards them; nothing is pruned, and the
+// returned error wraps the cause next to the failures of the run. A run
+// in which some pages failed still writes the other pages. The returned error matches the
+// causes of the failure with errors.Is: ErrInvalidConfig for invalid options or configuration,
+// ErrPageSkipped for pages that could not be exported and ErrRateLimited for those of them
+// still rate limited by Notion. Pages skipped for empty required properties and unsupported
+// blocks do not fail a run: a run without failed pages returns nil and only lists them in the
+// summary, while the error of a failed run also matches ErrPageSkipped and ErrUnsupportedBlock
+// for them.
+func Convert(ctx context.Context, opts Options) error {
+	config, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+	return runConversion(ctx, config)
+}
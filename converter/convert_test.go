@@ -0,0 +1,183 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestLoadConfigOptions(t *testing.T) {
+	captureLog(t, slog.LevelInfo, "text")
+	t.Chdir(t.TempDir())
+	env := map[string]string{"NOTION_API_TOKEN": "secret"}
+
+	strategy := SlugStrategyFunc(func(page notionapi.Page, title string, props PropertyMapping) string { return "custom" })
+	config, err := loadConfig(Options{RootPage: "root", Skip: []string{"0123456789abcdef0123456789abcdef"}, MaxRequests: 10, SlugStrategy: strategy, Env: env})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.slugStrategy().Slug(notionapi.Page{}, "Title", PropertyMapping{}) != "custom" {
+		t.Error("expected the slug strategy of the options")
+	}
+	if config.DatabaseType != "pages" || config.NotionRootPageID != "root" {
+		t.Errorf("type = %q, root page = %q, want the page tree of root", config.DatabaseType, config.NotionRootPageID)
+	}
+	if config.OutputFormat != "markdown" || config.NotionMaxRequests != 10 || len(config.SkipPages) != 1 {
+		t.Errorf("format = %q, max requests = %d, skipped pages = %v", config.OutputFormat, config.NotionMaxRequests, config.SkipPages)
+	}
+
+	// The zero value exports all databases, which need their IDs
+	if _, err := loadConfig(Options{}); ExitCode(err) != exitConfig {
+		t.Errorf("loadConfig() = %v, want a configuration error", err)
+	}
+	if _, err := loadConfig(Options{Page: "page", Verbose: true, Quiet: true, Env: env}); ExitCode(err) != exitConfig {
+		t.Errorf("loadConfig() = %v, want a configuration error", err)
+	}
+}
+
+func TestConvertCanceled(t *testing.T) {
+	captureLog(t, slog.LevelInfo, "text")
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Convert(ctx, Options{Page: "page", Token: "secret"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Convert() = %v, want context.Canceled", err)
+	}
+	// Nothing was written
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected an empty directory, got %d entries", len(entries))
+	}
+}
+
+func TestLoadConfigEmbedded(t *testing.T) {
+	t.Chdir(t.TempDir())
+	// The environment of the process and the .env file are not read
+	t.Setenv("IMAGES_DIR", "from-env")
+	if err := os.WriteFile(".env", []byte("BLOG_OUTPUT_DIR=from-dotenv\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+
+	config, err := loadConfig(Options{
+		Type:       "blog",
+		Token:      "secret",
+		ConfigData: []byte("blog:\n  databaseId: blog-db\n  outputDir: posts\n"),
+		Env:        map[string]string{"AUTHOR_NAME": "Author"},
+		Logger:     logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.NotionAPIToken != "secret" || config.NotionBlogDatabaseID != "blog-db" || config.BlogOutputDir != "posts" {
+		t.Errorf("token = %q, database = %q, output = %q", config.NotionAPIToken, config.NotionBlogDatabaseID, config.BlogOutputDir)
+	}
+	if config.AuthorName != "Author" || config.ImagesDir != "./public/images" || config.BlogOutputDir != "posts" {
+		t.Errorf("author = %q, images = %q, output = %q; want only the settings of the options", config.AuthorName, config.ImagesDir, config.BlogOutputDir)
+	}
+	if config.Logger != logger {
+		t.Error("expected the logger of the options")
+	}
+
+	if _, err := loadConfig(Options{Type: "blog", Token: "secret", ConfigData: []byte("blog: [")}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("loadConfig() = %v, want ErrInvalidConfig", err)
+	}
+}
+
+// fakeNotionAPI answers the Notion API requests of a single page export with a page titled
+// Hello and a paragraph. Each page request waits until runs page requests arrived, so the runs
+// overlap.
+type fakeNotionAPI struct {
+	mu      sync.Mutex
+	runs    int
+	arrived chan struct{}
+}
+
+func newFakeNotionAPI(runs int) *fakeNotionAPI {
+	return &fakeNotionAPI{runs: runs, arrived: make(chan struct{})}
+}
+
+func (api *fakeNotionAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body string
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/children"):
+		body = `{"object": "list", "has_more": false, "results": [{"object": "block", "id": "block", "type": "paragraph",
+			"paragraph": {"rich_text": [{"type": "text", "text": {"content": "Body"}, "plain_text": "Body"}]}}]}`
+	case strings.HasPrefix(req.URL.Path, "/v1/pages/"):
+		api.mu.Lock()
+		if api.runs--; api.runs == 0 {
+			close(api.arrived)
+		}
+		api.mu.Unlock()
+		select {
+		case <-api.arrived:
+		case <-time.After(5 * time.Second):
+		}
+		body = `{"object": "page", "id": "page", "created_time": "2024-05-01T09:00:00Z", "last_edited_time": "2024-05-01T09:00:00Z",
+			"parent": {"type": "database_id", "database_id": "blog-db"},
+			"properties": {"Title": {"id": "title", "type": "title", "title": [{"type": "text", "text": {"content": "Hello"}, "plain_text": "Hello"}]}}}`
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"object": "error", "status": 404}`)), Request: req}, nil
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestConvertParallel(t *testing.T) {
+	t.Chdir(t.TempDir())
+	api := newFakeNotionAPI(2)
+	projects := []string{"first", "second"}
+	errs := make([]error, len(projects))
+	var wg sync.WaitGroup
+	for i, project := range projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = Convert(context.Background(), Options{
+				Page:       "page",
+				Token:      "secret",
+				ConfigData: []byte("blog:\n  databaseId: blog-db\n  outputDir: " + project + "/content\nimagesDir: " + project + "/images\n"),
+				Env: map[string]string{
+					"STAGED_WRITES":   "true",
+					"MANIFEST_FILE":   project + "/manifest.json",
+					"SYNC_STATE_FILE": project + "/sync.json",
+				},
+				StagingDir: project + "/staging",
+				Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+				Transport:  api,
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i, project := range projects {
+		if errs[i] != nil {
+			t.Errorf("%s: Convert() = %v", project, errs[i])
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(project, "content", "Hello.md"))
+		if err != nil || !strings.Contains(string(data), "Body") {
+			t.Errorf("%s: page file = %q, %v", project, data, err)
+		}
+		if _, err := os.Stat(filepath.Join(project, "staging")); !os.IsNotExist(err) {
+			t.Errorf("%s: staging directory left behind: %v", project, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/mdrender"
+)
+
+// pageImage downloads an image of the page itself, such as its cover, like the images of the
+// body and returns the URL the frontmatter links to. The original URL is used when the download
+// fails.
+func pageImage(imageURL, pageID string, external bool, config runConfig) string {
+	// Numbered 0, so {index} of the body images still starts at 1
+	index := -1
+	config.ImageCount = &index
+
+	localImagePath, err := downloadImage(imageURL, pageID, external, config)
+	if err != nil {
+		config.logWarn("Failed to download image: %v", err)
+		return imageURL
+	}
+	if err := config.Manifest.RecordFile(config.imagePath(localImagePath), pageID); err != nil {
+		config.logWarn("Failed to record image in manifest: %v", err)
+	}
+	return config.imageURL(localImagePath)
+}
+
+// setPageCover sets the coverImage or heroImage field (COVER_IMAGE_FIELD) to the downloaded cover
+// of the page
+func setPageCover(frontmatter *Frontmatter, page notionapi.Page, config runConfig) {
+	if config.CoverImageField == "" || page.Cover == nil || page.Cover.GetURL() == "" {
+		return
+	}
+
+	cover := pageImage(page.Cover.GetURL(), page.ID.String(), page.Cover.Type == "external", config)
+	if config.CoverImageField == "heroImage" {
+		frontmatter.HeroImage = cover
+	} else {
+		frontmatter.CoverImage = cover
+	}
+}
+
+// pageIcon returns the emoji of the page icon, or the URL of its downloaded icon image
+func pageIcon(page notionapi.Page, config runConfig) string {
+	icon := page.Icon
+	if icon == nil || icon.Emoji != nil || icon.GetURL() == "" {
+		return mdrender.CalloutIcon(icon)
+	}
+	return pageImage(icon.GetURL(), page.ID.String(), icon.Type == "external", config)
+}
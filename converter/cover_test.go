@@ -0,0 +1,65 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+)
+
+func TestProcessPageCoverAndIcon(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Hello")},
+	}}}
+	page := titledPage("page", "Covered")
+	page.Cover = &notionapi.Image{Type: "external", External: &notionapi.FileObject{URL: server.URL + "/cover.png"}}
+	emoji := notionapi.Emoji("🚀")
+	page.Icon = &notionapi.Icon{Type: "emoji", Emoji: &emoji}
+
+	dir := t.TempDir()
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir,
+		ImagesDir: filepath.Join(dir, "images"), ImagesURLPrefix: "/images", CoverImageField: "heroImage", EmitIcon: true}
+	processPage(client, *page, config)
+
+	data, err := os.ReadFile(filepath.Join(dir, "Covered.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frontmatter Frontmatter
+	if err := yaml.Unmarshal([]byte(strings.Split(string(data), "---")[1]), &frontmatter); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(frontmatter.HeroImage, "/images/") || frontmatter.CoverImage != "" || frontmatter.Icon != "🚀" {
+		t.Errorf("unexpected cover and icon in:\n%s", data)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "images"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected the downloaded cover, got %v (%v)", entries, err)
+	}
+}
+
+func TestPageIconImage(t *testing.T) {
+	// Icon images are downloaded; without a server the original URL is kept
+	page := *titledPage("page", "Icon")
+	page.Icon = &notionapi.Icon{Type: "external", External: &notionapi.FileObject{URL: "http://127.0.0.1:0/icon.png"}}
+	if icon := pageIcon(page, runConfig{ImagesDir: t.TempDir()}); icon != "http://127.0.0.1:0/icon.png" {
+		t.Errorf("pageIcon() = %q", icon)
+	}
+}
@@ -0,0 +1,233 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dashboardImageExtensions are the extensions of the manifest files counted as images
+var dashboardImageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".avif": true, ".svg": true,
+}
+
+// dashboardPost is an exported page counted on the dashboard
+type dashboardPost struct {
+	collection string
+	month      string // YYYY-MM of publishedAt, or of date when the page has none
+	tags       []string
+	draft      bool
+}
+
+// Dashboard collects the pages of a run for STATS_DASHBOARD_FILE, a static stats.html showing
+// the publishing cadence, the tags, the image storage and the sync history.
+// A nil *Dashboard records nothing.
+type Dashboard struct {
+	mu    sync.Mutex
+	posts []dashboardPost
+}
+
+// AddPage counts an exported or unchanged page of collection
+func (d *Dashboard) AddPage(collection string, frontmatter Frontmatter) {
+	if d == nil {
+		return
+	}
+
+	date := frontmatter.publishedDate()
+	if len(date) >= 7 {
+		date = date[:7]
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.posts = append(d.posts, dashboardPost{collection: collection, month: date, tags: frontmatter.Tags, draft: frontmatter.Draft})
+}
+
+// dashboardCount is a row of a dashboard table; Percent sizes its bar relative to the largest row
+type dashboardCount struct {
+	Label   string
+	Count   int
+	Percent int
+}
+
+// dashboardData is what dashboardTemplate renders
+type dashboardData struct {
+	Generated   string
+	Posts       int
+	Drafts      int
+	Collections []dashboardCount
+	Months      []dashboardCount
+	Tags        []dashboardCount
+	ImageCount  int
+	ImageSize   string
+	Runs        []SyncRun
+}
+
+// Write renders the dashboard to path. Images are the image files in the manifest; the sync
+// history comes from the sync state file.
+func (d *Dashboard) Write(path string, config runConfig, now time.Time) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	data := dashboardData{Generated: now.Format("2006-01-02 15:04")}
+	collections, months, tags := map[string]int{}, map[string]int{}, map[string]int{}
+	for _, post := range d.posts {
+		if post.draft {
+			data.Drafts++
+			continue
+		}
+		data.Posts++
+		collections[post.collection]++
+		if post.month != "" {
+			months[post.month]++
+		}
+		for _, tag := range post.tags {
+			tags[tag]++
+		}
+	}
+	d.mu.Unlock()
+
+	data.Collections = dashboardCounts(collections, false)
+	data.Months = dashboardCounts(months, true)
+	data.Tags = dashboardCounts(tags, false)
+
+	var size int64
+	for file := range config.Manifest.Pages() {
+		if !dashboardImageExtensions[strings.ToLower(filepath.Ext(file))] {
+			continue
+		}
+		if info, err := config.Workspace.Stat(filepath.FromSlash(file)); err == nil {
+			data.ImageCount++
+			size += info.Size()
+		}
+	}
+	data.ImageSize = formatByteSize(size)
+
+	runs := config.SyncState.RunHistory()
+	for i := len(runs) - 1; i >= 0; i-- {
+		data.Runs = append(data.Runs, runs[i])
+	}
+
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to render dashboard: %v", err)
+	}
+	if err := config.Workspace.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create dashboard directory: %v", err)
+	}
+	if err := config.Workspace.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write dashboard %s: %v", path, err)
+	}
+	return nil
+}
+
+// dashboardCounts turns counts into table rows, ordered by label when chronological and by
+// count otherwise
+func dashboardCounts(counts map[string]int, chronological bool) []dashboardCount {
+	rows := make([]dashboardCount, 0, len(counts))
+	largest := 0
+	for label, count := range counts {
+		rows = append(rows, dashboardCount{Label: label, Count: count})
+		largest = max(largest, count)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !chronological && rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Label < rows[j].Label
+	})
+	for i := range rows {
+		rows[i].Percent = rows[i].Count * 100 / largest
+	}
+	return rows
+}
+
+// formatByteSize formats a file size in B, KB, MB or GB
+func formatByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, suffix := float64(size)/unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// dashboardTemplate is the self-contained stats.html page
+var dashboardTemplate = template.Must(template.New("stats.html").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>notion-to-astro-go stats</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem auto; max-width: 48rem; padding: 0 1rem; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: 0.25rem 0.5rem; border-bottom: 1px solid #eee; }
+td.count { text-align: right; width: 4rem; }
+td.bar { width: 60%; }
+td.bar span { display: block; height: 0.8rem; background: #4c7ef3; }
+.summary { display: flex; gap: 2rem; margin-bottom: 2rem; }
+.summary div { font-size: 1.5rem; }
+.summary small { display: block; font-size: 0.8rem; color: #666; }
+</style>
+</head>
+<body>
+<h1>Publishing stats</h1>
+<p>Generated {{.Generated}}</p>
+
+<div class="summary">
+<div>{{.Posts}}<small>posts</small></div>
+<div>{{.Drafts}}<small>drafts</small></div>
+<div>{{.ImageCount}}<small>images</small></div>
+<div>{{.ImageSize}}<small>image storage</small></div>
+</div>
+
+<h2>Collections</h2>
+<table>
+{{- range .Collections}}
+<tr><td>{{.Label}}</td><td class="count">{{.Count}}</td><td class="bar"><span style="width: {{.Percent}}%"></span></td></tr>
+{{- end}}
+</table>
+
+<h2>Posts per month</h2>
+<table>
+{{- range .Months}}
+<tr><td>{{.Label}}</td><td class="count">{{.Count}}</td><td class="bar"><span style="width: {{.Percent}}%"></span></td></tr>
+{{- else}}
+<tr><td>No dated posts</td></tr>
+{{- end}}
+</table>
+
+<h2>Tags</h2>
+<table>
+{{- range .Tags}}
+<tr><td>{{.Label}}</td><td class="count">{{.Count}}</td><td class="bar"><span style="width: {{.Percent}}%"></span></td></tr>
+{{- else}}
+<tr><td>No tags</td></tr>
+{{- end}}
+</table>
+
+<h2>Sync history</h2>
+<table>
+<tr><th>Run</th><th>Exported</th><th>Unchanged</th></tr>
+{{- range .Runs}}
+<tr><td>{{.Time}}</td><td>{{.Exported}}</td><td>{{.Unchanged}}</td></tr>
+{{- else}}
+<tr><td colspan="3">No history; set SYNC_STATE_FILE to record the runs</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
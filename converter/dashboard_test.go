@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardWrite(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "images", "photo.jpg")
+	if err := os.MkdirAll(filepath.Dir(imagePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imagePath, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.RecordFile(imagePath, "page"); err != nil {
+		t.Fatal(err)
+	}
+	state, err := loadSyncState(filepath.Join(dir, "sync.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.RecordRun(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), 3, 10)
+
+	dashboard := &Dashboard{}
+	dashboard.AddPage("blog", Frontmatter{Date: "2024-04-02", PublishedAt: "2024-05-01", Tags: []string{"go", "<astro>"}})
+	dashboard.AddPage("blog", Frontmatter{Date: "2024-05-20", Tags: []string{"go"}})
+	dashboard.AddPage("diary", Frontmatter{Date: "2024-03-01"})
+	dashboard.AddPage("blog", Frontmatter{Date: "2024-05-21", Draft: true})
+
+	path := filepath.Join(dir, "stats.html")
+	config := runConfig{Manifest: manifest, SyncState: state}
+	if err := dashboard.Write(path, config, time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+	for _, expected := range []string{
+		`<div>3<small>posts</small></div>`,
+		`<div>1<small>drafts</small></div>`,
+		`<div>2.0 KB<small>image storage</small></div>`,
+		`<tr><td>2024-03</td><td class="count">1</td><td class="bar"><span style="width: 50%"></span></td></tr>
+<tr><td>2024-05</td><td class="count">2</td>`,
+		`<tr><td>go</td><td class="count">2</td>`,
+		`&lt;astro&gt;`,
+		`<tr><td>2024-05-01 09:00</td><td>3</td><td>10</td></tr>`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected %q in dashboard:\n%s", expected, html)
+		}
+	}
+}
+
+func TestSyncStateRunHistory(t *testing.T) {
+	state, err := loadSyncState(filepath.Join(t.TempDir(), "sync.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxSyncRuns+5; i++ {
+		state.RecordRun(start.Add(time.Duration(i)*time.Hour), i, 0)
+	}
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := loadSyncState(state.path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := reloaded.RunHistory()
+	if len(history) != maxSyncRuns || history[0].Exported != 5 {
+		t.Errorf("expected the last %d runs, got %d starting at %+v", maxSyncRuns, len(history), history[0])
+	}
+}
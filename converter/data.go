@@ -0,0 +1,229 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+
+	"notion-to-astro-go/internal/mdrender"
+	"notion-to-astro-go/internal/writer"
+)
+
+// exportDataCollection writes the pages of a database with the data profile to its data file
+// instead of exporting them as posts: a JSON or YAML list with an entry per page, e.g. for an
+// Astro data collection or a component importing src/data/books.json
+func exportDataCollection(config runConfig) error {
+	client, database, pages, errs, err := fetchDatabase(config)
+	if err != nil {
+		return err
+	}
+	entries := dataEntries(client, pages, config)
+	if err := <-errs; err != nil {
+		// Keep the previous data file rather than writing part of the database
+		return notionError("failed to query database: %w", err)
+	}
+	config.logInfo("Found %d entries in Notion database", len(entries))
+
+	if err := saveDataFile(config.DataFile, entries, config.Workspace); err != nil {
+		config.logWarn("%v", err)
+		config.Report.AddFailedPage(config.DataFile, err)
+		return nil
+	}
+	if err := config.Manifest.RecordFile(config.DataFile, database.ID.String()); err != nil {
+		config.logWarn("Failed to record data file in manifest: %v", err)
+	}
+	config.Report.AddExportedPage(config.DataFile)
+	config.logInfo("Wrote data file: %s", config.DataFile)
+	return nil
+}
+
+// dataEntries converts the pages of a data database to entries of the data file, in the order
+// of the sorts of the config file, or else in the order the pages were created. Each entry holds the dashless page ID as id and the value of every
+// non-empty property under the property name.
+func dataEntries(client *notionapi.Client, pages <-chan notionapi.Page, config runConfig) []map[string]any {
+	var fetched []notionapi.Page
+	for page := range pages {
+		if reason, excluded := excludedPage(page, config); excluded {
+			config.logInfo("Skipping page %s: %s", page.ID, reason)
+			continue
+		}
+		fetched = append(fetched, page)
+	}
+	if len(config.querySorts()) == 0 {
+		slices.SortStableFunc(fetched, func(a, b notionapi.Page) int {
+			return a.CreatedTime.Compare(b.CreatedTime)
+		})
+	}
+
+	entries := []map[string]any{}
+	for _, page := range fetched {
+		entry := map[string]any{"id": strings.ReplaceAll(page.ID.String(), "-", "")}
+		for name, prop := range page.Properties {
+			if value, ok := dataValue(client, page, prop, config); ok {
+				entry[name] = value
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// dataValue converts a property to a JSON value: text, selects, URLs and unique IDs become
+// strings, numbers and checkboxes stay numbers and booleans, multi-selects, people, relations
+// and files become lists, and dates become YYYY-MM-DD (with the time when they have one), or
+// {start, end} for ranges. Files are downloaded like images. ok is false for empty properties
+// and for buttons and other types without a value.
+func dataValue(client *notionapi.Client, page notionapi.Page, prop notionapi.Property, config runConfig) (any, bool) {
+	switch p := prop.(type) {
+	case *notionapi.TitleProperty:
+		return dataText(mdrender.PlainText(p.Title))
+	case *notionapi.RichTextProperty:
+		return dataText(mdrender.PlainText(p.RichText))
+	case *notionapi.TextProperty:
+		return dataText(mdrender.PlainText(p.Text))
+	case *notionapi.NumberProperty:
+		return p.Number, true
+	case *notionapi.CheckboxProperty:
+		return p.Checkbox, true
+	case *notionapi.SelectProperty:
+		return dataText(p.Select.Name)
+	case *notionapi.StatusProperty:
+		return dataText(p.Status.Name)
+	case *notionapi.MultiSelectProperty:
+		names := []string{}
+		for _, option := range p.MultiSelect {
+			names = append(names, option.Name)
+		}
+		return names, true
+	case *notionapi.DateProperty:
+		return dataDate(p.Date)
+	case *notionapi.URLProperty:
+		return dataText(p.URL)
+	case *notionapi.EmailProperty:
+		return dataText(p.Email)
+	case *notionapi.PhoneNumberProperty:
+		return dataText(p.PhoneNumber)
+	case *notionapi.PeopleProperty:
+		names := []string{}
+		for _, user := range p.People {
+			names = append(names, config.Users.Name(config.context(), client, user))
+		}
+		return names, true
+	case *notionapi.CreatedByProperty:
+		return dataText(config.Users.Name(config.context(), client, p.CreatedBy))
+	case *notionapi.LastEditedByProperty:
+		return dataText(config.Users.Name(config.context(), client, p.LastEditedBy))
+	case *notionapi.RelationProperty:
+		ids := []string{}
+		for _, relation := range p.Relation {
+			ids = append(ids, strings.ReplaceAll(relation.ID.String(), "-", ""))
+		}
+		return ids, true
+	case *notionapi.FilesProperty:
+		urls := []string{}
+		for _, file := range p.Files {
+			if file.External != nil && file.External.URL != "" {
+				urls = append(urls, pageImage(file.External.URL, page.ID.String(), true, config))
+			} else if file.File != nil && file.File.URL != "" {
+				urls = append(urls, pageImage(file.File.URL, page.ID.String(), false, config))
+			}
+		}
+		return urls, true
+	case *notionapi.FormulaProperty:
+		switch p.Formula.Type {
+		case notionapi.FormulaTypeString:
+			return dataText(p.Formula.String)
+		case notionapi.FormulaTypeNumber:
+			return p.Formula.Number, true
+		case notionapi.FormulaTypeBoolean:
+			return p.Formula.Boolean, true
+		case notionapi.FormulaTypeDate:
+			return dataDate(p.Formula.Date)
+		}
+	case *notionapi.RollupProperty:
+		switch p.Rollup.Type {
+		case notionapi.RollupTypeNumber:
+			return p.Rollup.Number, true
+		case notionapi.RollupTypeDate:
+			return dataDate(p.Rollup.Date)
+		case notionapi.RollupTypeArray:
+			values := []any{}
+			for _, item := range p.Rollup.Array {
+				if value, ok := dataValue(client, page, item, config); ok {
+					values = append(values, value)
+				}
+			}
+			return values, true
+		}
+	case *notionapi.UniqueIDProperty:
+		return p.UniqueID.String(), true
+	case *notionapi.CreatedTimeProperty:
+		return p.CreatedTime.UTC().Format(time.RFC3339), true
+	case *notionapi.LastEditedTimeProperty:
+		return p.LastEditedTime.UTC().Format(time.RFC3339), true
+	}
+	return nil, false
+}
+
+// dataText returns text as a value, or ok false when it is empty
+func dataText(text string) (any, bool) {
+	return text, text != ""
+}
+
+// dataDate returns the start of date, or its start and end when it is a range
+func dataDate(date *notionapi.DateObject) (any, bool) {
+	if date == nil || date.Start == nil {
+		return nil, false
+	}
+	if date.End == nil {
+		return dataDateText(*date.Start), true
+	}
+	return map[string]string{"start": dataDateText(*date.Start), "end": dataDateText(*date.End)}, true
+}
+
+// dataDateText formats a date without a time as YYYY-MM-DD and other dates as RFC 3339
+func dataDateText(date notionapi.Date) string {
+	t := time.Time(date)
+	if t.Location() == time.UTC && t.Equal(t.Truncate(24*time.Hour)) {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// isDataFile reports whether path has the extension of a JSON or YAML data file
+func isDataFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// saveDataFile writes entries to path as JSON, or as YAML when path ends in .yaml or .yml
+func saveDataFile(path string, entries []map[string]any, workspace *writer.Workspace) error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(entries)
+	default:
+		data, err = json.MarshalIndent(entries, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode data file: %v", err)
+	}
+	if err := workspace.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create data file directory: %v", err)
+	}
+	if err := workspace.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write data file %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDataEntries(t *testing.T) {
+	day := func(s string) *notionapi.Date {
+		parsed, _ := time.Parse("2006-01-02", s)
+		date := notionapi.Date(parsed)
+		return &date
+	}
+	book := notionapi.Page{
+		ID:          "1234abcd-0000-0000-0000-000000000001",
+		CreatedTime: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		Properties: notionapi.Properties{
+			"Name":    &notionapi.TitleProperty{Title: richText("Dune")},
+			"Rating":  &notionapi.NumberProperty{Number: 4.5},
+			"Genres":  &notionapi.MultiSelectProperty{MultiSelect: []notionapi.Option{{Name: "SF"}, {Name: "Classic"}}},
+			"Read":    &notionapi.DateProperty{Date: &notionapi.DateObject{Start: day("2024-04-01"), End: day("2024-04-20")}},
+			"Owned":   &notionapi.CheckboxProperty{Checkbox: true},
+			"Shelf":   &notionapi.SelectProperty{},
+			"Notes":   &notionapi.RichTextProperty{},
+			"Author":  &notionapi.RelationProperty{Relation: []notionapi.Relation{{ID: "5678abcd-0000-0000-0000-000000000002"}}},
+			"Website": &notionapi.URLProperty{URL: "https://example.com/dune"},
+		},
+	}
+	earlier := notionapi.Page{
+		ID:          "earlier",
+		CreatedTime: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Properties: notionapi.Properties{
+			"Name":     &notionapi.TitleProperty{Title: richText("Solaris")},
+			"Finished": &notionapi.DateProperty{Date: &notionapi.DateObject{Start: day("2024-03-01")}},
+		},
+	}
+	skipped := notionapi.Page{ID: "skipped", Properties: notionapi.Properties{"Name": &notionapi.TitleProperty{Title: richText("Skipped")}}}
+
+	pages := make(chan notionapi.Page, 3)
+	pages <- book
+	pages <- earlier
+	pages <- skipped
+	close(pages)
+	entries := dataEntries(nil, pages, runConfig{SkipPages: []string{"skipped"}})
+
+	want := []map[string]any{
+		{"id": "earlier", "Name": "Solaris", "Finished": "2024-03-01"},
+		{
+			"id":      "1234abcd000000000000000000000001",
+			"Name":    "Dune",
+			"Rating":  4.5,
+			"Genres":  []string{"SF", "Classic"},
+			"Read":    map[string]string{"start": "2024-04-01", "end": "2024-04-20"},
+			"Owned":   true,
+			"Author":  []string{"5678abcd000000000000000000000002"},
+			"Website": "https://example.com/dune",
+		},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("dataEntries() = %v, want %v", entries, want)
+	}
+}
+
+func TestSaveDataFile(t *testing.T) {
+	dir := t.TempDir()
+	entries := []map[string]any{{"id": "a", "Name": "Dune", "Rating": 4.5}}
+
+	jsonPath := filepath.Join(dir, "data", "books.json")
+	if err := saveDataFile(jsonPath, entries, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded[0]["Name"] != "Dune" || !strings.HasSuffix(string(data), "]\n") {
+		t.Errorf("unexpected JSON data file (%v):\n%s", err, data)
+	}
+
+	yamlPath := filepath.Join(dir, "books.yml")
+	if err := saveDataFile(yamlPath, entries, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded = nil
+	if err := yaml.Unmarshal(data, &decoded); err != nil || decoded[0]["Rating"] != 4.5 {
+		t.Errorf("unexpected YAML data file (%v):\n%s", err, data)
+	}
+
+	// An empty database is written as an empty list rather than null
+	if err := saveDataFile(jsonPath, dataEntries(nil, closedPages(), runConfig{}), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(jsonPath); string(data) != "[]\n" {
+		t.Errorf("expected an empty list, got %q", data)
+	}
+}
+
+func TestDataDatabaseConfig(t *testing.T) {
+	config := runConfig{BlogOutputDir: "content/blog", Databases: []DatabaseDefinition{
+		{Name: "books", Profile: "data", DataFile: "src/data/books.json", DatabaseFileConfig: DatabaseFileConfig{DatabaseID: "books-db"}},
+	}}
+	books := config.databaseConfig("books")
+	if books.DatabaseType != "data" || books.databaseID() != "books-db" || books.DataFile != "src/data/books.json" {
+		t.Errorf("databaseConfig(books) = %+v", books)
+	}
+	// Without a mapping the query is not filtered on checkboxes the database does not have
+	if filter := databaseQueryFilter(books.properties()); len(filter) != 0 {
+		t.Errorf("expected no query filter, got %v", filter)
+	}
+	// Pruning one data database leaves the blog and the other data files alone
+	config.DatabaseType = "books"
+	if dirs := prunedDirs(config); len(dirs) != 0 {
+		t.Errorf("prunedDirs() = %v", dirs)
+	}
+}
+
+// closedPages returns a channel without pages
+func closedPages() <-chan notionapi.Page {
+	pages := make(chan notionapi.Page)
+	close(pages)
+	return pages
+}
@@ -0,0 +1,208 @@
+package converter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// builtinDatabaseTypes are the -type values that are not names of additional databases
+var builtinDatabaseTypes = []string{"blog", "diary", "pages", "all"}
+
+// DatabaseDefinition is an additional Notion database listed under databases: in the config
+// file. Its pages are exported like those of the blog or diary database, as chosen by its
+// profile, into its own directory, or with the data profile as entries of a single data file;
+// -type selects it by name.
+type DatabaseDefinition struct {
+	Name               string `yaml:"name"`
+	Profile            string `yaml:"profile,omitempty"`  // "blog" (default), "diary" or "data": frontmatter and file naming
+	DataFile           string `yaml:"dataFile,omitempty"` // JSON or YAML file of the data profile, e.g. src/data/books.json
+	DatabaseFileConfig `yaml:",inline"`
+}
+
+// profile returns the database type the pages of the database are exported as
+func (d DatabaseDefinition) profile() string {
+	return orDefault(d.Profile, "blog")
+}
+
+// validateDatabases reports an additional database without a unique name, a database ID or an
+// output directory (a data file for the data profile), or with an unknown profile
+func validateDatabases(databases []DatabaseDefinition) error {
+	seen := map[string]bool{}
+	for _, name := range builtinDatabaseTypes {
+		seen[name] = true
+	}
+	for i, database := range databases {
+		switch {
+		case database.Name == "":
+			return fmt.Errorf("databases[%d] has no name", i)
+		case seen[database.Name]:
+			return fmt.Errorf("database name %q is reserved or used twice", database.Name)
+		case database.DatabaseID == "":
+			return fmt.Errorf("database %q has no databaseId", database.Name)
+		case database.profile() == "data" && database.DataFile == "":
+			return fmt.Errorf("database %q has no dataFile", database.Name)
+		case database.profile() == "data" && !isDataFile(database.DataFile):
+			return fmt.Errorf("database %q has an invalid dataFile %q; must end in .json, .yaml or .yml", database.Name, database.DataFile)
+		case database.profile() != "data" && database.OutputDir == "":
+			return fmt.Errorf("database %q has no outputDir", database.Name)
+		case database.profile() != "blog" && database.profile() != "diary" && database.profile() != "data":
+			return fmt.Errorf("database %q has an invalid profile %q; must be 'blog', 'diary' or 'data'", database.Name, database.Profile)
+		}
+		if err := validateFilenamePattern(database.Filename); err != nil {
+			return fmt.Errorf("database %q: %v", database.Name, err)
+		}
+		if err := validateSlugStrategy(database.SlugStrategy); err != nil {
+			return fmt.Errorf("database %q: %v", database.Name, err)
+		}
+		if err := validateQuery(database.Filter, database.Sorts); err != nil {
+			return fmt.Errorf("database %q: %v", database.Name, err)
+		}
+		seen[database.Name] = true
+	}
+	return nil
+}
+
+// findDatabase returns the additional database called name
+func (c runConfig) findDatabase(name string) (DatabaseDefinition, bool) {
+	for _, database := range c.Databases {
+		if database.Name == name {
+			return database, true
+		}
+	}
+	return DatabaseDefinition{}, false
+}
+
+// databaseConfig returns the configuration for exporting the database called name. An
+// additional database takes the place of the blog or diary database of its profile.
+func (c runConfig) databaseConfig(name string) runConfig {
+	dbConfig := c
+	dbConfig.DatabaseType = name
+	database, ok := c.findDatabase(name)
+	if !ok {
+		return dbConfig
+	}
+
+	dbConfig.DatabaseType = database.profile()
+	dbConfig.DatabaseName = database.Name
+	if dbConfig.DatabaseType == "data" {
+		// Data databases rarely have the published and done checkboxes, so they only filter
+		// on the checkboxes set in the mapping
+		props := database.Properties
+		props.Published, props.Done = orDefault(props.Published, "-"), orDefault(props.Done, "-")
+		dbConfig.NotionBlogDatabaseID, dbConfig.DataFile = database.DatabaseID, database.DataFile
+		dbConfig.BlogOutputDir, dbConfig.BlogImagesSubdir = filepath.Dir(database.DataFile), database.ImagesSubdir
+		dbConfig.BlogProperties, dbConfig.BlogRequired = props, nil
+		dbConfig.BlogFilter, dbConfig.BlogSorts = database.Filter, database.Sorts
+	} else if dbConfig.DatabaseType == "diary" {
+		dbConfig.NotionDiaryDatabaseID, dbConfig.DiaryOutputDir = database.DatabaseID, database.OutputDir
+		dbConfig.DiaryImagesSubdir, dbConfig.DiaryFilename = database.ImagesSubdir, database.Filename
+		dbConfig.DiarySlugStrategy = database.SlugStrategy
+		dbConfig.DiaryProperties, dbConfig.DiaryRequired = database.Properties, database.Required
+		dbConfig.DiaryFilter, dbConfig.DiarySorts = database.Filter, database.Sorts
+	} else {
+		dbConfig.NotionBlogDatabaseID, dbConfig.BlogOutputDir = database.DatabaseID, database.OutputDir
+		dbConfig.BlogImagesSubdir, dbConfig.BlogFilename = database.ImagesSubdir, database.Filename
+		dbConfig.BlogSlugStrategy = database.SlugStrategy
+		dbConfig.BlogProperties, dbConfig.BlogRequired = database.Properties, database.Required
+		dbConfig.BlogFilter, dbConfig.BlogSorts = database.Filter, database.Sorts
+	}
+	return dbConfig
+}
+
+// selectedDatabases returns the names of the databases exported by the current -type: all
+// databases for "all", otherwise the selected one
+func (c runConfig) selectedDatabases() []string {
+	if c.DatabaseType != "all" {
+		return []string{c.DatabaseType}
+	}
+
+	var names []string
+	for _, name := range []string{"blog", "diary"} {
+		// With additional databases, a built-in database without an ID is simply not used
+		if len(c.Databases) == 0 || c.databaseConfig(name).databaseID() != "" {
+			names = append(names, name)
+		}
+	}
+	for _, database := range c.Databases {
+		names = append(names, database.Name)
+	}
+	return names
+}
+
+// databaseNames returns the names of all databases: the built-in types and the additional databases
+func (c runConfig) databaseNames() []string {
+	names := []string{"blog", "diary", "pages"}
+	for _, database := range c.Databases {
+		names = append(names, database.Name)
+	}
+	return names
+}
+
+// databaseID returns the ID of the database of the current type
+func (c runConfig) databaseID() string {
+	if c.DatabaseType == "diary" {
+		return c.NotionDiaryDatabaseID
+	}
+	return c.NotionBlogDatabaseID
+}
+
+// outputDir returns the output directory of the current database type
+func (c runConfig) outputDir() string {
+	switch c.DatabaseType {
+	case "diary":
+		return c.DiaryOutputDir
+	case "pages":
+		return c.PagesOutputDir
+	}
+	return c.BlogOutputDir
+}
+
+// collectionName returns the name of the database being processed: the name of an additional
+// database, or its type
+func (c runConfig) collectionName() string {
+	if c.DatabaseName != "" {
+		return c.DatabaseName
+	}
+	return c.DatabaseType
+}
+
+// routePrefix returns the site path the pages of the current database are served under
+func (c runConfig) routePrefix() string {
+	if c.DatabaseName != "" {
+		return "/" + astroSlug(c.DatabaseName)
+	}
+	switch c.DatabaseType {
+	case "blog", "diary":
+		return "/" + c.DatabaseType
+	}
+	return ""
+}
+
+// filenamePattern returns the file name pattern of the current database type
+func (c runConfig) filenamePattern() string {
+	switch c.DatabaseType {
+	case "blog":
+		return c.BlogFilename
+	case "diary":
+		return c.DiaryFilename
+	}
+	return ""
+}
+
+// validateFilenamePattern reports an unknown placeholder in a file name pattern
+func validateFilenamePattern(pattern string) error {
+	rest := strings.NewReplacer("{title}", "", "{slug}", "", "{date}", "", "{id}", "").Replace(pattern)
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("unknown placeholder in filename %q; use {title}, {slug}, {date} and {id}", pattern)
+	}
+	return nil
+}
+
+// expandFilenamePattern fills in a file name pattern: {title} is the page title with invalid
+// file name characters replaced, {slug} the slug of the page, {date} the date of the page and
+// {id} the page ID
+func expandFilenamePattern(pattern, title, slug, date, id string) string {
+	name := strings.NewReplacer("{title}", title, "{slug}", slug, "{date}", date, "{id}", id).Replace(pattern)
+	return strings.ReplaceAll(name, "/", "_")
+}
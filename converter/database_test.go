@@ -0,0 +1,123 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestDatabaseConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notion-to-astro.yaml")
+	yaml := `blog:
+  databaseId: blog-db
+diary:
+  databaseId: diary-db
+databases:
+  - name: notes
+    databaseId: notes-db
+    outputDir: ./content/notes
+    imagesSubdir: notes
+    filename: "{date}-{title}"
+    properties:
+      title: Subject
+  - name: journal
+    profile: diary
+    databaseId: journal-db
+    outputDir: ./content/journal
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileConfig, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateDatabases(fileConfig.Databases); err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{
+		DatabaseType:          "all",
+		NotionBlogDatabaseID:  fileConfig.Blog.DatabaseID,
+		NotionDiaryDatabaseID: fileConfig.Diary.DatabaseID,
+		BlogOutputDir:         "./content/blog",
+		Databases:             fileConfig.Databases,
+	}
+
+	if got, want := config.selectedDatabases(), []string{"blog", "diary", "notes", "journal"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selectedDatabases() = %v, want %v", got, want)
+	}
+
+	notes := config.databaseConfig("notes")
+	if notes.DatabaseType != "blog" || notes.databaseID() != "notes-db" || notes.outputDir() != "./content/notes" ||
+		notes.imagesSubdir() != "notes" || notes.properties().Title != "Subject" || notes.filenamePattern() != "{date}-{title}" {
+		t.Errorf("databaseConfig(notes) = %+v", notes)
+	}
+	journal := config.databaseConfig("journal")
+	if journal.DatabaseType != "diary" || journal.databaseID() != "journal-db" || journal.routePrefix() != "/journal" {
+		t.Errorf("databaseConfig(journal) = %+v", journal)
+	}
+	if blog := config.databaseConfig("blog"); blog.databaseID() != "blog-db" || blog.routePrefix() != "/blog" {
+		t.Errorf("databaseConfig(blog) = %+v", blog)
+	}
+
+	route, ok := pageRoute(notes, filepath.Join("content", "notes", "2024-01-01-Hello World.md"))
+	if !ok || route != "/notes/2024-01-01-hello-world" {
+		t.Errorf("pageRoute() = %q, %v; want /notes/2024-01-01-hello-world", route, ok)
+	}
+}
+
+func TestValidateDatabases(t *testing.T) {
+	valid := DatabaseDefinition{Name: "notes", DatabaseFileConfig: DatabaseFileConfig{DatabaseID: "db", OutputDir: "out"}}
+	if err := validateDatabases([]DatabaseDefinition{valid}); err != nil {
+		t.Errorf("validateDatabases() error = %v", err)
+	}
+
+	invalid := map[string]func(*DatabaseDefinition){
+		"no name":          func(d *DatabaseDefinition) { d.Name = "" },
+		"reserved name":    func(d *DatabaseDefinition) { d.Name = "blog" },
+		"no database ID":   func(d *DatabaseDefinition) { d.DatabaseID = "" },
+		"no output dir":    func(d *DatabaseDefinition) { d.OutputDir = "" },
+		"unknown profile":  func(d *DatabaseDefinition) { d.Profile = "pages" },
+		"unknown filename": func(d *DatabaseDefinition) { d.Filename = "{name}" },
+		"no data file":     func(d *DatabaseDefinition) { d.Profile = "data" },
+		"not a data file":  func(d *DatabaseDefinition) { d.Profile, d.DataFile = "data", "books.csv" },
+	}
+	for name, change := range invalid {
+		database := valid
+		change(&database)
+		if err := validateDatabases([]DatabaseDefinition{database}); err == nil {
+			t.Errorf("%s: validateDatabases() accepted %+v", name, database)
+		}
+	}
+	if err := validateDatabases([]DatabaseDefinition{valid, valid}); err == nil {
+		t.Error("validateDatabases() accepted a duplicate name")
+	}
+
+	// A data database needs a data file instead of an output directory
+	data := DatabaseDefinition{Name: "books", Profile: "data", DataFile: "src/data/books.yml", DatabaseFileConfig: DatabaseFileConfig{DatabaseID: "db"}}
+	if err := validateDatabases([]DatabaseDefinition{data}); err != nil {
+		t.Errorf("validateDatabases() error = %v", err)
+	}
+}
+
+func TestProcessPageAdditionalDatabase(t *testing.T) {
+	dir := t.TempDir()
+	config := runConfig{
+		LineBreakStyle: "spaces",
+		Databases: []DatabaseDefinition{{
+			Name:               "notes",
+			DatabaseFileConfig: DatabaseFileConfig{DatabaseID: "notes-db", OutputDir: dir, Filename: "{date}-{title}"},
+		}},
+	}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{}}}
+	page := titledPage("page", "Hello")
+	processPage(client, *page, config.databaseConfig("notes"))
+
+	want := filepath.Join(dir, page.CreatedTime.Format("2006-01-02")+"-Hello.md")
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected %s: %v", want, err)
+	}
+}
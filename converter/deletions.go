@@ -0,0 +1,86 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jomei/notionapi"
+)
+
+// syncDeletedPages removes the files of pages that were exported before but are now archived,
+// deleted or no longer published in Notion (-sync-deletions). Each page of the collection that
+// was not exported in this run is retrieved to tell why. Unlike -prune, pages that are still
+// published but were not exported for another reason, such as a failed conversion or
+// -mark-published, keep their files.
+func syncDeletedPages(client *notionapi.Client, config runConfig) {
+	pages := config.Manifest.Pages()
+	removed := map[string]bool{}
+	var deleted []string
+	for _, path := range config.Manifest.StaleFiles(config.collectionDirs()) {
+		pageID := pages[path]
+		if len(config.Manifest.SeenFiles(pageID)) > 0 {
+			// An image the exported page stopped using is left to -prune
+			continue
+		}
+		gone, checked := removed[pageID]
+		if !checked {
+			var reason string
+			reason, gone = removedPage(client, pageID, config)
+			if gone {
+				config.logInfo("Page %s was %s in Notion", pageID, reason)
+			}
+			removed[pageID] = gone
+		}
+		if gone {
+			deleted = append(deleted, path)
+		}
+	}
+
+	if len(deleted) == 0 {
+		config.logInfo("No deleted pages to sync")
+		return
+	}
+	pruneFiles(config, deleted)
+}
+
+// removedPage reports whether the page is no longer exported because it was archived or
+// deleted in Notion, was excluded, or had its done checkbox unchecked, and why. A checked
+// published checkbox does not remove the page, since it marks a page that is live on the
+// site. A page that cannot be retrieved for another reason is kept.
+func removedPage(client *notionapi.Client, pageID string, config runConfig) (string, bool) {
+	page, err := client.Page.Get(config.context(), notionapi.PageID(pageID))
+	if err != nil {
+		var apiErr *notionapi.Error
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return "deleted or is no longer shared with the integration", true
+		}
+		config.logWarn("Failed to check page %s, keeping its files: %v", pageID, err)
+		return "", false
+	}
+	if page.Archived {
+		return "archived", true
+	}
+	if reason, excluded := excludedPage(*page, config); excluded {
+		return "excluded: " + reason, true
+	}
+	if config.DatabaseType == "pages" {
+		// Pages of the page tree have no publishing conditions
+		return "", false
+	}
+
+	if name := config.properties().doneProperty(); name != "" && !pageCheckbox(*page, name, true) {
+		return fmt.Sprintf("unpublished: %s is unchecked", name), true
+	}
+	return "", false
+}
+
+// pageCheckbox returns the checkbox property name of the page, or missing when the page has
+// no such checkbox
+func pageCheckbox(page notionapi.Page, name string, missing bool) bool {
+	checkbox, ok := page.Properties[name].(*notionapi.CheckboxProperty)
+	if !ok {
+		return missing
+	}
+	return checkbox.Checkbox
+}
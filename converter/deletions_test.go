@@ -0,0 +1,71 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestSyncDeletedPages(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blogDir := filepath.Join(dir, "blog")
+	if err := os.MkdirAll(blogDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Files of an earlier run, one page per file
+	for _, id := range []string{"exported", "archived", "deleted", "unpublished", "failed"} {
+		path := filepath.Join(blogDir, id+".md")
+		if err := os.WriteFile(path, []byte(id), 0644); err != nil {
+			t.Fatal(err)
+		}
+		manifest.Files[filepath.ToSlash(path)] = ManifestEntry{PageID: id}
+	}
+	manifest.KeepPage("exported")
+
+	checkbox := func(checked bool) *notionapi.CheckboxProperty { return &notionapi.CheckboxProperty{Checkbox: checked} }
+	archived := titledPage("archived", "Archived")
+	archived.Archived = true
+	unpublished := titledPage("unpublished", "Unpublished")
+	unpublished.Properties["done"] = checkbox(false)
+	failed := titledPage("failed", "Failed")
+	failed.Properties["done"] = checkbox(true)
+	failed.Properties["published"] = checkbox(true) // Checked by -mark-published
+	client := &notionapi.Client{Page: &fakePageService{pages: map[notionapi.PageID]*notionapi.Page{
+		"archived": archived, "unpublished": unpublished, "failed": failed,
+	}}}
+
+	config := runConfig{DatabaseType: "blog", BlogOutputDir: blogDir, PruneMode: "delete", MarkPublished: true, Manifest: manifest}
+	syncDeletedPages(client, config)
+
+	for id, kept := range map[string]bool{"exported": true, "archived": false, "deleted": false, "unpublished": false, "failed": true} {
+		_, err := os.Stat(filepath.Join(blogDir, id+".md"))
+		if kept && err != nil {
+			t.Errorf("expected the file of %s to be kept: %v", id, err)
+		} else if !kept && !os.IsNotExist(err) {
+			t.Errorf("expected the file of %s to be removed", id)
+		}
+	}
+	if pages := manifest.Pages(); len(pages) != 2 {
+		t.Errorf("expected the removed files to be forgotten, got %v", pages)
+	}
+}
+
+func TestRemovedPageKeepsPublishedPages(t *testing.T) {
+	published := titledPage("published", "Published")
+	published.Properties["done"] = &notionapi.CheckboxProperty{Checkbox: true}
+	published.Properties["published"] = &notionapi.CheckboxProperty{Checkbox: true}
+	client := &notionapi.Client{Page: &fakePageService{pages: map[notionapi.PageID]*notionapi.Page{"published": published}}}
+
+	for _, markPublished := range []bool{false, true} {
+		config := runConfig{DatabaseType: "blog", MarkPublished: markPublished}
+		if reason, gone := removedPage(client, "published", config); gone {
+			t.Errorf("expected a published page to be kept with -mark-published=%v, got removed as %q", markPublished, reason)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notion-to-astro-go/internal/notion"
+)
+
+// Exit codes of a conversion run, so scripts and CI can tell why a run failed
+const (
+	exitFailure = 1 // The run failed, e.g. the output could not be written
+	exitConfig  = 2 // The configuration or the flags are invalid
+	exitNotion  = 3 // A database or page could not be retrieved from Notion
+	exitPartial = 4 // The run finished, but some pages could not be exported
+	exitBudget  = 5 // The run stopped after NOTION_MAX_REQUESTS Notion API requests
+
+	exitInterrupted = 130 // The run was interrupted, e.g. with Ctrl-C; 128 + SIGINT as in shells
+)
+
+// Causes of a failed run. Programs embedding the converter test the error returned by Convert
+// for them with errors.Is instead of matching its message.
+var (
+	// ErrInvalidConfig is the cause of a run stopped by invalid options, flags or configuration
+	ErrInvalidConfig = errors.New("invalid configuration")
+	// ErrRateLimited is the cause of a Notion API request still answered with 429 Too Many
+	// Requests after NOTION_MAX_ATTEMPTS attempts
+	ErrRateLimited = notion.ErrRateLimited
+	// ErrPageSkipped is the cause of a page that was not exported, e.g. because its frontmatter
+	// was invalid, its content could not be retrieved or its file could not be written. Pages
+	// skipped for empty required properties only match it in the error of a failed run.
+	ErrPageSkipped = errors.New("page skipped")
+	// ErrUnsupportedBlock is the cause of blocks left out of the exported pages because they
+	// cannot be converted. Only the error of a failed run matches it; they do not fail a run.
+	ErrUnsupportedBlock = errors.New("unsupported block")
+)
+
+// causeError is err matching cause, one of the exported errors, with errors.Is. Its message
+// is the message of err.
+type causeError struct {
+	err   error
+	cause error
+}
+
+func (e *causeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *causeError) Unwrap() []error {
+	return []error{e.err, e.cause}
+}
+
+// withCause returns err matching cause with errors.Is
+func withCause(err, cause error) error {
+	return &causeError{err: err, cause: cause}
+}
+
+// runError is an error that ends the run with its exit code
+type runError struct {
+	code int
+	err  error
+}
+
+func (e *runError) Error() string {
+	return e.err.Error()
+}
+
+func (e *runError) Unwrap() error {
+	return e.err
+}
+
+// configError returns an error of an invalid configuration
+func configError(format string, args ...any) error {
+	return &runError{code: exitConfig, err: withCause(fmt.Errorf(format, args...), ErrInvalidConfig)}
+}
+
+// notionError returns an error of a failed Notion request
+func notionError(format string, args ...any) error {
+	return &runError{code: exitNotion, err: fmt.Errorf(format, args...)}
+}
+
+// pagesError ends a run in which some pages could not be exported. It wraps the problems of
+// all pages recorded in the run report, so errors.Is finds their causes.
+type pagesError struct {
+	problems []error
+}
+
+func (e *pagesError) Error() string {
+	return "some pages could not be exported; see the summary"
+}
+
+func (e *pagesError) Unwrap() []error {
+	return e.problems
+}
+
+// pagesFailedError returns the error of a run in which some pages could not be exported
+func pagesFailedError(problems []error) error {
+	return &runError{code: exitPartial, err: &pagesError{problems: problems}}
+}
+
+// interruptedError ends a run that was stopped by canceling ctx
+func interruptedError(ctx context.Context) error {
+	return &runError{code: exitInterrupted, err: fmt.Errorf("run interrupted: %w", context.Cause(ctx))}
+}
+
+// ExitCode returns the exit code of the command line tool for err, an error returned by Convert
+// or another function of the package: 0 for nil, 2 for an invalid configuration, 3 for a
+// failed Notion request, 4 for pages that could not be exported, 5 for an exhausted request
+// budget, 130 for an interrupted run and 1 otherwise
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var runErr *runError
+	if errors.As(err, &runErr) {
+		return runErr.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"notion-to-astro-go/internal/notion"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"configuration", configError("invalid concurrency: %d", 0), exitConfig},
+		{"notion", notionError("failed to get database: %w", errors.New("unauthorized")), exitNotion},
+		{"wrapped", fmt.Errorf("blog: %w", notionError("failed to query database")), exitNotion},
+		{"joined", errors.Join(nil, configError("invalid LAYOUT_MAP")), exitConfig},
+		{"failed pages", pagesFailedError(nil), exitPartial},
+		{"other", errors.New("failed to save manifest"), exitFailure},
+		{"success", nil, 0},
+	}
+
+	for _, tt := range tests {
+		if code := ExitCode(tt.err); code != tt.expected {
+			t.Errorf("%s: ExitCode() = %d, want %d", tt.name, code, tt.expected)
+		}
+	}
+}
+
+func TestErrorCauses(t *testing.T) {
+	err := configError("invalid concurrency: %d. Must be at least 1", 0)
+	if !errors.Is(err, ErrInvalidConfig) || err.Error() != "invalid concurrency: 0. Must be at least 1" {
+		t.Errorf("configError() = %v, want ErrInvalidConfig with the message as it is", err)
+	}
+	if err := notionError("failed to get database"); errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("notionError() = %v, want no ErrInvalidConfig", err)
+	}
+
+	report := newRunReport()
+	report.CountUnsupportedBlock("synced_block")
+	report.AddIncompletePage("Draft", []string{"date"})
+	if err := report.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil for a run without failed pages", err)
+	}
+
+	// The error of a failed run finds the causes of all pages
+	rateLimited := withCause(errors.New("failed after 5 attempts: 429 Too Many Requests"), ErrRateLimited)
+	report.AddContentError("Post", fmt.Errorf("Get \"https://api.notion.com/v1/blocks\": %w", rateLimited), "wrote placeholder")
+	err = report.Err()
+	if ExitCode(err) != exitPartial {
+		t.Errorf("ExitCode() = %d, want %d", ExitCode(err), exitPartial)
+	}
+	for _, cause := range []error{ErrRateLimited, ErrUnsupportedBlock, ErrPageSkipped} {
+		if !errors.Is(err, cause) {
+			t.Errorf("Err() = %v, want it to match %v", err, cause)
+		}
+	}
+	if errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Err() = %v, want no ErrInvalidConfig", err)
+	}
+}
+
+func TestRunErrorCauses(t *testing.T) {
+	captureLog(t, slog.LevelInfo, "text")
+	failed := func(r *RunReport) { r.AddFailedPage("Broken", errors.New("permission denied")) }
+	tests := []struct {
+		name   string
+		record func(r *RunReport)
+		causes []error // nil expects no error
+	}{
+		{"failed page", failed, []error{ErrPageSkipped}},
+		{"rate limited page", func(r *RunReport) {
+			r.AddContentError("Post", withCause(errors.New("429 Too Many Requests"), ErrRateLimited), "skipped")
+		}, []error{ErrRateLimited}},
+		{"incomplete page", func(r *RunReport) { r.AddIncompletePage("Draft", []string{"date"}) }, nil},
+		{"incomplete page in a failed run", func(r *RunReport) {
+			r.AddIncompletePage("Draft", []string{"date"})
+			failed(r)
+		}, []error{ErrPageSkipped}},
+		{"unsupported block", func(r *RunReport) { r.CountUnsupportedBlock("synced_block") }, nil},
+		{"unsupported block in a failed run", func(r *RunReport) {
+			r.CountUnsupportedBlock("synced_block")
+			failed(r)
+		}, []error{ErrUnsupportedBlock, ErrPageSkipped}},
+	}
+
+	for _, tt := range tests {
+		report := newRunReport()
+		tt.record(report)
+		err := report.Err()
+		if tt.causes == nil && err != nil {
+			t.Errorf("%s: Err() = %v, want nil", tt.name, err)
+		}
+		if tt.causes != nil && ExitCode(err) != exitPartial {
+			t.Errorf("%s: ExitCode() = %d, want %d", tt.name, ExitCode(err), exitPartial)
+		}
+		for _, cause := range tt.causes {
+			if !errors.Is(err, cause) {
+				t.Errorf("%s: Err() = %v, want it to match %v", tt.name, err, cause)
+			}
+		}
+	}
+
+	// Invalid options fail the run before it starts
+	if err := Convert(context.Background(), Options{Verbose: true, Quiet: true}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Convert() = %v, want ErrInvalidConfig", err)
+	}
+}
+
+// serverTransport sends the requests of a Notion client to a test server
+type serverTransport struct {
+	server *url.URL
+}
+
+func (t serverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = t.server.Scheme, t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProcessPageRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/blocks/nested/children" {
+			// The page itself has a toggle whose children are rate limited
+			w.Write([]byte(`{"object":"list","has_more":false,"results":[{"object":"block","id":"toggle","type":"toggle","has_children":true,"toggle":{"rich_text":[]}}]}`))
+			return
+		}
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := notion.NewTransport(serverTransport{serverURL}, 0, 2, 0, nil).Client("token")
+
+	for _, pageID := range []string{"page", "nested"} {
+		// A page written with a placeholder reports the cause in the run's error
+		config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), ContentErrorPolicy: "placeholder", Report: newRunReport()}
+		if err := processPage(client, *titledPage(pageID, "Title"), config); err != nil {
+			t.Fatalf("processPage(%s) = %v, want a placeholder", pageID, err)
+		}
+		if err := config.Report.Err(); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Err() for %s = %v, want ErrRateLimited", pageID, err)
+		}
+
+		// A failing page returns the cause
+		config.ContentErrorPolicy = "fail"
+		if err := processPage(client, *titledPage(pageID, "Title"), config); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("processPage(%s) = %v, want ErrRateLimited", pageID, err)
+		}
+	}
+}
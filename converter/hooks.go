@@ -0,0 +1,49 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// runPostProcessFile runs POST_PROCESS_FILE_COMMAND for a generated file, passing its path as
+// the last argument. Failures are reported in the run summary.
+func runPostProcessFile(config runConfig, path string) {
+	if config.PostProcessFileCmd == "" {
+		return
+	}
+	// With staged writes the file is still in the workspace
+	if err := runHookCommand(config.PostProcessFileCmd, config.Workspace.Path(path)); err != nil {
+		config.logWarn("Post-process command failed for %s: %v", path, err)
+		config.Report.AddHookFailure(fmt.Sprintf("%s (%s)", config.PostProcessFileCmd, path), err)
+	}
+}
+
+// runPostProcess runs POST_PROCESS_COMMAND once after all files were generated
+func runPostProcess(config runConfig) {
+	if config.PostProcessCmd == "" {
+		return
+	}
+	config.logInfo("Running post-process command: %s", config.PostProcessCmd)
+	if err := runHookCommand(config.PostProcessCmd); err != nil {
+		config.logWarn("Post-process command failed: %v", err)
+		config.Report.AddHookFailure(config.PostProcessCmd, err)
+	}
+}
+
+// runHookCommand runs command through the shell with args appended, so commands like
+// `prettier --write` can be configured as a single string. The output of the command is
+// passed through to stderr.
+func runHookCommand(command string, args ...string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", strings.Join(append([]string{command}, args...), " "))
+	} else {
+		cmd = exec.Command("sh", append([]string{"-c", command + ` "$@"`, "sh"}, args...)...)
+	}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
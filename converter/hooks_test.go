@@ -0,0 +1,38 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunPostProcessFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	path := filepath.Join(t.TempDir(), "post.md")
+	if err := os.WriteFile(path, []byte("# Post\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := runConfig{PostProcessFileCmd: "echo formatted >>", Report: newRunReport()}
+	runPostProcessFile(config, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "# Post\nformatted\n" {
+		t.Errorf("expected the command to receive the file path, got %q", data)
+	}
+	if len(config.Report.hookFailures) != 0 {
+		t.Errorf("unexpected failures: %v", config.Report.hookFailures)
+	}
+
+	config.PostProcessFileCmd = "exit 3 #"
+	runPostProcessFile(config, path)
+	if len(config.Report.hookFailures) != 1 {
+		t.Errorf("expected the failing command to be reported, got %v", config.Report.hookFailures)
+	}
+}
@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"notion-to-astro-go/internal/images"
+)
+
+// downloadImage downloads an image from a URL, compresses it, and saves it to config.ImagesDir
+// Returns the local path to the image, relative to config.ImagesDir with forward slashes
+// Existing external images are revalidated with a conditional GET when config.RefreshImages is set.
+func downloadImage(imageURL, pageID string, external bool, config runConfig) (string, error) {
+	config.logDebug("Downloading image from URL: %s", imageURL)
+	opts := config.imageOptions()
+
+	// Create a hash of the URL to use as the filename
+	hasher := sha256.New()
+	hasher.Write([]byte(imageSource(imageURL, external)))
+	hash := hex.EncodeToString(hasher.Sum(nil))[:16] // Use first 16 chars of hash
+	config.logDebug("Generated hash for image: %s", hash)
+
+	// The extension of the URL is only a guess; the format is detected once the image is
+	// downloaded, and an image whose URL has no image extension is named after downloading
+	config.logDebug("Extracting file extension...")
+	sourceExt := images.URLExt(imageURL)
+	// IMAGE_FORMAT saves JPEG and PNG images in the new format, with its extension; HEIC images
+	// are saved as JPEG unless IMAGE_FORMAT selects another format
+	ext := opts.FormatExt(sourceExt)
+	config.logDebug("Using file extension: %s", ext)
+
+	// Reuse the file this image was saved to before, whatever IMAGE_FILENAME named it then.
+	// A file outside ImagesDir, such as the images folder of a renamed colocated page, is not.
+	fields := newImageFilenameFields(imageURL, pageID, hash, config)
+	contentNamed := strings.Contains(config.imageFilenameTemplate(), "{content}")
+	filename, reusable := "", false
+	previous := ""
+	if key := config.Manifest.ImageBySource(pageID, hash); key != "" {
+		previous = config.imageRel(filepath.FromSlash(key))
+	}
+	// An image saved in another format before IMAGE_FORMAT was changed is converted again.
+	previousExt := strings.TrimPrefix(path.Ext(previous), ".")
+	currentFormat := previousExt == ext || (ext == "" && opts.FormatExt(previousExt) == previousExt)
+	if previous != "" && !strings.HasPrefix(previous, "../") && currentFormat {
+		filename, reusable = previous, true
+	} else if !contentNamed && ext != "" {
+		// Names without {content} are known before downloading
+		filename, reusable = claimImageFilename(config, imageFilename(config, fields, ext), pageID, hash)
+	}
+	outputPath := ""
+	if filename != "" {
+		outputPath = config.imagePath(filename)
+		config.logDebug("Output path for image: %s", outputPath)
+	}
+
+	// Check if file already exists
+	req := images.Request{URL: imageURL, Headers: config.ImageHeaders, UserAgent: config.ImageUserAgent}
+	if _, err := config.Workspace.Stat(outputPath); outputPath != "" && reusable && err == nil {
+		if !external || !config.RefreshImages {
+			// File exists, return the path
+			config.logDebug("Image already exists at: %s", outputPath)
+			return filename, nil
+		}
+		// Revalidate the external image so an unchanged one is not downloaded again
+		req.ETag, req.LastModified = config.Manifest.ImageValidators(outputPath)
+		config.logDebug("Revalidating existing image: %s", outputPath)
+	}
+
+	// Download next to the output so the image is moved into place without copying it
+	tmpDir := config.imagePath(config.imagesSubdir())
+	if outputPath != "" {
+		tmpDir = filepath.Dir(outputPath)
+	}
+	if err := config.Workspace.MkdirAll(tmpDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %v", err)
+	}
+	download, err := images.Fetch(req, config.Workspace.TempDir(tmpDir), config.Logger)
+	if err != nil {
+		return "", err
+	}
+	if download == nil {
+		config.logDebug("Image not modified: %s", outputPath)
+		return filename, nil
+	}
+	defer os.Remove(download.Path)
+
+	if download.Ext != sourceExt {
+		config.logDebug("Detected image format: %s", download.Ext)
+		if outputPath != "" {
+			config.Manifest.ReleaseImage(outputPath)
+			config.Workspace.Remove(outputPath)
+			filename, outputPath = "", ""
+		}
+		sourceExt, ext = download.Ext, opts.FormatExt(download.Ext)
+	}
+
+	// DEDUPE_IMAGES links the file saved from the same data before, by this or another page,
+	// e.g. an image pasted on several pages or uploaded again
+	if config.DedupeImages {
+		if existing, ok := savedImage(config, download.Content, ext); ok && config.imagePath(existing) != outputPath {
+			if outputPath != "" {
+				config.Manifest.ReleaseImage(outputPath)
+				config.Workspace.Remove(outputPath)
+			}
+			config.logDebug("Image already saved as: %s", existing)
+			config.Manifest.ShareImage(config.imagePath(existing), pageID, hash)
+			return existing, nil
+		}
+	}
+
+	// Names with {content}, and names of images detected after downloading, are only known
+	// once the image data is downloaded
+	if outputPath == "" {
+		fields.content = download.Content[:16]
+		filename, _ = claimImageFilename(config, imageFilename(config, fields, ext), pageID, hash)
+		outputPath = config.imagePath(filename)
+		if err := config.Workspace.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return "", fmt.Errorf("failed to create image directory: %v", err)
+		}
+		config.logDebug("Output path for image: %s", outputPath)
+	}
+
+	saved, err := images.Save(download.Path, sourceExt, ext, opts)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(saved)
+	if err := config.Workspace.Rename(saved, outputPath); err != nil {
+		return "", fmt.Errorf("failed to save image: %v", err)
+	}
+
+	config.Manifest.SetImageContent(outputPath, download.Content)
+	// Keep the validators of external images for conditional GETs on refresh runs
+	if external {
+		config.Manifest.SetImageValidators(outputPath, download.ETag, download.LastModified)
+	}
+
+	config.logDebug("Image successfully saved to: %s", outputPath)
+	return filename, nil
+}
+
+// savedImage returns the image file recorded with the data hash content, relative to ImagesDir,
+// when it exists and is saved as ext. Files outside ImagesDir, such as the images of another
+// colocated page, are not linked.
+func savedImage(config runConfig, content, ext string) (string, bool) {
+	key := config.Manifest.ImageByContent(content)
+	if key == "" {
+		return "", false
+	}
+	existing := config.imageRel(filepath.FromSlash(key))
+	if strings.HasPrefix(existing, "../") || path.Ext(existing) != "."+ext {
+		return "", false
+	}
+	if _, err := config.Workspace.Stat(config.imagePath(existing)); err != nil {
+		return "", false
+	}
+	return existing, true
+}
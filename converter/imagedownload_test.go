@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDownloadImageTranscodesHEIC(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the decode command is run through sh")
+	}
+	// The decoder writes a PNG whatever HEIC it is given
+	dir := t.TempDir()
+	decoded := filepath.Join(dir, "decoded.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(decoded, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	decoder := filepath.Join(dir, "decode")
+	if err := os.WriteFile(decoder, []byte("#!/bin/sh\ncp "+decoded+" \"$2\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00mif1heic"))
+	}))
+	defer server.Close()
+
+	config := runConfig{ImagesDir: t.TempDir(), ImageFormat: "original", ImageDecodeCommand: decoder}
+	filename, err := downloadImage(server.URL+"/IMG_0001.HEIC", "page", true, config)
+	if err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+	if filepath.Ext(filename) != ".jpg" {
+		t.Fatalf("expected a .jpg image, got %q", filename)
+	}
+
+	f, err := os.Open(config.imagePath(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := jpeg.DecodeConfig(f); err != nil {
+		t.Errorf("expected a JPEG image: %v", err)
+	}
+}
+
+func TestDownloadImageResizes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	// Oversized images are scaled down also when they are otherwise kept as downloaded
+	config := runConfig{ImagesDir: t.TempDir(), ImageMaxWidth: 10}
+	filename, err := downloadImage(server.URL+"/wide.png", "page", true, config)
+	if err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+
+	f, err := os.Open(config.imagePath(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved, _, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Width != 10 || saved.Height != 5 {
+		t.Errorf("expected a 10x5 image, got %dx%d", saved.Width, saved.Height)
+	}
+}
@@ -0,0 +1,34 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+
+	"notion-to-astro-go/internal/images"
+)
+
+// imageOptions returns the settings downloaded images are saved with. A custom
+// IMAGE_DECODE_COMMAND is run through the shell with both paths appended.
+func (c runConfig) imageOptions() images.Options {
+	opts := images.Options{
+		Format:          c.ImageFormat,
+		Quality:         c.ImageQuality,
+		Compress:        c.CompressImages,
+		MaxWidth:        c.ImageMaxWidth,
+		MaxHeight:       c.ImageMaxHeight,
+		MaxDecodePixels: c.MaxDecodePixels,
+		Logger:          c.Logger,
+	}
+	if command := c.ImageDecodeCommand; command != "" {
+		opts.Decode = func(srcPath, outputPath string) error {
+			if err := runHookCommand(command, srcPath, outputPath); err != nil {
+				return fmt.Errorf("IMAGE_DECODE_COMMAND failed: %v", err)
+			}
+			if _, err := os.Stat(outputPath); err != nil {
+				return fmt.Errorf("%s wrote no image: %v", command, err)
+			}
+			return nil
+		}
+	}
+	return opts
+}
@@ -0,0 +1,75 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"notion-to-astro-go/internal/images"
+)
+
+// fakeEncoder installs a cwebp on PATH that copies its input and records the arguments
+func fakeEncoder(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake encoder is a shell script")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\ncp \"$4\" \"$6\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "cwebp"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestDownloadImageConvertsFormat(t *testing.T) {
+	log := fakeEncoder(t)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	if err := images.ValidateFormat("webp"); err != nil {
+		t.Fatalf("images.ValidateFormat() error = %v", err)
+	}
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{ImagesDir: t.TempDir(), ImagesURLPrefix: "/images", Manifest: manifest, ImageFormat: "webp", ImageQuality: 60}
+	filename, err := downloadImage(server.URL+"/photo.png", "page", true, config)
+	if err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+
+	if filepath.Ext(filename) != ".webp" {
+		t.Errorf("expected a .webp image, got %q", filename)
+	}
+	if _, err := os.Stat(config.imagePath(filename)); err != nil {
+		t.Errorf("image not saved: %v", err)
+	}
+	args, _ := os.ReadFile(log)
+	if !strings.HasPrefix(string(args), "-quiet -q 60 ") || !strings.HasSuffix(strings.TrimSpace(string(args)), ".webp") {
+		t.Errorf("unexpected encoder arguments %q", args)
+	}
+
+	// Formats other than JPEG and PNG are kept
+	if gif := config.imageOptions().FormatExt("gif"); gif != "gif" {
+		t.Errorf("FormatExt(gif) = %q", gif)
+	}
+	if err := images.ValidateFormat("heic"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
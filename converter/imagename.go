@@ -0,0 +1,134 @@
+package converter
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// imageFilenamePlaceholders lists the placeholders of IMAGE_FILENAME
+var imageFilenamePlaceholders = []string{"{page}", "{slug}", "{index}", "{name}", "{hash}", "{content}"}
+
+// invalidImageFilenameChars matches characters that must not appear in an image file name
+var invalidImageFilenameChars = regexp.MustCompile(`[/\\:*?"<>|\s]`)
+
+// imageFilenameFields are the values of the IMAGE_FILENAME placeholders for one image
+type imageFilenameFields struct {
+	page    string // Page ID
+	slug    string // Slug of the page title
+	index   int    // Position of the image in the page, starting at 1
+	name    string // Slug of the file name in the image URL
+	hash    string // Hash of the image URL
+	content string // Hash of the image data
+}
+
+// imageFilenameTemplate returns IMAGE_FILENAME, or the default <pageID>_<hash> name
+func (c runConfig) imageFilenameTemplate() string {
+	if c.ImageFilename != "" {
+		return c.ImageFilename
+	}
+	if c.ImagesPerPage || c.ImagesColocated {
+		// The page ID or the page file is already the directory
+		return "{hash}"
+	}
+	return "{page}_{hash}"
+}
+
+// validateImageFilename reports an unknown placeholder in an IMAGE_FILENAME template
+func validateImageFilename(template string) error {
+	rest := template
+	for _, placeholder := range imageFilenamePlaceholders {
+		rest = strings.ReplaceAll(rest, placeholder, "")
+	}
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("unknown placeholder in %q; use %s", template, strings.Join(imageFilenamePlaceholders, ", "))
+	}
+	return nil
+}
+
+// newImageFilenameFields collects the placeholder values known before the image is downloaded
+func newImageFilenameFields(imageURL, pageID, urlHash string, config runConfig) imageFilenameFields {
+	fields := imageFilenameFields{page: pageID, slug: astroSlug(config.PageTitle), index: 1, hash: urlHash}
+	if fields.slug == "" {
+		fields.slug = pageID
+	}
+	if config.ImageCount != nil {
+		*config.ImageCount++
+		fields.index = *config.ImageCount
+	}
+	if parsed, err := url.Parse(imageURL); err == nil {
+		name := path.Base(parsed.Path)
+		fields.name = astroSlug(strings.TrimSuffix(name, path.Ext(name)))
+	}
+	if fields.name == "" {
+		fields.name = "image"
+	}
+	return fields
+}
+
+// imageSource returns the URL an image is identified by. Files uploaded to Notion are served
+// from signed URLs whose query changes with every API response, so the query is dropped and
+// the path, which holds the ID of the file, keeps the name of the image stable across runs.
+func imageSource(imageURL string, external bool) string {
+	if external {
+		return imageURL
+	}
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return imageURL
+	}
+	parsed.RawQuery, parsed.Fragment = "", ""
+	return parsed.String()
+}
+
+// imageFilename expands the IMAGE_FILENAME template into a path relative to ImagesDir
+func imageFilename(config runConfig, fields imageFilenameFields, ext string) string {
+	name := strings.NewReplacer(
+		"{page}", fields.page,
+		"{slug}", fields.slug,
+		"{index}", strconv.Itoa(fields.index),
+		"{name}", fields.name,
+		"{hash}", fields.hash,
+		"{content}", fields.content,
+	).Replace(config.imageFilenameTemplate())
+	name = invalidImageFilenameChars.ReplaceAllString(name, "_") + "." + ext
+	if config.ImagesPerPage && !config.ImagesColocated {
+		name = path.Join(fields.page, name)
+	}
+	return path.Join(config.imagesSubdir(), name)
+}
+
+// claimImageFilename reserves filename in the manifest for the image of pageID downloaded from
+// source, appending -2, -3, ... while the name belongs to another image, so a template without
+// {hash} never overwrites a different image. It returns the claimed name and whether the file
+// saved under it is already this image.
+func claimImageFilename(config runConfig, filename, pageID, source string) (string, bool) {
+	ext := path.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	for i := 1; ; i++ {
+		candidate := filename
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		previous, ok := config.Manifest.ClaimImage(config.imagePath(candidate), pageID, source)
+		if !ok {
+			continue
+		}
+		// Files recorded before sources were tracked can only be trusted when the URL hash is in the name
+		same := previous == source || (previous == "" && strings.Contains(config.imageFilenameTemplate(), "{hash}"))
+		return candidate, same
+	}
+}
+
+// imageRel returns the path of an image file relative to ImagesDir, as returned by downloadImage
+func (c runConfig) imageRel(outputPath string) string {
+	rel, err := filepath.Rel(c.ImagesDir, outputPath)
+	if err != nil {
+		return filepath.ToSlash(outputPath)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,96 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestImageFilenameTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	manifest := &Manifest{Files: map[string]ManifestEntry{}, seen: map[string]bool{}}
+	config := runConfig{
+		ImagesDir:       t.TempDir(),
+		ImagesURLPrefix: "/images",
+		ImageFilename:   "{slug}-{name}",
+		PageTitle:       "Hello World",
+		CompressImages:  false,
+		Manifest:        manifest,
+	}
+	run := func(urls ...string) []string {
+		config.ImageCount = new(int)
+		var names []string
+		for _, url := range urls {
+			filename, err := downloadImage(url, "page", true, config)
+			if err != nil {
+				t.Fatalf("downloadImage(%s) error = %v", url, err)
+			}
+			manifest.RecordFile(config.imagePath(filename), "page")
+			names = append(names, filename)
+		}
+		return names
+	}
+
+	// The same file name in two URLs must not overwrite the first image
+	names := run(server.URL+"/a/photo.png", server.URL+"/b/photo.png")
+	if names[0] != "hello-world-photo.png" || names[1] != "hello-world-photo-2.png" {
+		t.Errorf("image names = %v, want hello-world-photo.png and hello-world-photo-2.png", names)
+	}
+
+	// A later run reuses the files without downloading them again
+	manifest.seen = map[string]bool{}
+	downloads = 0
+	again := run(server.URL+"/b/photo.png", server.URL+"/a/photo.png")
+	if again[0] != names[1] || again[1] != names[0] {
+		t.Errorf("image names on the next run = %v, want %v reversed", again, names)
+	}
+	if downloads != 0 {
+		t.Errorf("downloaded %d images on the next run, want 0", downloads)
+	}
+
+	// Another page cannot take over the name
+	other, err := downloadImage(server.URL+"/c/photo.png", "other", true, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other != "hello-world-photo-3.png" {
+		t.Errorf("image of another page = %q, want hello-world-photo-3.png", other)
+	}
+
+	config.ImageFilename = "{index}-{content}"
+	config.ImageCount = new(int)
+	content, err := downloadImage(server.URL+"/d/photo.png", "page", true, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^1-[0-9a-f]{16}\.png$`).MatchString(content) {
+		t.Errorf("content named image = %q", content)
+	}
+}
+
+func TestValidateImageFilename(t *testing.T) {
+	for _, template := range []string{"", "{slug}-{index}", "{page}_{hash}", "img-{content}"} {
+		if err := validateImageFilename(template); err != nil {
+			t.Errorf("validateImageFilename(%q) error = %v", template, err)
+		}
+	}
+	for _, template := range []string{"{title}", "{slug", "{index}}"} {
+		if err := validateImageFilename(template); err == nil {
+			t.Errorf("validateImageFilename(%q) accepted an unknown placeholder", template)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadImageDetectsFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/svg" {
+			w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+			w.Write([]byte("<!-- logo -->"))
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{ImagesDir: t.TempDir(), Manifest: manifest}
+	for _, tt := range []struct {
+		path     string
+		expected string
+	}{
+		{"/photo", ".png"},          // No extension
+		{"/photo.jpg", ".png"},      // A wrong extension
+		{"/photo.jpeg?a=b", ".png"}, // A query
+		{"/svg", ".svg"},            // Only the Content-Type tells the format
+	} {
+		filename, err := downloadImage(server.URL+tt.path, "page", true, config)
+		if err != nil {
+			t.Fatalf("downloadImage(%s) error = %v", tt.path, err)
+		}
+		if filepath.Ext(filename) != tt.expected {
+			t.Errorf("downloadImage(%s) = %q, want a %s image", tt.path, filename, tt.expected)
+		}
+	}
+
+	// An image named after downloading is reused on the next run
+	first, _ := downloadImage(server.URL+"/photo", "page", true, config)
+	if second, err := downloadImage(server.URL+"/photo", "page", true, config); err != nil || second != first {
+		t.Errorf("expected %q to be reused, got %q (%v)", first, second, err)
+	}
+
+	// The name claimed for the wrong extension is released
+	for file := range manifest.Pages() {
+		if strings.HasSuffix(file, ".jpg") || strings.HasSuffix(file, ".jpeg") {
+			t.Errorf("unexpected manifest entry %s", file)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// pageLayout returns the layout chosen for the page by its template or layout property, mapped
+// through LAYOUT_MAP. Values missing from the map are used as they are; a page without a value
+// gets "".
+func pageLayout(page notionapi.Page, config runConfig) string {
+	prop, ok := lookupProperty(page.Properties, config.properties().Layout, "template", "Template", "layout", "Layout")
+	if !ok {
+		return ""
+	}
+	value := strings.TrimSpace(propertyText(prop))
+	if value == "" {
+		return ""
+	}
+	if layout, ok := config.LayoutMap[value]; ok {
+		return layout
+	}
+	return value
+}
+
+// setPageLayout sets the layout or template field (LAYOUT_FIELD) to the layout of the page
+func setPageLayout(frontmatter *Frontmatter, page notionapi.Page, config runConfig) {
+	if config.LayoutField == "" {
+		return
+	}
+
+	layout := pageLayout(page, config)
+	if config.LayoutField == "template" {
+		frontmatter.Template = layout
+	} else {
+		frontmatter.Layout = layout
+	}
+}
+
+// parseLayoutMap parses LAYOUT_MAP, "value=layout" pairs separated by semicolons
+func parseLayoutMap(value string) (map[string]string, error) {
+	layouts := map[string]string{}
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, layout, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is not a \"value=layout\" pair", strings.TrimSpace(pair))
+		}
+		layouts[name] = strings.TrimSpace(layout)
+	}
+	return layouts, nil
+}
@@ -0,0 +1,69 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestPageLayout(t *testing.T) {
+	layouts := map[string]string{"photo": "../layouts/PhotoPost.astro", "longform": "Longform"}
+	tests := []struct {
+		name     string
+		prop     notionapi.Property
+		mapping  PropertyMapping
+		expected string
+	}{
+		{"mapped select", &notionapi.SelectProperty{Select: notionapi.Option{Name: "photo"}}, PropertyMapping{}, "../layouts/PhotoPost.astro"},
+		{"unmapped select", &notionapi.SelectProperty{Select: notionapi.Option{Name: "standard"}}, PropertyMapping{}, "standard"},
+		{"empty select", &notionapi.SelectProperty{}, PropertyMapping{}, ""},
+		{"text", &notionapi.RichTextProperty{RichText: richText(" longform ")}, PropertyMapping{}, "Longform"},
+		{"other property", &notionapi.SelectProperty{Select: notionapi.Option{Name: "photo"}}, PropertyMapping{Layout: "Design"}, ""},
+	}
+
+	for _, tt := range tests {
+		page := *titledPage("page", "Title")
+		page.Properties["template"] = tt.prop
+		config := runConfig{DatabaseType: "blog", BlogProperties: tt.mapping, LayoutMap: layouts}
+		if layout := pageLayout(page, config); layout != tt.expected {
+			t.Errorf("%s: pageLayout() = %q, want %q", tt.name, layout, tt.expected)
+		}
+	}
+}
+
+func TestProcessPageLayout(t *testing.T) {
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Hello")},
+	}}}
+	page := titledPage("page", "Photos")
+	page.Properties["Design"] = &notionapi.SelectProperty{Select: notionapi.Option{Name: "photo"}}
+
+	dir := t.TempDir()
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, LayoutField: "template",
+		BlogProperties: PropertyMapping{Layout: "Design"}, LayoutMap: map[string]string{"photo": "PhotoPost"}}
+	processPage(client, *page, config)
+
+	data, err := os.ReadFile(filepath.Join(dir, "Photos.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\ntemplate: PhotoPost\n") || strings.Contains(string(data), "\nlayout:") {
+		t.Errorf("expected template: PhotoPost in:\n%s", data)
+	}
+}
+
+func TestParseLayoutMap(t *testing.T) {
+	layouts, err := parseLayoutMap("standard=Post; photo = ../layouts/PhotoPost.astro;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layouts) != 2 || layouts["standard"] != "Post" || layouts["photo"] != "../layouts/PhotoPost.astro" {
+		t.Errorf("parseLayoutMap() = %v", layouts)
+	}
+	if _, err := parseLayoutMap("photo"); err == nil {
+		t.Error("expected an error for a value without a layout")
+	}
+}
@@ -0,0 +1,177 @@
+package converter
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// markdownLinkPattern matches markdown links, but not images
+var markdownLinkPattern = regexp.MustCompile(`(^|[^!])\[[^\]]*\]\(([^)\s]+)\)`)
+
+// notionPageIDPattern matches the page ID at the end of a Notion page URL
+var notionPageIDPattern = regexp.MustCompile(`([0-9a-f]{32}|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})$`)
+
+// pageLink is a link found in a generated file
+type pageLink struct {
+	file string
+	url  string
+}
+
+// LinkChecker collects the links of exported pages and checks them at the end of the run (-check-links).
+// A nil *LinkChecker records nothing.
+type LinkChecker struct {
+	mu    sync.Mutex
+	links []pageLink
+
+	checkExternal bool
+	client        *http.Client
+}
+
+// newLinkChecker creates a link checker; external links are only requested when checkExternal is set
+func newLinkChecker(checkExternal bool) *LinkChecker {
+	return &LinkChecker{checkExternal: checkExternal, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Record collects the links of a generated markdown file
+func (c *LinkChecker) Record(file, markdown string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, match := range markdownLinkPattern.FindAllStringSubmatch(markdown, -1) {
+		c.links = append(c.links, pageLink{file: file, url: match[2]})
+	}
+}
+
+// Check verifies the recorded links against the routes of the pages in the manifest and
+// reports problems to the run report. Internal links are only checked under the routes of
+// exported collections, so links to other pages of the site are left alone.
+func (c *LinkChecker) Check(config runConfig) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	routes, pageRoutes := exportedRoutes(config)
+	prefixes := map[string]bool{}
+	for route := range routes {
+		prefixes[routeSection(route)] = true
+	}
+
+	external := map[string][]string{}
+	for _, link := range c.links {
+		target := strings.SplitN(strings.SplitN(link.url, "#", 2)[0], "?", 2)[0]
+		switch {
+		case target == "" || strings.HasPrefix(link.url, "mailto:") || strings.HasPrefix(link.url, "tel:"):
+			// Anchors on the same page and non-web links
+		case strings.Contains(link.url, "notion.so/") || (strings.HasPrefix(target, "/") && notionPageIDPattern.MatchString(target)):
+			id := strings.ReplaceAll(notionPageIDPattern.FindString(target), "-", "")
+			if route, ok := pageRoutes[id]; ok {
+				config.Report.AddLinkProblem(link.file, link.url, "links to Notion instead of the exported page "+route)
+			} else {
+				config.Report.AddLinkProblem(link.file, link.url, "links to a Notion page that is not exported")
+			}
+		case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+			external[link.url] = append(external[link.url], link.file)
+		case strings.HasPrefix(target, "//"):
+			// Protocol-relative links are treated as external but not requested
+		case strings.HasPrefix(target, "/"):
+			route := strings.TrimSuffix(target, "/")
+			if prefixes[routeSection(route)] && !routes[route] {
+				config.Report.AddLinkProblem(link.file, link.url, "no exported page has this path")
+			}
+		default:
+			config.Report.AddLinkProblem(link.file, link.url, "relative link; it resolves differently depending on the page URL")
+		}
+	}
+
+	if c.checkExternal {
+		c.checkExternalLinks(config, external)
+	}
+}
+
+// checkExternalLinks requests each external link once with a few concurrent requests
+func (c *LinkChecker) checkExternalLinks(config runConfig, external map[string][]string) {
+	urls := make([]string, 0, len(external))
+	for url := range external {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range queue {
+				if reason := c.deadLinkReason(url); reason != "" {
+					for _, file := range external[url] {
+						config.Report.AddLinkProblem(file, url, reason)
+					}
+				}
+			}
+		}()
+	}
+	for _, url := range urls {
+		config.logDebug("Checking external link %s", url)
+		queue <- url
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// deadLinkReason requests url and returns why it is broken, or "" if it responds
+func (c *LinkChecker) deadLinkReason(url string) string {
+	resp, err := c.client.Head(url)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		// Some servers do not support HEAD
+		resp.Body.Close()
+		resp, err = c.client.Get(url)
+	}
+	if err != nil {
+		return fmt.Sprintf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return ""
+}
+
+// exportedRoutes returns the routes of the markdown files in the manifest, and the route of each page ID
+func exportedRoutes(config runConfig) (map[string]bool, map[string]string) {
+	routes := map[string]bool{}
+	pageRoutes := map[string]string{}
+	for path, pageID := range config.Manifest.Pages() {
+		if ext := filepath.Ext(path); ext != ".md" && ext != ".mdx" {
+			continue
+		}
+		for _, name := range config.databaseNames() {
+			typeConfig := config.databaseConfig(name)
+			if route, ok := pageRoute(typeConfig, filepath.FromSlash(path)); ok {
+				routes[route] = true
+				pageRoutes[strings.ReplaceAll(pageID, "-", "")] = route
+				break
+			}
+		}
+	}
+	return routes, pageRoutes
+}
+
+// routeSection returns the first segment of a route, e.g. "/blog" for "/blog/post"
+func routeSection(route string) string {
+	if i := strings.Index(strings.TrimPrefix(route, "/"), "/"); i >= 0 {
+		return route[:i+1]
+	}
+	return route
+}
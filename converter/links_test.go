@@ -0,0 +1,53 @@
+package converter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLinkCheckerCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	blogDir := t.TempDir()
+	postPath := filepath.Join(blogDir, "Hello World.md")
+	if err := os.WriteFile(postPath, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.RecordFile(postPath, "1234567890abcdef1234567890abcdef"); err != nil {
+		t.Fatal(err)
+	}
+
+	config := runConfig{BlogOutputDir: blogDir, Manifest: manifest, Report: newRunReport()}
+	links := newLinkChecker(true)
+	links.Record("post.md", `[ok](/blog/hello-world/) [missing](/blog/missing) [about](/about) [anchor](#top)
+[relative](../other) ![image](/images/photo.png)
+[notion](https://www.notion.so/Hello-World-1234567890abcdef1234567890abcdef)
+[alive](`+server.URL+`/ok) [gone](`+server.URL+`/gone)`)
+	links.Check(config)
+
+	expected := []string{
+		"post.md: ../other (relative link; it resolves differently depending on the page URL)",
+		"post.md: /blog/missing (no exported page has this path)",
+		"post.md: " + server.URL + "/gone (status 404)",
+		"post.md: https://www.notion.so/Hello-World-1234567890abcdef1234567890abcdef (links to Notion instead of the exported page /blog/hello-world)",
+	}
+	problems := config.Report.linkProblems
+	sort.Strings(problems)
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("link problems = %q\nwant %q", problems, expected)
+	}
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dateLocale holds the names needed to write a date in one language
+type dateLocale struct {
+	weekdays [7]string                   // Sunday first, as time.Weekday
+	longDate func(date time.Time) string // Long-form date, e.g. 2024年1月15日
+}
+
+// dateLocales lists the locales supported by DIARY_DATE_LOCALE
+var dateLocales = map[string]dateLocale{
+	"ja": {
+		weekdays: [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		longDate: func(date time.Time) string {
+			return fmt.Sprintf("%d年%d月%d日", date.Year(), date.Month(), date.Day())
+		},
+	},
+	"en": {
+		weekdays: [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		longDate: func(date time.Time) string {
+			return date.Format("January 2, 2006")
+		},
+	},
+}
+
+// supportedDateLocales returns the names of dateLocales in a stable order for error messages
+func supportedDateLocales() string {
+	names := make([]string, 0, len(dateLocales))
+	for name := range dateLocales {
+		names = append(names, "'"+name+"'")
+	}
+	sort.Strings(names)
+	return strings.Join(names, " or ")
+}
+
+// localizeDate returns the weekday and the long-form date of a YYYY-MM-DD date in locale.
+// Empty strings are returned for an unknown locale or an unparsable date.
+func localizeDate(date, locale string) (string, string) {
+	names, ok := dateLocales[locale]
+	if !ok {
+		return "", ""
+	}
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", ""
+	}
+	return names.weekdays[parsed.Weekday()], names.longDate(parsed)
+}
@@ -0,0 +1,37 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalizeDate(t *testing.T) {
+	tests := []struct {
+		date, locale, dayOfWeek, label string
+	}{
+		{"2024-01-15", "ja", "月曜日", "2024年1月15日"},
+		{"2024-01-15", "en", "Monday", "January 15, 2024"},
+		{"2024-02-29", "ja", "木曜日", "2024年2月29日"},
+		{"2024-01-15", "fr", "", ""},
+		{"", "ja", "", ""},
+	}
+	for _, tt := range tests {
+		dayOfWeek, label := localizeDate(tt.date, tt.locale)
+		if dayOfWeek != tt.dayOfWeek || label != tt.label {
+			t.Errorf("localizeDate(%q, %q) = %q, %q, want %q, %q", tt.date, tt.locale, dayOfWeek, label, tt.dayOfWeek, tt.label)
+		}
+	}
+}
+
+func TestLocalizedDateFrontmatter(t *testing.T) {
+	frontmatter := Frontmatter{Title: "日記", Date: "2024-01-15"}
+	frontmatter.DayOfWeek, frontmatter.DateLabel = localizeDate(frontmatter.Date, "ja")
+
+	yaml, err := generateFrontmatterYAML(frontmatter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(yaml, "date: 2024-01-15\n") || !strings.Contains(yaml, "dayOfWeek: 月曜日\ndateLabel: 2024年1月15日\n") {
+		t.Errorf("frontmatter = %q, want dayOfWeek and dateLabel", yaml)
+	}
+}
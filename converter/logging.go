@@ -0,0 +1,155 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// newLogger returns the logger of a run at level and format: "text" writes messages for people,
+// "json" writes a JSON object per message for CI. -quiet logs warnings and errors only,
+// -verbose (or -debug) adds the debug messages of each block, image and step.
+func newLogger(level slog.Level, format string, out, errOut io.Writer) (*slog.Logger, error) {
+	handler, err := newLogHandler(level, format, out, errOut)
+	if err != nil {
+		return nil, err
+	}
+	return slog.New(handler), nil
+}
+
+// runLogger returns opts.Logger, or else the logger of a run at level and format writing to
+// os.Stdout and os.Stderr. Info messages go to os.Stderr when -output - prints the page itself.
+func runLogger(opts Options, level slog.Level, format string) (*slog.Logger, error) {
+	if opts.Logger != nil {
+		return opts.Logger, nil
+	}
+	out := io.Writer(os.Stdout)
+	if opts.Output == "-" {
+		// Keep stdout for the converted page only
+		out = os.Stderr
+	}
+	return newLogger(level, format, out, os.Stderr)
+}
+
+// NewLogger returns the logger Convert logs the run of opts to, so the caller can log its own
+// messages, like the error of the run, the same way: opts.Logger, or else a logger writing to
+// os.Stdout and os.Stderr according to Verbose, Quiet and LogFormat and the DEBUG and
+// LOG_FORMAT settings of opts.Env
+func NewLogger(opts Options) (*slog.Logger, error) {
+	env := settings(opts.Env)
+	// -quiet overrides DEBUG
+	debug := !opts.Quiet && (env.getBool("DEBUG", false) || opts.Verbose)
+	format := orDefault(opts.LogFormat, env.get("LOG_FORMAT", "text"))
+	logger, err := runLogger(opts, logLevel(opts.Quiet, debug), format)
+	if err != nil {
+		return nil, configError("invalid LOG_FORMAT: %s. Must be 'text' or 'json'", format)
+	}
+	return logger, nil
+}
+
+// newLogHandler returns the handler of format writing info messages to out, and warnings,
+// errors and debug messages of the text format to errOut
+func newLogHandler(level slog.Level, format string, out, errOut io.Writer) (slog.Handler, error) {
+	switch format {
+	case "", "text":
+		return &textLogHandler{level: level, out: out, errOut: errOut, mu: &sync.Mutex{}}, nil
+	case "json":
+		return slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level}), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q; must be 'text' or 'json'", format)
+}
+
+// logLevel returns the level of -quiet and -verbose
+func logLevel(quiet, verbose bool) slog.Level {
+	switch {
+	case verbose:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// logDebug logs a debug message of the run, shown with -verbose
+func (c runConfig) logDebug(format string, args ...any) {
+	logf(c.Logger, slog.LevelDebug, format, args...)
+}
+
+// logInfo logs the progress of the run, hidden by -quiet
+func (c runConfig) logInfo(format string, args ...any) {
+	logf(c.Logger, slog.LevelInfo, format, args...)
+}
+
+// logWarn logs a problem the run continues after, such as a page or image that failed
+func (c runConfig) logWarn(format string, args ...any) {
+	logf(c.Logger, slog.LevelWarn, format, args...)
+}
+
+// logError logs a problem that stops the run or loses its result
+func (c runConfig) logError(format string, args ...any) {
+	logf(c.Logger, slog.LevelError, format, args...)
+}
+
+// logf formats and logs a message through logger when level is enabled. A nil logger logs
+// through the default slog logger, as the subcommands do.
+func logf(logger *slog.Logger, level slog.Level, format string, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
+
+// textLogHandler writes info messages as they are to stdout, and the other levels prefixed
+// with the level to stderr, so the problems of a run stand out from its progress
+type textLogHandler struct {
+	level  slog.Level
+	out    io.Writer
+	errOut io.Writer
+	attrs  []slog.Attr
+	mu     *sync.Mutex // Shared by the handlers derived with WithAttrs
+}
+
+func (h *textLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textLogHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	out := h.out
+	if record.Level != slog.LevelInfo {
+		line.WriteString(record.Level.String() + ": ")
+		out = h.errOut
+	}
+	line.WriteString(record.Message)
+	appendAttr := func(attr slog.Attr) bool {
+		fmt.Fprintf(&line, " %s=%v", attr.Key, attr.Value)
+		return true
+	}
+	for _, attr := range h.attrs {
+		appendAttr(attr)
+	}
+	record.Attrs(appendAttr)
+	line.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(out, line.String())
+	return err
+}
+
+func (h *textLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *h
+	derived.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &derived
+}
+
+func (h *textLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
@@ -0,0 +1,119 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLog sends the log of the test at level and format to the returned buffers of info
+// messages and of the other messages
+func captureLog(t *testing.T, level slog.Level, format string) (*bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	handler, err := newLogHandler(level, format, &out, &errOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(handler))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &out, &errOut
+}
+
+func TestTextLog(t *testing.T) {
+	out, errOut := captureLog(t, slog.LevelInfo, "text")
+	var config runConfig
+	config.logDebug("Processing block %d", 1)
+	config.logInfo("Found %d articles", 2)
+	config.logWarn("Failed to download image: %s", "timeout")
+	config.logError("Failed to save manifest")
+
+	if expected := "Found 2 articles\n"; out.String() != expected {
+		t.Errorf("stdout = %q, want %q", out.String(), expected)
+	}
+	if expected := "WARN: Failed to download image: timeout\nERROR: Failed to save manifest\n"; errOut.String() != expected {
+		t.Errorf("stderr = %q, want %q", errOut.String(), expected)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		quiet, verbose bool
+		stdout, stderr string
+	}{
+		{false, false, "info\n", "WARN: warn\n"},
+		{true, false, "", "WARN: warn\n"},
+		{false, true, "info\n", "DEBUG: debug\nWARN: warn\n"},
+	}
+
+	for _, tt := range tests {
+		out, errOut := captureLog(t, logLevel(tt.quiet, tt.verbose), "text")
+		var config runConfig
+		config.logDebug("debug")
+		config.logInfo("info")
+		config.logWarn("warn")
+		if out.String() != tt.stdout || errOut.String() != tt.stderr {
+			t.Errorf("quiet=%v verbose=%v: logged %q and %q, want %q and %q", tt.quiet, tt.verbose, out.String(), errOut.String(), tt.stdout, tt.stderr)
+		}
+	}
+}
+
+func TestJSONLog(t *testing.T) {
+	out, errOut := captureLog(t, slog.LevelInfo, "json")
+	var config runConfig
+	config.logInfo("Found %d articles", 2)
+	config.logWarn("Failed to get page %s", "abc")
+
+	if errOut.Len() != 0 {
+		t.Errorf("unexpected stderr %q", errOut.String())
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %q", out.String())
+	}
+	var message struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Level != "WARN" || message.Msg != "Failed to get page abc" {
+		t.Errorf("unexpected message %+v", message)
+	}
+}
+
+func TestNewLoggerInvalidFormat(t *testing.T) {
+	if _, err := newLogger(slog.LevelInfo, "xml", io.Discard, io.Discard); err == nil {
+		t.Error("expected an error for an unknown log format")
+	}
+}
+
+func TestNewLoggerOptions(t *testing.T) {
+	logger, err := NewLogger(Options{Env: map[string]string{"DEBUG": "true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("DEBUG did not enable debug messages")
+	}
+	logger, err = NewLogger(Options{Quiet: true, Env: map[string]string{"DEBUG": "true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("-quiet did not override DEBUG")
+	}
+	if _, err := NewLogger(Options{Env: map[string]string{"LOG_FORMAT": "xml"}}); ExitCode(err) != exitConfig {
+		t.Errorf("NewLogger() = %v, want a configuration error", err)
+	}
+	given := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if logger, err := NewLogger(Options{Logger: given}); err != nil || logger != given {
+		t.Errorf("NewLogger() = %v, %v; want the logger of the options", logger, err)
+	}
+}
@@ -0,0 +1,1917 @@
+package converter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+
+	"notion-to-astro-go/internal/images"
+	"notion-to-astro-go/internal/mdrender"
+	"notion-to-astro-go/internal/notion"
+	"notion-to-astro-go/internal/writer"
+)
+
+// interruptGracePeriod is how long the pages in progress may take to finish after the run is
+// interrupted before their Notion requests are canceled
+var interruptGracePeriod = 30 * time.Second
+
+// defaultStagingDir holds the workspaces of the runs with STAGED_WRITES unless STAGING_DIR
+// names another; it only exists while a run is in progress or after a run failed to commit
+const defaultStagingDir = ".notion-to-astro-staging"
+
+// runConfig is the configuration of a run, with the objects its pages share. It is internal to
+// the package; programs embedding the converter configure a run through Options.
+type runConfig struct {
+	NotionAPIToken        string
+	NotionBlogDatabaseID  string
+	NotionDiaryDatabaseID string
+	NotionRootPageID      string // Root page exported with its child pages in "pages" mode
+	SummaryPageID         string // Page the run summary is appended to; empty disables it
+	SummaryDatabaseID     string // Database receiving an entry with the run summary per run; empty disables it
+	SinglePageID          string // Export only this page (-page)
+	PagesFile             string // File listing the page IDs or URLs to export (-pages-file); "-" reads stdin
+	OutputPath            string // Output file of a single page export (-output); "-" prints to stdout
+	BlogOutputDir         string // Output directory for blog content
+	DiaryOutputDir        string // Output directory for diary content
+	PagesOutputDir        string // Output directory for the page tree in "pages" mode
+	DatabaseType          string // "blog", "diary", "pages" or "data" (the profile of an additional database)
+	DatabaseName          string // Name of the additional database exported as DatabaseType (set by databaseConfig)
+	DataFile              string // Data file the pages of a database with the data profile are written to
+	ImagesDir             string // Directory for storing downloaded images
+	ImagesURLPrefix       string // Site path of ImagesDir, e.g. "/images"
+	BlogImagesSubdir      string // Subdirectory of ImagesDir for blog images
+	DiaryImagesSubdir     string // Subdirectory of ImagesDir for diary images
+	PagesImagesSubdir     string // Subdirectory of ImagesDir for images of the page tree
+	ImagesPerPage         bool   // Store images in a subfolder per page ID
+	ImagesColocated       bool   // Store images in a folder next to the page file, linked relatively
+	ImageFilename         string // Template of image file names, e.g. "{slug}-{index}"; empty uses <pageID>_<hash>
+	ImageAltFallback      string // Alt text of images without a caption: "image" ("Image"), "empty" (decorative), "title" or "filename"
+	ImageCaptions         string // Visible image captions: "none", "text" (paragraph below the image) or "figure" (<figcaption>)
+	ImageUserAgent        string // User-Agent of image downloads; empty uses the Go default
+	IncludeNotionURL      bool   // Emit the source Notion page URL as notionUrl in frontmatter
+	CanonicalOutput       bool   // Sort tags and normalize whitespace so exports are byte-identical across runs and machines
+	BlogProperties        PropertyMapping
+	DiaryProperties       PropertyMapping
+	Databases             []DatabaseDefinition
+	ImageHeaders          map[string]string
+	LayoutMap             map[string]string
+	SkipPages             []string       // Dashless IDs of pages that are never exported, whatever the filters select
+	BlogRequired          []string       // Properties a blog page must fill to be exported ("cover" and "icon" for the page cover and icon)
+	DiaryRequired         []string       // Properties a diary page must fill to be exported
+	BlogFilename          string         // File name pattern of blog posts; empty uses the title
+	DiaryFilename         string         // File name pattern of diary entries; empty uses <date>_<title>
+	BlogSlugStrategy      string         // Name of the slug strategy of blog posts; empty uses "property"
+	DiarySlugStrategy     string         // Name of the slug strategy of diary entries; empty uses "property"
+	SlugStrategy          SlugStrategy   // Slug strategy of every database, replacing the named ones (Options.SlugStrategy)
+	BlogFilter            QueryFilter    // Notion API filter blog posts must also match
+	DiaryFilter           QueryFilter    // Notion API filter diary entries must also match
+	BlogSorts             []QuerySort    // Order blog posts are queried in; empty uses the order of Notion
+	DiarySorts            []QuerySort    // Order diary entries are queried in; empty uses the order of Notion
+	NoIndexField          string         // "robots" (robots: noindex) or "sitemap" (sitemap: false)
+	EmitJSONLD            bool           // Emit Article JSON-LD fields under jsonLd in frontmatter
+	EmitContentHash       bool           // Emit the SHA-256 of the body as contentHash in frontmatter
+	EmitSyncMetadata      bool           // Emit exportedAt and sourceLastEdited in frontmatter
+	EmitAdjacentPosts     bool           // Emit the slugs of the previous and next post as prev/next in frontmatter
+	EmitSlug              bool           // Emit the page slug as slug in frontmatter and name files after it
+	EmitIcon              bool           // Emit the page icon (emoji or downloaded image) as icon in frontmatter
+	CoverImageField       string         // Frontmatter field of the downloaded page cover: "coverImage" or "heroImage"; empty omits it
+	LayoutField           string         // Frontmatter field of the layout chosen by the template property: "layout" or "template"; empty omits it
+	AuthorName            string         // Default author when the page has no author property
+	DiaryDateLocale       string         // Locale of dayOfWeek and dateLabel in diary frontmatter ("ja" or "en"); empty omits them
+	RefreshImages         bool           // Revalidate already downloaded external images (-refresh-images)
+	CompressImages        bool           // Recompress downloaded JPEG and PNG images; otherwise they are saved as downloaded
+	MaxDecodePixels       int            // Images with more pixels are saved as downloaded instead of being decoded; 0 disables the limit
+	ImageFormat           string         // Format JPEG and PNG images are converted to: "original", "webp" or "avif"
+	ImageDecodeCommand    string         // Command decoding HEIC and AVIF images to PNG, with both paths appended; empty uses heif-convert and avifdec
+	DedupeImages          bool           // Link an image already saved from the same data, by any page, instead of saving a copy
+	ImageQuality          int            // Quality (1-100) of compressed and converted images; 0 uses the default of the format
+	ImageMaxWidth         int            // Images are scaled down to this width, keeping their aspect ratio; 0 for no limit
+	ImageMaxHeight        int            // Images are scaled down to this height, keeping their aspect ratio; 0 for no limit
+	WriteStatsSidecar     bool           // Write a <post>.stats.json file with computed stats next to each post
+	WarnPageSizeKB        int            // Report pages whose file is larger than this many KB; 0 disables the check
+	WarnPageImages        int            // Report pages with more images than this; 0 disables the check
+	WarnPageAssetsMB      int            // Report pages whose images total more than this many MB; 0 disables the check
+	LineBreakStyle        string         // "spaces" (trailing double space) or "br" (<br/>) for newlines inside a block
+	EmptyParagraphs       string         // "collapse" (mdrender.ProcessEmptyLines), "preserve" (blank lines as in Notion) or "br" (<br/> for each empty paragraph)
+	NumberedListContinue  bool           // Continue numbering when a numbered list resumes after other blocks
+	CalloutStyle          string         // "blockquote" (admonition-style quote), "html" (<aside> element), "aside" (Starlight aside) or "component" (MDX component)
+	CalloutComponent      string         // Name of the MDX component for CALLOUT_STYLE=component
+	CalloutImport         string         // Module the callout component is imported from
+	OutputFormat          string         // "markdown" or "json-ast" (normalized JSON representation of the page)
+	OutputProfile         string         // "default" or "starlight" (sidebar frontmatter, asides, index pages for sections)
+	RenderBreadcrumbs     bool           // Render breadcrumb blocks as a trail of parent page names
+	NormalizeHeadings     bool           // Shift headings so the first one is h2 and no level is skipped
+	StripTitleHeading     bool           // Remove a leading heading that repeats the page title
+	ManifestFile          string         // Path of the manifest listing all generated files
+	SyncStateFile         string         // Path of the sync state used to skip unchanged pages; empty exports every page
+	StatsDashboardFile    string         // Path of the stats.html dashboard written after a run; empty disables it
+	Force                 bool           // Export pages even if they did not change since the last run (-force)
+	Concurrency           int            // Pages of a database processed in parallel (-concurrency)
+	NotionRequestsPerSec  int            // Notion API requests per second across all pages; 0 disables the limit
+	NotionMaxRequests     int            // Notion API requests a run may send before it stops; 0 disables the limit
+	NotionMaxAttempts     int            // Attempts of a Notion API request failing with 429, 5xx or a network error
+	MarkPublished         bool           // Check the published checkbox of exported pages in Notion (-mark-published)
+	Prune                 bool           // Remove files of pages that are no longer exported (-prune)
+	SyncDeletions         bool           // Remove files of pages archived, deleted or unpublished in Notion (-sync-deletions)
+	CheckLinks            bool           // Check the links of exported pages at the end of the run (-check-links)
+	FrontmatterOnly       bool           // Regenerate only the frontmatter of already exported pages (-frontmatter-only)
+	CheckExternalLinks    bool           // Also request external links with -check-links
+	PostProcessFileCmd    string         // Command run for each generated page file, with the path appended
+	PostProcessCmd        string         // Command run once after the run
+	RedirectsFile         string         // File collecting old → new routes of renamed pages and redirect_from; empty disables it
+	RedirectsFormat       string         // "astro" (redirects JSON), "netlify" (_redirects) or "vercel" (vercel.json)
+	PruneMode             string         // "delete", "trash" (move pruned files to .notion-to-astro-trash/<timestamp>/) or "list" (only list them)
+	PruneArchiveDir       string         // Directory keeping a copy of pruned files under <page ID>/<date>/; empty disables it
+	StagedWrites          bool           // Stage all changes under StagingDir and apply them only when the run succeeds
+	StagingDir            string         // Directory holding the workspace of each run with staged changes
+	ContentErrorPolicy    string         // "placeholder", "skip", "keep" (existing file) or "fail" when page content cannot be retrieved
+	UserCacheFile         string         // Path of the on-disk user name cache; empty keeps the cache in memory
+	BodyCacheDir          string         // Directory caching converted page bodies; empty disables the cache
+	CollectionMetadataDir string         // Directory for <type>/collection.json; empty disables it
+	Debug                 bool           // Enable debug logging; otherwise only pages, warnings and progress are printed
+	Quiet                 bool           // Log only warnings and errors
+	LogFormat             string         // "text" (default) or "json" for a JSON object per log message
+	ProgressInterval      int            // Print the number of processed pages every this many pages; 0 disables it
+	PageTitle             string         // Title of the current page (set per page by processPage)
+	ImageCount            *int           // Images of the current page so far, for {index} (set per page by renderBlocks)
+	PageTreeDir           string         // Directory of the current page relative to PagesOutputDir (set per page by exportPageTree)
+	SectionIndex          bool           // Write the current page as index.md (set per page by exportPageTree)
+	SidebarOrder          int            // Position of the current page among its siblings (set per page by exportPageTree)
+	Manifest              *Manifest      // Manifest shared by the current run
+	Report                *RunReport     // Summary shared by the current run
+	Users                 *UserDirectory // User name cache shared by the current run
+	Redirects             *RedirectMap   // Redirects of renamed pages collected by the current run
+	Links                 *LinkChecker   // Links of exported pages, checked at the end of the run (-check-links)
+	BannedContent         *ContentFilter // Patterns that must not appear in generated content
+	BodyCache             *BodyCache     // Converted page bodies reused when only properties changed
+	SyncState             *SyncState     // Last export of each page, used to skip unchanged pages
+	Collection            *Collection    // Metadata of the database being processed (set by processDatabaseType)
+	Navigation            *Navigation    // Pages of the database being processed, for prev/next (set by processDatabaseType)
+	Dashboard             *Dashboard     // Pages counted on the stats dashboard of the current run
+	Progress              *Progress      // Pages processed so far, printed every ProgressInterval pages
+
+	// Nested blocks fetched by the markdown conversion of the current page, kept for its
+	// json-ast (set per page by processPage)
+	Rendered *RenderedBlocks
+
+	// Stop is canceled to interrupt the run, e.g. by Ctrl-C; no page starts after it. Context
+	// carries the Notion requests of the run and is canceled interruptGracePeriod later, so the
+	// pages in progress can finish.
+	Stop    context.Context
+	Context context.Context
+
+	// Each run has its own logger, Notion transport and workspace, so runs in parallel do not
+	// share a rate limit, a request budget or staged files
+	Logger    *slog.Logger      // Receives the messages of the run; nil logs through the default slog logger
+	Notion    *notion.Transport // Sends the Notion API requests of the run; nil sends them with the default limits
+	Workspace *writer.Workspace // Stages the changes of the run with STAGED_WRITES; nil writes directly
+	Stdout    io.Writer         // Receives the page exported with -output -; nil writes to os.Stdout
+	Transport http.RoundTripper // Carries the Notion API requests of the run; nil uses http.DefaultTransport
+}
+
+// context returns the context of the Notion requests of the run
+func (c runConfig) context() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+// stopContext returns the context that interrupts the run, or else the context of its requests
+func (c runConfig) stopContext() context.Context {
+	if c.Stop == nil {
+		return c.context()
+	}
+	return c.Stop
+}
+
+// stopErr returns the error that stops the run before its next page: the request budget was
+// used up or the run was interrupted
+func (c runConfig) stopErr() error {
+	if err := c.budgetErr(); err != nil {
+		return err
+	}
+	if c.stopContext().Err() != nil {
+		return interruptedError(c.stopContext())
+	}
+	return nil
+}
+
+// properties returns the property mapping for the current database type
+func (c runConfig) properties() PropertyMapping {
+	if c.DatabaseType == "diary" {
+		return c.DiaryProperties
+	}
+	return c.BlogProperties
+}
+
+// imagesSubdir returns the subdirectory of ImagesDir for the current database type
+func (c runConfig) imagesSubdir() string {
+	if c.ImagesColocated {
+		// Every page has its own images folder
+		return ""
+	}
+	switch c.DatabaseType {
+	case "diary":
+		return c.DiaryImagesSubdir
+	case "pages":
+		return c.PagesImagesSubdir
+	}
+	return c.BlogImagesSubdir
+}
+
+// imagePath returns the file path of an image stored at rel (relative to ImagesDir)
+func (c runConfig) imagePath(rel string) string {
+	return filepath.Join(c.ImagesDir, filepath.FromSlash(rel))
+}
+
+// imageURL returns the site path of an image stored at rel (relative to ImagesDir)
+func (c runConfig) imageURL(rel string) string {
+	return strings.TrimSuffix(c.ImagesURLPrefix, "/") + "/" + rel
+}
+
+// colocateImages returns the config of a page written to pagePath with IMAGES_COLOCATED: its
+// images go to a folder named after the file (blog/my-post.md → blog/my-post/) and are linked
+// relative to the page, so Astro processes them like images of a content collection. An
+// index page keeps its images in its own directory.
+func (c runConfig) colocateImages(pagePath string) runConfig {
+	name := strings.TrimSuffix(filepath.Base(pagePath), filepath.Ext(pagePath))
+	if name == "index" {
+		c.ImagesDir, c.ImagesURLPrefix = filepath.Dir(pagePath), "."
+	} else {
+		c.ImagesDir, c.ImagesURLPrefix = filepath.Join(filepath.Dir(pagePath), name), "./"+name
+	}
+	return c
+}
+
+// Frontmatter for Astro templates
+type Frontmatter struct {
+	ID               string            `yaml:"id,omitempty" json:"id,omitempty"`
+	Title            string            `yaml:"title" json:"title"`
+	Slug             string            `yaml:"slug,omitempty" json:"slug,omitempty"`
+	Description      string            `yaml:"description,omitempty" json:"description,omitempty"`
+	PublishedAt      string            `yaml:"publishedAt,omitempty" json:"publishedAt,omitempty"`
+	UpdatedAt        string            `yaml:"updatedAt,omitempty" json:"updatedAt,omitempty"`
+	Date             string            `yaml:"date,omitempty" json:"date,omitempty"`
+	Tags             []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	CoverImage       string            `yaml:"coverImage,omitempty" json:"coverImage,omitempty"`
+	HeroImage        string            `yaml:"heroImage,omitempty" json:"heroImage,omitempty"`
+	Icon             string            `yaml:"icon,omitempty" json:"icon,omitempty"`
+	Layout           string            `yaml:"layout,omitempty" json:"layout,omitempty"`
+	Template         string            `yaml:"template,omitempty" json:"template,omitempty"`
+	Draft            bool              `yaml:"draft,omitempty" json:"draft,omitempty"`
+	Weather          string            `yaml:"weather,omitempty" json:"weather,omitempty"`
+	DayOfWeek        string            `yaml:"dayOfWeek,omitempty" json:"dayOfWeek,omitempty"`
+	DateLabel        string            `yaml:"dateLabel,omitempty" json:"dateLabel,omitempty"`
+	NotionURL        string            `yaml:"notionUrl,omitempty" json:"notionUrl,omitempty"`
+	ContentHash      string            `yaml:"contentHash,omitempty" json:"contentHash,omitempty"`
+	ExportedAt       string            `yaml:"exportedAt,omitempty" json:"exportedAt,omitempty"`
+	SourceLastEdited string            `yaml:"sourceLastEdited,omitempty" json:"sourceLastEdited,omitempty"`
+	Robots           string            `yaml:"robots,omitempty" json:"robots,omitempty"`
+	NoSitemap        bool              `yaml:"sitemap,omitempty" json:"noSitemap,omitempty"` // Emitted as sitemap: false
+	JSONLD           *ArticleJSONLD    `yaml:"jsonLd,omitempty" json:"jsonLd,omitempty"`
+	Sidebar          *StarlightSidebar `yaml:"sidebar,omitempty" json:"sidebar,omitempty"`
+}
+
+// publishedDate returns the date the page was published: PublishedAt, or Date when the page
+// has no publication date
+func (f Frontmatter) publishedDate() string {
+	if f.PublishedAt != "" {
+		return f.PublishedAt
+	}
+	return f.Date
+}
+
+// ArticleJSONLD holds the fields needed to render Article JSON-LD
+type ArticleJSONLD struct {
+	Headline      string `yaml:"headline" json:"headline"`
+	DatePublished string `yaml:"datePublished,omitempty" json:"datePublished,omitempty"`
+	DateModified  string `yaml:"dateModified,omitempty" json:"dateModified,omitempty"`
+	Author        string `yaml:"author,omitempty" json:"author,omitempty"`
+	Image         string `yaml:"image,omitempty" json:"image,omitempty"`
+}
+
+// settings holds the settings given by environment variable name, as Options.Env does
+type settings map[string]string
+
+// get returns the setting key, or defaultValue if it is empty
+func (env settings) get(key, defaultValue string) string {
+	return orDefault(env[key], defaultValue)
+}
+
+// orDefault returns value, or defaultValue if value is empty
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// getInt returns the integer setting key, or defaultValue if it is not an integer
+func (env settings) getInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(env[key])
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getBool returns the boolean setting key, or defaultValue if it is not a boolean
+func (env settings) getBool(key string, defaultValue bool) bool {
+	switch strings.ToLower(env[key]) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	}
+	return defaultValue
+}
+
+// calloutImport returns the MDX import of the callout component
+func calloutImport(config runConfig) string {
+	return fmt.Sprintf("import %s from %s;\n\n", config.CalloutComponent, strconv.Quote(config.CalloutImport))
+}
+
+// maxBreadcrumbDepth bounds the parent walk of breadcrumbTrail
+const maxBreadcrumbDepth = 10
+
+// breadcrumbTrail returns the names of the page's ancestors followed by the page itself, root first
+func breadcrumbTrail(ctx context.Context, client *notionapi.Client, pageID notionapi.ObjectID) ([]string, error) {
+	var trail []string
+	parent := notionapi.Parent{Type: notionapi.ParentTypePageID, PageID: notionapi.PageID(pageID)}
+
+	for depth := 0; depth < maxBreadcrumbDepth; depth++ {
+		switch parent.Type {
+		case notionapi.ParentTypePageID:
+			page, err := client.Page.Get(ctx, parent.PageID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get page %s: %w", parent.PageID, err)
+			}
+			trail = append([]string{pageTitle(*page)}, trail...)
+			parent = page.Parent
+		case notionapi.ParentTypeDatabaseID:
+			database, err := client.Database.Get(ctx, parent.DatabaseID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get database %s: %w", parent.DatabaseID, err)
+			}
+			trail = append([]string{DatabaseTitle(database)}, trail...)
+			parent = database.Parent
+		case notionapi.ParentTypeBlockID:
+			block, err := client.Block.Get(ctx, parent.BlockID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get block %s: %w", parent.BlockID, err)
+			}
+			if block.GetParent() == nil {
+				return trail, nil
+			}
+			parent = *block.GetParent()
+		default:
+			// Reached the workspace
+			return trail, nil
+		}
+	}
+	return trail, nil
+}
+
+// pageTitle returns the plain-text value of a page's title property, whatever its name
+func pageTitle(page notionapi.Page) string {
+	for _, prop := range page.Properties {
+		if tp, ok := prop.(*notionapi.TitleProperty); ok {
+			var title strings.Builder
+			for _, rt := range tp.Title {
+				title.WriteString(rt.PlainText)
+			}
+			return title.String()
+		}
+	}
+	return ""
+}
+
+// retrievePageContent retrieves the content of a Notion page and converts it to markdown.
+// The fetched blocks are returned as well so other output formats can reuse them.
+func retrievePageContent(client *notionapi.Client, pageID notionapi.ObjectID, config runConfig) (string, []notionapi.Block, error) {
+	config.logDebug("Retrieving content for page: %s", pageID)
+
+	// Get the children blocks of the page
+	config.logDebug("Fetching children blocks...")
+	blocks, err := notion.FetchBlockChildren(config.context(), client, notionapi.BlockID(pageID), config.Logger)
+	if err != nil {
+		config.logWarn("Error retrieving page content: %v", err)
+		return "", nil, fmt.Errorf("failed to retrieve page content: %w", err)
+	}
+	config.logDebug("Retrieved %d blocks from page", len(blocks))
+
+	markdown, err := renderBlocks(client, pageID, blocks, config)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to retrieve page content: %w", err)
+	}
+	return markdown, blocks, nil
+}
+
+// renderBlocks converts the top-level blocks of a page to markdown, downloading its images.
+// Nested blocks are fetched and converted as well.
+func renderBlocks(client *notionapi.Client, pageID notionapi.ObjectID, blocks []notionapi.Block, config runConfig) (string, error) {
+	// Convert blocks to markdown
+	config.logDebug("Converting blocks to markdown...")
+	config.ImageCount = new(int)
+	markdown, err := blockRenderer(client, pageID, config).Blocks(blocks)
+	if err != nil {
+		return "", err
+	}
+
+	config.logDebug("Successfully converted page content to markdown (%d characters)", len(markdown))
+	return markdown, nil
+}
+
+// blockRenderer returns the renderer of the blocks of a page. Nested blocks are fetched and
+// recorded for the other output formats; images and uploaded videos are saved to ImagesDir and
+// recorded in the manifest.
+func blockRenderer(client *notionapi.Client, pageID notionapi.ObjectID, config runConfig) mdrender.Renderer {
+	renderer := mdrender.Renderer{
+		LineBreakStyle:       config.LineBreakStyle,
+		EmptyParagraphs:      config.EmptyParagraphs,
+		NumberedListContinue: config.NumberedListContinue,
+		CalloutStyle:         config.CalloutStyle,
+		CalloutComponent:     config.CalloutComponent,
+		ImageCaptions:        config.ImageCaptions,
+		ImageAltFallback:     config.ImageAltFallback,
+		Title:                config.PageTitle,
+		ChildPages:           config.DatabaseType == "pages",
+		Logger:               config.Logger,
+		Children: func(block notionapi.Block) ([]notionapi.Block, error) {
+			children, err := notion.FetchBlockChildren(config.context(), client, block.GetID(), config.Logger)
+			if err == nil {
+				config.Rendered.AddChildren(block.GetID(), children)
+			}
+			return children, err
+		},
+		File: func(fileURL string, external bool) (string, error) {
+			localPath, err := downloadImage(fileURL, pageID.String(), external, config)
+			if err != nil {
+				return "", err
+			}
+			// Files are linked with their site path, e.g. "/images/<file>" for ./public/images
+			src := config.imageURL(localPath)
+			config.Rendered.AddAsset(fileURL, src)
+			if err := config.Manifest.RecordFile(config.imagePath(localPath), pageID.String()); err != nil {
+				config.logWarn("Failed to record %s in manifest: %v", localPath, err)
+			}
+			return src, nil
+		},
+		Unsupported: config.Report.CountUnsupportedBlock,
+	}
+	if config.RenderBreadcrumbs {
+		renderer.Breadcrumb = func() ([]string, error) {
+			return breadcrumbTrail(config.context(), client, pageID)
+		}
+	}
+	return renderer
+}
+
+// generateFrontmatterYAML generates YAML frontmatter
+func generateFrontmatterYAML(frontmatter Frontmatter) (string, error) {
+	// Fields are added in the order readers of the files expect, not the struct order
+	fields := &yaml.Node{Kind: yaml.MappingNode}
+
+	// Add ID if present
+	if frontmatter.ID != "" {
+		addYAMLField(fields, "id", yamlPlain(frontmatter.ID))
+	}
+
+	// Add title
+	addYAMLField(fields, "title", yamlText(frontmatter.Title))
+
+	// Add slug if present; Astro uses it as the entry ID
+	if frontmatter.Slug != "" {
+		addYAMLField(fields, "slug", yamlText(frontmatter.Slug))
+	}
+
+	// Add description if present
+	if frontmatter.Description != "" {
+		addYAMLField(fields, "description", yamlText(frontmatter.Description))
+	}
+
+	// Add publishedAt and date if present (without quotes, so they are read as dates)
+	if frontmatter.PublishedAt != "" {
+		addYAMLField(fields, "publishedAt", yamlPlain(frontmatter.PublishedAt))
+	}
+	if frontmatter.Date != "" {
+		addYAMLField(fields, "date", yamlPlain(frontmatter.Date))
+	}
+
+	// Add tags if present (in the format ["tag1", "tag2", "tag3"])
+	if len(frontmatter.Tags) > 0 {
+		tags := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, tag := range frontmatter.Tags {
+			tags.Content = append(tags.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: tag})
+		}
+		addYAMLField(fields, "tags", tags)
+	}
+
+	// Add the page cover and icon if present
+	if frontmatter.CoverImage != "" {
+		addYAMLField(fields, "coverImage", yamlText(frontmatter.CoverImage))
+	}
+	if frontmatter.HeroImage != "" {
+		addYAMLField(fields, "heroImage", yamlText(frontmatter.HeroImage))
+	}
+	if frontmatter.Icon != "" {
+		addYAMLField(fields, "icon", yamlText(frontmatter.Icon))
+	}
+
+	// Add the layout chosen in Notion if present
+	if frontmatter.Layout != "" {
+		addYAMLField(fields, "layout", yamlText(frontmatter.Layout))
+	}
+	if frontmatter.Template != "" {
+		addYAMLField(fields, "template", yamlText(frontmatter.Template))
+	}
+
+	// Add draft if true
+	if frontmatter.Draft {
+		addYAMLField(fields, "draft", yamlPlain("true"))
+	}
+
+	// Add weather if present
+	if frontmatter.Weather != "" {
+		addYAMLField(fields, "weather", yamlText(frontmatter.Weather))
+	}
+
+	// Add the localized weekday and date of diary entries
+	if frontmatter.DayOfWeek != "" {
+		addYAMLField(fields, "dayOfWeek", yamlText(frontmatter.DayOfWeek))
+	}
+	if frontmatter.DateLabel != "" {
+		addYAMLField(fields, "dateLabel", yamlText(frontmatter.DateLabel))
+	}
+
+	// Add robots/sitemap if the page is excluded from search indexing
+	if frontmatter.Robots != "" {
+		addYAMLField(fields, "robots", yamlPlain(frontmatter.Robots))
+	}
+	if frontmatter.NoSitemap {
+		addYAMLField(fields, "sitemap", yamlPlain("false"))
+	}
+
+	// Add notionUrl if present
+	if frontmatter.NotionURL != "" {
+		addYAMLField(fields, "notionUrl", yamlPlain(frontmatter.NotionURL))
+	}
+
+	// Add contentHash if present
+	if frontmatter.ContentHash != "" {
+		addYAMLField(fields, "contentHash", yamlPlain(frontmatter.ContentHash))
+	}
+
+	// Add the export and source edit times if present
+	if frontmatter.ExportedAt != "" {
+		addYAMLField(fields, "exportedAt", yamlPlain(frontmatter.ExportedAt))
+	}
+	if frontmatter.SourceLastEdited != "" {
+		addYAMLField(fields, "sourceLastEdited", yamlPlain(frontmatter.SourceLastEdited))
+	}
+
+	// Add JSON-LD fields as a nested map if present
+	if jsonLD := frontmatter.JSONLD; jsonLD != nil {
+		nested := &yaml.Node{Kind: yaml.MappingNode}
+		addYAMLField(nested, "headline", yamlText(jsonLD.Headline))
+		if jsonLD.DatePublished != "" {
+			addYAMLField(nested, "datePublished", yamlPlain(jsonLD.DatePublished))
+		}
+		if jsonLD.DateModified != "" {
+			addYAMLField(nested, "dateModified", yamlPlain(jsonLD.DateModified))
+		}
+		if jsonLD.Author != "" {
+			addYAMLField(nested, "author", yamlText(jsonLD.Author))
+		}
+		if jsonLD.Image != "" {
+			addYAMLField(nested, "image", yamlPlain(jsonLD.Image))
+		}
+		addYAMLField(fields, "jsonLd", nested)
+	}
+
+	// Add Starlight sidebar fields as a nested map if present
+	if sidebar := frontmatter.Sidebar; sidebar != nil {
+		nested := &yaml.Node{Kind: yaml.MappingNode}
+		if sidebar.Label != "" {
+			addYAMLField(nested, "label", yamlText(sidebar.Label))
+		}
+		if sidebar.Order != 0 {
+			addYAMLField(nested, "order", yamlPlain(strconv.Itoa(sidebar.Order)))
+		}
+		addYAMLField(fields, "sidebar", nested)
+	}
+
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(fields); err != nil {
+		return "", fmt.Errorf("failed to encode frontmatter: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode frontmatter: %v", err)
+	}
+	return out.String(), nil
+}
+
+// addYAMLField appends a key and its value to a YAML mapping
+func addYAMLField(mapping *yaml.Node, key string, value *yaml.Node) {
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+// yamlText returns a free-text value, quoted when it would otherwise be read as another type
+// (e.g. a title of "2024") or be invalid YAML (e.g. "Q&A: part 1"), like yamlString
+func yamlText(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if strings.ContainsAny(value, "\n\r") {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
+
+// yamlPlain returns a value written without quotes when possible, so that YAML reads dates,
+// numbers and booleans as such
+func yamlPlain(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// extractAuthor returns the author names from the author property, or defaultAuthor.
+// People without a name on the page are looked up through users.
+func extractAuthor(ctx context.Context, client *notionapi.Client, page notionapi.Page, authorProperty, defaultAuthor string, users *UserDirectory) string {
+	prop, ok := lookupProperty(page.Properties, authorProperty, "author", "Author")
+	if !ok {
+		return defaultAuthor
+	}
+
+	var names []string
+	switch p := prop.(type) {
+	case *notionapi.PeopleProperty:
+		for _, person := range p.People {
+			if name := users.Name(ctx, client, person); name != "" {
+				names = append(names, name)
+			}
+		}
+	case *notionapi.RichTextProperty:
+		if text := mdrender.PlainText(p.RichText); text != "" {
+			names = append(names, text)
+		}
+	case *notionapi.SelectProperty:
+		if p.Select.Name != "" {
+			names = append(names, p.Select.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		return defaultAuthor
+	}
+	return strings.Join(names, ", ")
+}
+
+// generateFilename generates a filename for the article
+func generateFilename(page notionapi.Page, titleProperty string) string {
+	title := ""
+
+	// Try to get title from properties
+	if titleProp, ok := lookupProperty(page.Properties, titleProperty, "title", "Title", "Name"); ok {
+		if tp, ok := titleProp.(*notionapi.TitleProperty); ok && len(tp.Title) > 0 {
+			title = tp.Title[0].PlainText
+		}
+	}
+
+	// If no title found, use page ID
+	if title == "" {
+		title = page.ID.String()
+	}
+
+	// Replace only invalid filename characters
+	// These characters are invalid in most file systems: / \ : * ? " < > |
+	reg := regexp.MustCompile(`[/\\:*?"<>|]`)
+	filename := reg.ReplaceAllString(title, "_")
+
+	return filename + ".md"
+}
+
+// pageOutputPath returns the file a page is written to, named after its title, slug or the
+// filename pattern of the database
+func pageOutputPath(page notionapi.Page, slug, date string, config runConfig) string {
+	props := config.properties()
+	config.logDebug("Generating filename...")
+	filename := generateFilename(page, props.Title)
+	pattern := config.filenamePattern()
+	if pattern != "" {
+		filename = expandFilenamePattern(pattern, strings.TrimSuffix(filename, ".md"), slug, date, page.ID.String()) + ".md"
+	} else if config.EmitSlug {
+		// The file is named after the slug so that its route matches the slug field
+		filename = slug + ".md"
+	}
+	if config.SectionIndex {
+		// Section roots become the index page of their directory
+		filename = "index.md"
+	}
+	if config.CalloutStyle == "component" {
+		// Components only work in MDX
+		filename = strings.TrimSuffix(filename, ".md") + ".mdx"
+	}
+	config.logDebug("Generated filename: %s", filename)
+
+	// For diary entries, add the date at the beginning of the filename
+	if config.DatabaseType == "diary" && date != "" && pattern == "" && !config.EmitSlug {
+		config.logDebug("Adding date prefix to diary filename...")
+		// Extract just the filename without extension
+		filenameWithoutExt := strings.TrimSuffix(filename, filepath.Ext(filename))
+		// Create new filename with date prefix
+		filename = date + "_" + filenameWithoutExt + filepath.Ext(filename)
+		config.logDebug("Updated filename with date prefix: %s", filename)
+	}
+
+	// Determine the output directory based on database type
+	config.logDebug("Determining output directory...")
+	var outputDir string
+	if config.DatabaseType == "blog" {
+		outputDir = config.BlogOutputDir
+		config.logDebug("Using blog output directory: %s", outputDir)
+	} else if config.DatabaseType == "diary" {
+		outputDir = config.DiaryOutputDir
+		config.logDebug("Using diary output directory: %s", outputDir)
+	} else if config.DatabaseType == "pages" {
+		outputDir = filepath.Join(config.PagesOutputDir, config.PageTreeDir)
+		config.logDebug("Using page tree output directory: %s", outputDir)
+	} else {
+		// Fallback behavior for unknown database types
+		var subDir string
+		if config.DatabaseType == "blog" {
+			subDir = "blog"
+		} else if config.DatabaseType == "diary" {
+			subDir = "diary"
+		}
+		outputDir = filepath.Join("./content", subDir)
+		config.logDebug("Using fallback output directory: %s", outputDir)
+	}
+
+	outputPath := filepath.Join(outputDir, filename)
+	if config.OutputFormat == "json-ast" {
+		outputPath = strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".json"
+	}
+	// A single page export may write the result to an explicit path
+	if config.OutputPath != "" && config.OutputPath != "-" {
+		outputPath = config.OutputPath
+	}
+	return outputPath
+}
+
+// processPage processes a single Notion page and saves it as a markdown file
+func processPage(client *notionapi.Client, page notionapi.Page, config runConfig) error {
+	config.logDebug("Processing page: %s", page.ID)
+	defer config.Progress.PageDone()
+
+	// Excluded pages are not kept, so -prune removes files exported before they were excluded
+	if reason, ok := excludedPage(page, config); ok {
+		config.logInfo("Skipping page %s: %s", page.ID, reason)
+		return nil
+	}
+
+	// Extract title
+	config.logDebug("Extracting title...")
+	props := config.properties()
+	title := ""
+	// "titile" handles a typo in the field name
+	if titleProp, ok := lookupProperty(page.Properties, props.Title, "title", "Title", "Name", "titile"); ok {
+		if tp, ok := titleProp.(*notionapi.TitleProperty); ok && len(tp.Title) > 0 {
+			title = tp.Title[0].PlainText
+		}
+	}
+
+	// Pages skipped because of their content, unlike excluded pages, keep the files of their last
+	// export, so -prune does not unpublish a page while an editor is fixing it
+	if title == "" {
+		config.logInfo("Skipping page %s: no title found", page.ID)
+		config.Manifest.KeepPage(page.ID.String())
+		return nil
+	}
+	config.PageTitle = title
+
+	// Skip pages that match the query but are not finished yet
+	if missing := missingRequiredProperties(page, config.requiredProperties()); len(missing) > 0 {
+		config.logInfo("Skipping page %s: required properties are empty: %s", page.ID, strings.Join(missing, ", "))
+		config.Report.AddIncompletePage(title, missing)
+		config.Manifest.KeepPage(page.ID.String())
+		return nil
+	}
+
+	// Create frontmatter with page ID as fallback
+	frontmatter := Frontmatter{
+		ID:    page.ID.String(),
+		Title: title,
+	}
+	slug := config.slugStrategy().Slug(page, title, props)
+	if config.EmitSlug {
+		frontmatter.Slug = slug
+	}
+
+	// Try to get ID from properties (use the ID column value from Notion)
+	if idProp, ok := lookupProperty(page.Properties, props.ID, "ID", "id"); ok {
+		// Convert the property to string and extract the last part (the actual ID value)
+		idStr := fmt.Sprintf("%v", idProp)
+		parts := strings.Split(idStr, " ")
+		if len(parts) > 0 {
+			// Get the last part and remove any closing brace
+			lastPart := strings.TrimSuffix(parts[len(parts)-1], "}")
+			frontmatter.ID = lastPart
+		} else {
+			frontmatter.ID = idStr
+		}
+	}
+
+	// Extract tags if available
+	config.logDebug("Extracting tags...")
+	if tagsProp, ok := lookupProperty(page.Properties, props.Tags, "tags", "Tags"); ok {
+		if mp, ok := tagsProp.(*notionapi.MultiSelectProperty); ok {
+			tags := make([]string, len(mp.MultiSelect))
+			for i, tag := range mp.MultiSelect {
+				tags[i] = tag.Name
+			}
+			if config.CanonicalOutput {
+				tags = canonicalTags(tags)
+			}
+			frontmatter.Tags = tags
+			config.logDebug("Found %d tags", len(tags))
+		}
+	} else {
+		config.logDebug("No tags found")
+	}
+
+	// For diary entries, extract weather only (description is no longer needed)
+	if config.DatabaseType == "diary" {
+		config.logDebug("Extracting weather for diary entry...")
+		// Extract weather
+		if weatherProp, ok := lookupProperty(page.Properties, props.Weather, "weather"); ok {
+			if rtp, ok := weatherProp.(*notionapi.RichTextProperty); ok && len(rtp.RichText) > 0 {
+				frontmatter.Weather = rtp.RichText[0].PlainText
+				config.logDebug("Weather: %s", frontmatter.Weather)
+			} else {
+				config.logDebug("No weather text found")
+			}
+		} else {
+			config.logDebug("No weather property found")
+		}
+	}
+
+	// Exclude the page from search indexing if the noindex checkbox is set
+	if noindexProp, ok := lookupProperty(page.Properties, props.NoIndex, "noindex", "NoIndex"); ok {
+		if cp, ok := noindexProp.(*notionapi.CheckboxProperty); ok && cp.Checkbox {
+			if config.NoIndexField == "sitemap" {
+				frontmatter.NoSitemap = true
+			} else {
+				frontmatter.Robots = "noindex"
+			}
+			config.logDebug("Page is marked noindex (%s)", config.NoIndexField)
+		}
+	}
+
+	// Let the site pick the design chosen with the template property
+	setPageLayout(&frontmatter, page, config)
+
+	// Link back to the source page so editors can jump to it from the site
+	if config.IncludeNotionURL {
+		frontmatter.NotionURL = page.URL
+	}
+
+	// Use CreatedTime as the date
+	frontmatter.Date = page.CreatedTime.Format("2006-01-02")
+	if config.DatabaseType == "diary" && config.DiaryDateLocale != "" {
+		frontmatter.DayOfWeek, frontmatter.DateLabel = localizeDate(frontmatter.Date, config.DiaryDateLocale)
+	}
+
+	// Use the publication date property if the database has one
+	if name, publishedProp, ok := lookupNamedProperty(page.Properties, props.PublishedAt, "publishedAt", "PublishedAt", "published_at"); ok {
+		publishedAt, err := coerceDate(title, name, publishedProp)
+		if err != nil {
+			config.logInfo("Skipping page %s: %v", page.ID, err)
+			config.Report.AddValidationError(err)
+			config.Manifest.KeepPage(page.ID.String())
+			return nil
+		}
+		frontmatter.PublishedAt = publishedAt
+	}
+
+	// Skip pages that did not change since the last run
+	settings := conversionSettings(config)
+	if path, ok := config.SyncState.Unchanged(page, settings); ok && !config.Force {
+		config.logInfo("Skipping unchanged page %s (%s)", page.ID, title)
+		config.Manifest.KeepPage(page.ID.String())
+		if data, err := config.Workspace.ReadFile(path); err == nil {
+			config.Links.Record(path, string(data))
+		}
+		config.Collection.AddPage(page, frontmatter)
+		config.Dashboard.AddPage(config.collectionName(), frontmatter)
+		config.Navigation.AddPage(page.ID.String(), path, frontmatter)
+		config.Report.AddUnchangedPage()
+		markPublished(client, page, title, config)
+		return nil
+	}
+
+	outputPath := pageOutputPath(page, slug, frontmatter.Date, config)
+	if config.ImagesColocated && config.OutputPath != "-" {
+		// Images are saved next to the page file and linked relatively
+		config = config.colocateImages(outputPath)
+	}
+
+	// Download the page cover and icon next to the images of the body
+	setPageCover(&frontmatter, page, config)
+	if config.EmitIcon {
+		frontmatter.Icon = pageIcon(page, config)
+	}
+
+	// Retrieve page content
+	config.logDebug("Retrieving content for page %s...", page.ID)
+	var pageContent string
+	var blocks []notionapi.Block
+	var err error
+	if config.OutputFormat == "json-ast" {
+		config.Rendered = newRenderedBlocks()
+	}
+	existingBody, keptBody := existingPageBody(config, page.ID.String())
+	if keptBody {
+		config.logInfo("Keeping the exported body of page %s", page.ID)
+		pageContent = existingBody
+	} else {
+		pageContent, blocks, err = retrievePageBody(client, page, config)
+	}
+	if errors.Is(err, notion.ErrRequestBudgetExceeded) {
+		// Neither a placeholder nor a skipped page; the run stops
+		return config.budgetErr()
+	}
+	if err != nil && config.context().Err() != nil {
+		// The requests of the page were canceled by the interruption; its files are left as they are
+		return interruptedError(config.context())
+	}
+	if err != nil {
+		config.logWarn("Failed to retrieve content for page %s: %v", page.ID, err)
+		switch config.ContentErrorPolicy {
+		case "fail":
+			return fmt.Errorf("stopping: failed to retrieve content for page %s and ON_CONTENT_ERROR is fail: %w", page.ID, err)
+		case "skip":
+			// Unlike keep, the files of the last export are pruned
+			config.Report.AddContentError(title, err, "skipped")
+			return nil
+		case "keep":
+			// Leave the files of the previous run in place, also when pruning
+			config.Manifest.KeepPage(page.ID.String())
+			config.Report.AddContentError(title, err, "kept existing file")
+			return nil
+		}
+		// If we can't retrieve the content, use a placeholder
+		config.Report.AddContentError(title, err, "wrote placeholder")
+		pageContent = "This content was imported from Notion, but the content could not be retrieved."
+	} else if !keptBody {
+		config.logDebug("Successfully retrieved content for page %s", page.ID)
+	}
+
+	if config.StripTitleHeading {
+		if stripped := mdrender.StripTitleHeading(pageContent, title); stripped != pageContent {
+			config.logDebug("Removing the heading that repeats the title %q", title)
+			pageContent = stripped
+		}
+	}
+	if config.NormalizeHeadings {
+		pageContent = mdrender.NormalizeHeadings(pageContent)
+	}
+
+	// For blog entries, set description as first 70 characters of content with newlines converted to spaces
+	if config.DatabaseType == "blog" && pageContent != "" {
+		config.logDebug("Generating description for blog entry...")
+		// Replace newlines with spaces
+		descriptionText := strings.ReplaceAll(pageContent, "\n", " ")
+		// Remove extra spaces
+		descriptionText = regexp.MustCompile(`\s+`).ReplaceAllString(descriptionText, " ")
+		// Trim spaces
+		descriptionText = strings.TrimSpace(descriptionText)
+
+		// Convert markdown links to plain text first
+		descriptionText = mdrender.LinksToPlainText(descriptionText)
+		descriptionText = mdrender.StripFormatting(descriptionText)
+
+		// Get first 70 characters or less if content is shorter
+		// Use runes to correctly handle multi-byte characters like Japanese
+		runes := []rune(descriptionText)
+		if len(runes) > 70 {
+			frontmatter.Description = string(runes[:70]) + "..."
+			config.logDebug("Generated description (truncated): %s", frontmatter.Description)
+		} else {
+			frontmatter.Description = descriptionText
+			config.logDebug("Generated description: %s", frontmatter.Description)
+		}
+	} else if config.DatabaseType == "blog" {
+		config.logDebug("Not setting description for blog entry: %s (empty content)", title)
+	}
+
+	// Assemble Article JSON-LD fields from the same values as the frontmatter
+	if config.EmitJSONLD {
+		frontmatter.JSONLD = &ArticleJSONLD{
+			Headline:      frontmatter.Title,
+			DatePublished: frontmatter.publishedDate(),
+			DateModified:  page.LastEditedTime.Format("2006-01-02"),
+			Author:        extractAuthor(config.context(), client, page, props.Author, config.AuthorName, config.Users),
+			Image:         mdrender.FirstImage(pageContent),
+		}
+	}
+
+	// Add sidebar label/order for Starlight docs
+	if config.OutputProfile == "starlight" {
+		if frontmatter.Sidebar, err = extractSidebar(page, title, props, config.SidebarOrder); err != nil {
+			config.logInfo("Skipping page %s: %v", page.ID, err)
+			config.Report.AddValidationError(err)
+			config.Manifest.KeepPage(page.ID.String())
+			return nil
+		}
+	}
+
+	// Process empty lines: remove single empty lines, but keep one if there are multiple consecutive empty lines
+	config.logDebug("Processing empty lines...")
+	body := pageContent
+	if !keptBody && config.EmptyParagraphs != "preserve" {
+		// The kept body was processed when it was exported; processing it again would drop its blank lines
+		body = mdrender.ProcessEmptyLines(pageContent)
+	}
+
+	// MDX pages import the callout component; a kept body already has the import
+	if config.CalloutStyle == "component" && !strings.HasPrefix(body, calloutImport(config)) {
+		body = calloutImport(config) + body
+	}
+
+	// Let builds key caches and "updated" badges off the body as written
+	if config.EmitContentHash {
+		sum := sha256.Sum256([]byte(body))
+		frontmatter.ContentHash = hex.EncodeToString(sum[:])
+	}
+
+	// Let editors audit how stale a published page is compared to Notion
+	if config.EmitSyncMetadata {
+		frontmatter.ExportedAt = syncMetadataTime(time.Now())
+		frontmatter.SourceLastEdited = syncMetadataTime(page.LastEditedTime)
+	}
+
+	// Generate frontmatter YAML
+	config.logDebug("Generating frontmatter YAML...")
+	frontmatterYAML, err := generateFrontmatterYAML(frontmatter)
+	if err != nil {
+		config.logWarn("Failed to generate frontmatter for page %s: %v", page.ID, err)
+		config.Report.AddValidationError(fmt.Errorf("page %q: %v", title, err))
+		config.Manifest.KeepPage(page.ID.String())
+		return nil
+	}
+	config.logDebug("Frontmatter generated successfully")
+
+	// Create content with frontmatter
+	config.logDebug("Creating content with frontmatter...")
+	content := fmt.Sprintf("---\n%s---\n\n%s", frontmatterYAML, body)
+	if config.CanonicalOutput {
+		content = canonicalText(content)
+	}
+
+	// Keep confidential scraps in Notion from reaching the public site
+	if findings := config.BannedContent.Find(content); len(findings) > 0 {
+		for _, finding := range findings {
+			config.Report.AddBannedContent(title, finding)
+		}
+		if config.BannedContent.Strict() {
+			// The page fails the run, and the file of an earlier run is kept rather than pruned
+			config.logWarn("Skipping page %s: banned content found", page.ID)
+			config.Report.AddFailedPage(title, errors.New("banned content found"))
+			config.Manifest.KeepPage(page.ID.String())
+			return nil
+		}
+		config.logWarn("Banned content found in page %s", page.ID)
+	}
+
+	// Do not write a page whose users or mentions could not be looked up within the budget
+	if err := config.budgetErr(); err != nil {
+		return err
+	}
+
+	// Save to file
+	data := []byte(content)
+	if config.OutputFormat == "json-ast" {
+		// Downstream tools render the page themselves from the normalized blocks
+		data, err = encodePageAST(buildPageAST(page, frontmatter, blocks, config))
+		if err != nil {
+			config.logWarn("Failed to encode page %s: %v", page.ID, err)
+			config.Report.AddFailedPage(title, err)
+			return nil
+		}
+	}
+
+	// A single page export may print the result or write it to an explicit path
+	if config.OutputPath == "-" {
+		if _, err := config.stdout().Write(data); err != nil {
+			config.logWarn("Failed to print page %s: %v", page.ID, err)
+			config.Report.AddFailedPage(title, err)
+		}
+		return nil
+	}
+	if config.EmitSyncMetadata {
+		data = keepExportedAt(data, outputPath, frontmatter.ExportedAt, config)
+	}
+
+	// Create the directory if it doesn't exist
+	config.logDebug("Ensuring output directory exists: %s", filepath.Dir(outputPath))
+	if err := config.Workspace.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		config.logWarn("Failed to create output directory %s: %v", filepath.Dir(outputPath), err)
+		config.Report.AddFailedPage(title, err)
+		return nil
+	}
+	config.logDebug("Saving content to file: %s", outputPath)
+	if err := config.Workspace.WriteFile(outputPath, data, 0644); err != nil {
+		config.logWarn("Failed to write article to file %s: %v", outputPath, err)
+		config.Report.AddFailedPage(title, err)
+		return nil
+	}
+
+	// Post-process before recording so the manifest checksum matches the final file
+	runPostProcessFile(config, outputPath)
+	if err := config.Manifest.RecordFile(outputPath, page.ID.String()); err != nil {
+		config.logWarn("Failed to record article in manifest: %v", err)
+	}
+	config.Links.Record(outputPath, content)
+	config.Report.AddExportedPage(outputPath)
+	if problems := oversizedPage(config, page.ID.String(), len(data)); len(problems) > 0 {
+		config.logWarn("Page %s is oversized: %s", page.ID, strings.Join(problems, ", "))
+		config.Report.AddOversizedPage(filepath.ToSlash(outputPath), problems)
+	}
+	if config.OutputPath == "" {
+		if err := config.SyncState.Record(page, settings, outputPath); err != nil {
+			config.logWarn("Failed to record page in sync state: %v", err)
+		}
+		config.Navigation.AddPage(page.ID.String(), outputPath, frontmatter)
+		markPublished(client, page, title, config)
+	}
+
+	// Remove the file written under the page's previous title
+	if config.OutputPath == "" {
+		removeRenamedOutputs(config, page.ID.String(), outputPath)
+
+		// Redirect the old paths listed in Notion to the page
+		if route, ok := pageRoute(config, outputPath); ok {
+			for _, from := range redirectFromPaths(page, props.RedirectFrom) {
+				config.Redirects.Add(from, route)
+			}
+		}
+	}
+
+	// Write computed stats next to the post for site-wide dashboards
+	if config.WriteStatsSidecar {
+		statsPath, err := writeStatsSidecar(outputPath, pageContent, config.Workspace)
+		if err != nil {
+			config.logWarn("Failed to write stats sidecar: %v", err)
+		} else if err := config.Manifest.RecordFile(statsPath, page.ID.String()); err != nil {
+			config.logWarn("Failed to record stats sidecar in manifest: %v", err)
+		}
+	}
+
+	config.Collection.AddPage(page, frontmatter)
+	config.Dashboard.AddPage(config.collectionName(), frontmatter)
+
+	config.logDebug("Successfully converted article: %s", outputPath)
+	config.logInfo("Successfully converted article: %s", outputPath)
+	return nil
+}
+
+// fetchDatabase initializes the Notion client, fetches the database, and queries it for pages.
+// Pages are streamed as result pages arrive; the error channel reports a failed query once the
+// page channel is closed.
+func fetchDatabase(config runConfig) (*notionapi.Client, *notionapi.Database, <-chan notionapi.Page, <-chan error, error) {
+	// Initialize Notion client
+	client := config.notionClient()
+
+	// Determine which database ID to use
+	databaseID := config.databaseID()
+	config.logInfo("Processing %s database...", config.DatabaseType)
+
+	// Fetch database
+	database, err := client.Database.Get(config.stopContext(), notionapi.DatabaseID(databaseID))
+	if err != nil {
+		return nil, nil, nil, nil, notionError("failed to get %s database: %w", config.DatabaseType, err)
+	}
+
+	config.logInfo("Found database: %s", database.Title[0].PlainText)
+
+	// Query database for pages
+	query, err := databaseQuery(config)
+	if err != nil {
+		return nil, nil, nil, nil, configError("invalid %s query: %w", config.DatabaseType, err)
+	}
+
+	// Listing the pages stops with the run; only the pages in progress are given time to finish
+	pages, errs := notion.StreamDatabasePages(config.stopContext(), client, notionapi.DatabaseID(databaseID), query, config.Logger)
+	return client, database, pages, errs, nil
+}
+
+// databaseQueryFilter returns the conditions a page must meet to be exported: the published
+// checkbox unchecked and the done checkbox checked, unless the mapping disables them
+func databaseQueryFilter(props PropertyMapping) notionapi.AndCompoundFilter {
+	var filter notionapi.AndCompoundFilter
+	if published := props.publishedProperty(); published != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: published,
+			Checkbox: &notionapi.CheckboxFilterCondition{
+				DoesNotEqual: true, // published が false のデータ
+			},
+		})
+	}
+	if done := props.doneProperty(); done != "" {
+		filter = append(filter, notionapi.PropertyFilter{
+			Property: done,
+			Checkbox: &notionapi.CheckboxFilterCondition{
+				Equals: true, // done が true のデータ
+			},
+		})
+	}
+	return filter
+}
+
+// readConfig builds the configuration of dbType from the config file and the settings of
+// opts, logging to logger
+func readConfig(opts Options, dbType string, logger *slog.Logger) (runConfig, error) {
+	// Load the config file if it exists
+	var fileConfig FileConfig
+	var err error
+	if opts.ConfigData != nil {
+		fileConfig, err = parseFileConfig(opts.ConfigData, "from Options.ConfigData")
+	} else {
+		fileConfig, err = loadFileConfig(orDefault(opts.ConfigFile, DefaultConfigFile))
+	}
+	if err != nil {
+		return runConfig{}, configError("%w", err)
+	}
+	env := settings(opts.Env)
+	config, err := configFromFile(fileConfig, dbType, env, logger)
+	if err != nil {
+		return runConfig{}, err
+	}
+	if opts.Token != "" {
+		config.NotionAPIToken = opts.Token
+	}
+	if err := useStoredToken(&config, env, logger); err != nil {
+		return runConfig{}, err
+	}
+	return config, nil
+}
+
+// missingTokenError is the error of a run without a Notion token
+func missingTokenError() error {
+	return configError("NOTION_API_TOKEN environment variable is required (or connect with `notion-to-astro-go login`)")
+}
+
+// configFromFile builds the configuration from the settings in env and fileConfig, logging
+// to logger
+func configFromFile(fileConfig FileConfig, dbType string, env settings, logger *slog.Logger) (runConfig, error) {
+	bannedContent, err := newContentFilter(fileConfig.BannedContent.Patterns, env.getBool("BANNED_CONTENT_STRICT", fileConfig.BannedContent.Strict))
+	if err != nil {
+		return runConfig{}, configError("%w", err)
+	}
+
+	// Get configuration from environment variables, falling back to the config file
+	outputProfile := env.get("OUTPUT_PROFILE", "default")
+	config := runConfig{
+		NotionAPIToken:        env.get("NOTION_API_TOKEN", ""),
+		NotionBlogDatabaseID:  env.get("NOTION_BLOG_DATABASE_ID", fileConfig.Blog.DatabaseID),
+		NotionDiaryDatabaseID: env.get("NOTION_DIARY_DATABASE_ID", fileConfig.Diary.DatabaseID),
+		NotionRootPageID:      env.get("NOTION_ROOT_PAGE_ID", fileConfig.Pages.RootPageID),
+		BlogOutputDir:         env.get("BLOG_OUTPUT_DIR", orDefault(fileConfig.Blog.OutputDir, "./content/blog")),
+		DiaryOutputDir:        env.get("DIARY_OUTPUT_DIR", orDefault(fileConfig.Diary.OutputDir, "./content/diary")),
+		PagesOutputDir:        env.get("PAGES_OUTPUT_DIR", orDefault(fileConfig.Pages.OutputDir, "./content/pages")),
+		ImagesDir:             env.get("IMAGES_DIR", orDefault(fileConfig.ImagesDir, "./public/images")),
+		ImagesURLPrefix:       env.get("IMAGES_URL_PREFIX", orDefault(fileConfig.ImagesURLPrefix, "/images")),
+		BlogImagesSubdir:      env.get("BLOG_IMAGES_SUBDIR", fileConfig.Blog.ImagesSubdir),
+		DiaryImagesSubdir:     env.get("DIARY_IMAGES_SUBDIR", fileConfig.Diary.ImagesSubdir),
+		PagesImagesSubdir:     env.get("PAGES_IMAGES_SUBDIR", fileConfig.Pages.ImagesSubdir),
+		ImagesPerPage:         env.getBool("IMAGES_PER_PAGE", fileConfig.ImagesPerPage),
+		ImagesColocated:       env.getBool("IMAGES_COLOCATED", fileConfig.ImagesColocated),
+		ImageFilename:         env.get("IMAGE_FILENAME", fileConfig.ImageFilename),
+		ImageAltFallback:      env.get("IMAGE_ALT_FALLBACK", "image"),
+		ImageCaptions:         env.get("IMAGE_CAPTIONS", "none"),
+		ImageUserAgent:        env.get("IMAGE_USER_AGENT", fileConfig.ImageUserAgent),
+		ImageHeaders:          fileConfig.ImageHeaders,
+		CheckExternalLinks:    env.getBool("CHECK_EXTERNAL_LINKS", false),
+		PostProcessFileCmd:    env.get("POST_PROCESS_FILE_COMMAND", ""),
+		PostProcessCmd:        env.get("POST_PROCESS_COMMAND", ""),
+		SummaryPageID:         env.get("NOTION_SUMMARY_PAGE_ID", ""),
+		SummaryDatabaseID:     env.get("NOTION_SUMMARY_DATABASE_ID", ""),
+		BannedContent:         bannedContent,
+		CompressImages:        env.getBool("COMPRESS_IMAGES", true),
+		MaxDecodePixels:       env.getInt("IMAGE_MAX_DECODE_PIXELS", 25000000),
+		ImageFormat:           env.get("IMAGE_FORMAT", "original"),
+		ImageDecodeCommand:    env.get("IMAGE_DECODE_COMMAND", ""),
+		DedupeImages:          env.getBool("DEDUPE_IMAGES", false),
+		ImageQuality:          env.getInt("IMAGE_QUALITY", 0),
+		ImageMaxWidth:         env.getInt("IMAGE_MAX_WIDTH", 0),
+		ImageMaxHeight:        env.getInt("IMAGE_MAX_HEIGHT", 0),
+		WarnPageSizeKB:        env.getInt("WARN_PAGE_SIZE_KB", 0),
+		WarnPageImages:        env.getInt("WARN_PAGE_IMAGES", 0),
+		WarnPageAssetsMB:      env.getInt("WARN_PAGE_ASSETS_MB", 0),
+		IncludeNotionURL:      env.getBool("INCLUDE_NOTION_URL", false),
+		CanonicalOutput:       env.getBool("CANONICAL_OUTPUT", false),
+		NoIndexField:          env.get("NOINDEX_FIELD", "robots"),
+		EmitJSONLD:            env.getBool("EMIT_JSON_LD", false),
+		EmitContentHash:       env.getBool("EMIT_CONTENT_HASH", false),
+		EmitSyncMetadata:      env.getBool("EMIT_SYNC_METADATA", false),
+		EmitAdjacentPosts:     env.getBool("EMIT_ADJACENT_POSTS", false),
+		EmitSlug:              env.getBool("EMIT_SLUG", false),
+		EmitIcon:              env.getBool("EMIT_ICON", false),
+		CoverImageField:       env.get("COVER_IMAGE_FIELD", ""),
+		LayoutField:           env.get("LAYOUT_FIELD", ""),
+		LayoutMap:             fileConfig.Layouts,
+		AuthorName:            env.get("AUTHOR_NAME", ""),
+		DiaryDateLocale:       env.get("DIARY_DATE_LOCALE", ""),
+		WriteStatsSidecar:     env.getBool("WRITE_STATS_SIDECAR", false),
+		LineBreakStyle:        env.get("LINE_BREAK_STYLE", "spaces"),
+		EmptyParagraphs:       env.get("EMPTY_PARAGRAPHS", "collapse"),
+		NumberedListContinue:  env.getBool("NUMBERED_LIST_CONTINUE", false),
+		CalloutStyle:          env.get("CALLOUT_STYLE", defaultCalloutStyle(outputProfile)),
+		CalloutComponent:      env.get("CALLOUT_COMPONENT", "Callout"),
+		CalloutImport:         env.get("CALLOUT_COMPONENT_IMPORT", ""),
+		OutputProfile:         outputProfile,
+		RenderBreadcrumbs:     env.getBool("RENDER_BREADCRUMBS", false),
+		NormalizeHeadings:     env.getBool("NORMALIZE_HEADINGS", false),
+		StripTitleHeading:     env.getBool("STRIP_TITLE_HEADING", false),
+		ManifestFile:          env.get("MANIFEST_FILE", "./notion-to-astro.manifest.json"),
+		SyncStateFile:         env.get("SYNC_STATE_FILE", "./.notion-sync.json"),
+		StatsDashboardFile:    env.get("STATS_DASHBOARD_FILE", ""),
+		Concurrency:           env.getInt("CONCURRENCY", 1),
+		NotionRequestsPerSec:  env.getInt("NOTION_REQUESTS_PER_SECOND", 3),
+		NotionMaxAttempts:     env.getInt("NOTION_MAX_ATTEMPTS", 5),
+		NotionMaxRequests:     env.getInt("NOTION_MAX_REQUESTS", 0),
+		UserCacheFile:         env.get("USER_CACHE_FILE", ""),
+		BodyCacheDir:          env.get("BODY_CACHE_DIR", ""),
+		CollectionMetadataDir: env.get("COLLECTION_METADATA_DIR", ""),
+		PruneMode:             env.get("PRUNE_MODE", "delete"),
+		PruneArchiveDir:       env.get("PRUNE_ARCHIVE_DIR", ""),
+		StagedWrites:          env.getBool("STAGED_WRITES", false),
+		StagingDir:            env.get("STAGING_DIR", defaultStagingDir),
+		ContentErrorPolicy:    env.get("ON_CONTENT_ERROR", "placeholder"),
+		RedirectsFile:         env.get("REDIRECTS_FILE", ""),
+		RedirectsFormat:       env.get("REDIRECTS_FORMAT", "astro"),
+		Debug:                 env.getBool("DEBUG", false),
+		LogFormat:             env.get("LOG_FORMAT", "text"),
+		ProgressInterval:      env.getInt("LOG_PROGRESS_EVERY", 50),
+		BlogProperties:        fileConfig.Blog.Properties,
+		DiaryProperties:       fileConfig.Diary.Properties,
+		SkipPages:             fileConfig.SkipPages,
+		BlogRequired:          fileConfig.Blog.Required,
+		DiaryRequired:         fileConfig.Diary.Required,
+		BlogFilename:          fileConfig.Blog.Filename,
+		DiaryFilename:         fileConfig.Diary.Filename,
+		BlogSlugStrategy:      fileConfig.Blog.SlugStrategy,
+		DiarySlugStrategy:     fileConfig.Diary.SlugStrategy,
+		BlogFilter:            fileConfig.Blog.Filter,
+		DiaryFilter:           fileConfig.Diary.Filter,
+		BlogSorts:             fileConfig.Blog.Sorts,
+		DiarySorts:            fileConfig.Diary.Sorts,
+		Databases:             fileConfig.Databases,
+		DatabaseType:          dbType,
+	}
+	return config, nil
+}
+
+// useStoredToken falls back to the OAuth token saved by `login` when no token is configured
+func useStoredToken(config *runConfig, env settings, logger *slog.Logger) error {
+	if config.NotionAPIToken != "" {
+		return nil
+	}
+	token, err := storedAccessToken(TokenFile(env), env, logger)
+	if err != nil {
+		return configError("%w", err)
+	}
+	config.NotionAPIToken = token
+	return nil
+}
+
+// loadConfig loads the application configuration, applies opts and validates the result
+func loadConfig(opts Options) (runConfig, error) {
+	if opts.Quiet && opts.Verbose {
+		return runConfig{}, configError("-quiet cannot be used together with -verbose")
+	}
+	// Apply the options before the configuration is read, so its messages follow them too
+	logger, err := runLogger(opts, logLevel(opts.Quiet, opts.Verbose), opts.LogFormat)
+	if err != nil {
+		return runConfig{}, configError("invalid -log-format: %s. Must be 'text' or 'json'", opts.LogFormat)
+	}
+
+	dbType := orDefault(opts.Type, "all")
+	if opts.RootPage != "" {
+		dbType = "pages"
+	}
+	config, err := readConfig(opts, dbType, logger)
+	if err != nil {
+		return runConfig{}, err
+	}
+	env := settings(opts.Env)
+	if opts.RootPage != "" {
+		config.NotionRootPageID = opts.RootPage
+	}
+	config.OutputFormat = orDefault(opts.Format, "markdown")
+	config.SinglePageID = opts.Page
+	config.PagesFile = opts.PagesFile
+	config.OutputPath = opts.Output
+	config.Stdout = opts.Stdout
+	config.Transport = opts.Transport
+	if opts.StagingDir != "" {
+		config.StagingDir = opts.StagingDir
+	}
+	config.SlugStrategy = opts.SlugStrategy
+	config.RefreshImages = opts.RefreshImages
+	config.Prune = opts.Prune
+	config.SyncDeletions = opts.SyncDeletions
+	config.CheckLinks = opts.CheckLinks
+	config.FrontmatterOnly = opts.FrontmatterOnly
+	config.Force = opts.Force
+	config.MarkPublished = opts.MarkPublished
+	if opts.Concurrency != 0 {
+		config.Concurrency = opts.Concurrency
+	}
+	if opts.MaxRequests != 0 {
+		config.NotionMaxRequests = opts.MaxRequests
+	}
+	// -quiet overrides DEBUG
+	config.Debug = !opts.Quiet && (config.Debug || opts.Verbose)
+	config.Quiet = opts.Quiet
+	if opts.LogFormat != "" {
+		config.LogFormat = opts.LogFormat
+	}
+	if config.Logger, err = runLogger(opts, logLevel(config.Quiet, config.Debug), config.LogFormat); err != nil {
+		return runConfig{}, configError("invalid LOG_FORMAT: %s. Must be 'text' or 'json'", config.LogFormat)
+	}
+
+	// Validate configuration
+	if config.NotionAPIToken == "" {
+		return runConfig{}, missingTokenError()
+	}
+	if config.Concurrency < 1 {
+		return runConfig{}, configError("invalid concurrency: %d. Must be at least 1", config.Concurrency)
+	}
+	if config.NotionRequestsPerSec < 0 {
+		return runConfig{}, configError("invalid NOTION_REQUESTS_PER_SECOND: %d. Must be 0 (no limit) or more", config.NotionRequestsPerSec)
+	}
+	if config.ProgressInterval < 0 {
+		return runConfig{}, configError("invalid LOG_PROGRESS_EVERY: %d. Must be 0 (no progress) or more", config.ProgressInterval)
+	}
+	if config.NotionMaxAttempts < 1 {
+		return runConfig{}, configError("invalid NOTION_MAX_ATTEMPTS: %d. Must be at least 1", config.NotionMaxAttempts)
+	}
+	if config.NotionMaxRequests < 0 {
+		return runConfig{}, configError("invalid NOTION_MAX_REQUESTS: %d. Must be 0 (no limit) or more", config.NotionMaxRequests)
+	}
+	if mode := config.EmptyParagraphs; mode != "collapse" && mode != "preserve" && mode != "br" {
+		return runConfig{}, configError("invalid EMPTY_PARAGRAPHS: %s. Must be 'collapse', 'preserve' or 'br'", mode)
+	}
+	if config.LineBreakStyle != "spaces" && config.LineBreakStyle != "br" {
+		return runConfig{}, configError("invalid LINE_BREAK_STYLE: %s. Must be 'spaces' or 'br'", config.LineBreakStyle)
+	}
+	if style := config.CalloutStyle; style != "blockquote" && style != "html" && style != "aside" && style != "component" {
+		return runConfig{}, configError("invalid CALLOUT_STYLE: %s. Must be 'blockquote', 'html', 'aside' or 'component'", style)
+	}
+	if config.CalloutStyle == "component" && config.CalloutImport == "" {
+		return runConfig{}, configError("CALLOUT_COMPONENT_IMPORT is required when CALLOUT_STYLE is component")
+	}
+	if config.OutputFormat != "markdown" && config.OutputFormat != "json-ast" {
+		return runConfig{}, configError("invalid format: %s. Must be 'markdown' or 'json-ast'", config.OutputFormat)
+	}
+	if config.OutputProfile != "default" && config.OutputProfile != "starlight" {
+		return runConfig{}, configError("invalid OUTPUT_PROFILE: %s. Must be 'default' or 'starlight'", config.OutputProfile)
+	}
+	if config.CoverImageField != "" && config.CoverImageField != "coverImage" && config.CoverImageField != "heroImage" {
+		return runConfig{}, configError("invalid COVER_IMAGE_FIELD: %s. Must be 'coverImage' or 'heroImage'", config.CoverImageField)
+	}
+	if config.LayoutField != "" && config.LayoutField != "layout" && config.LayoutField != "template" {
+		return runConfig{}, configError("invalid LAYOUT_FIELD: %s. Must be 'layout' or 'template'", config.LayoutField)
+	}
+	if config.NoIndexField != "robots" && config.NoIndexField != "sitemap" {
+		return runConfig{}, configError("invalid NOINDEX_FIELD: %s. Must be 'robots' or 'sitemap'", config.NoIndexField)
+	}
+
+	if fallback := config.ImageAltFallback; fallback != "image" && fallback != "empty" && fallback != "title" && fallback != "filename" {
+		return runConfig{}, configError("invalid IMAGE_ALT_FALLBACK: %s. Must be 'image', 'empty', 'title' or 'filename'", fallback)
+	}
+	if captions := config.ImageCaptions; captions != "none" && captions != "text" && captions != "figure" {
+		return runConfig{}, configError("invalid IMAGE_CAPTIONS: %s. Must be 'none', 'text' or 'figure'", captions)
+	}
+	if err := images.ValidateFormat(config.ImageFormat); err != nil {
+		return runConfig{}, configError("invalid IMAGE_FORMAT: %w", err)
+	}
+	if config.ImageQuality < 0 || config.ImageQuality > 100 {
+		return runConfig{}, configError("invalid IMAGE_QUALITY: %d. Must be between 1 and 100, or 0 for the default", config.ImageQuality)
+	}
+	if config.ImageMaxWidth < 0 || config.ImageMaxHeight < 0 {
+		return runConfig{}, configError("invalid IMAGE_MAX_WIDTH or IMAGE_MAX_HEIGHT: %dx%d. Must be 0 (no limit) or more", config.ImageMaxWidth, config.ImageMaxHeight)
+	}
+	if config.WarnPageSizeKB < 0 || config.WarnPageImages < 0 || config.WarnPageAssetsMB < 0 {
+		return runConfig{}, configError("invalid WARN_PAGE_SIZE_KB, WARN_PAGE_IMAGES or WARN_PAGE_ASSETS_MB: %d, %d, %d. Must be 0 (no check) or more", config.WarnPageSizeKB, config.WarnPageImages, config.WarnPageAssetsMB)
+	}
+	if err := validateImageFilename(config.ImageFilename); err != nil {
+		return runConfig{}, configError("invalid IMAGE_FILENAME: %w", err)
+	}
+	skipPages, err := parseSkipPages(append(append(config.SkipPages, env["SKIP_PAGES"]), opts.Skip...)...)
+	if err != nil {
+		return runConfig{}, configError("invalid skip list: %w", err)
+	}
+	config.SkipPages = skipPages
+	if value := env["IMAGE_HEADERS"]; value != "" {
+		headers, err := parseHeaderList(value)
+		if err != nil {
+			return runConfig{}, configError("invalid IMAGE_HEADERS: %w", err)
+		}
+		config.ImageHeaders = headers
+	}
+	if value := env["LAYOUT_MAP"]; value != "" {
+		layouts, err := parseLayoutMap(value)
+		if err != nil {
+			return runConfig{}, configError("invalid LAYOUT_MAP: %w", err)
+		}
+		config.LayoutMap = layouts
+	}
+	if _, ok := dateLocales[config.DiaryDateLocale]; config.DiaryDateLocale != "" && !ok {
+		return runConfig{}, configError("invalid DIARY_DATE_LOCALE: %s. Must be %s", config.DiaryDateLocale, supportedDateLocales())
+	}
+	if config.PruneMode != "delete" && config.PruneMode != "trash" && config.PruneMode != "list" {
+		return runConfig{}, configError("invalid PRUNE_MODE: %s. Must be 'delete', 'trash' or 'list'", config.PruneMode)
+	}
+	if policy := config.ContentErrorPolicy; policy != "placeholder" && policy != "skip" && policy != "keep" && policy != "fail" {
+		return runConfig{}, configError("invalid ON_CONTENT_ERROR: %s. Must be 'placeholder', 'skip', 'keep' or 'fail'", policy)
+	}
+	if config.RedirectsFormat != "astro" && config.RedirectsFormat != "netlify" && config.RedirectsFormat != "vercel" {
+		return runConfig{}, configError("invalid REDIRECTS_FORMAT: %s. Must be 'astro', 'netlify' or 'vercel'", config.RedirectsFormat)
+	}
+	if config.Prune && config.SinglePageID != "" {
+		return runConfig{}, configError("-prune cannot be used together with -page")
+	}
+	if config.MarkPublished && config.Prune {
+		// Published pages no longer match the query, so pruning would delete them
+		return runConfig{}, configError("-mark-published cannot be used together with -prune")
+	}
+	if config.PagesFile != "" && (config.SinglePageID != "" || config.Prune) {
+		return runConfig{}, configError("-pages-file cannot be used together with -page or -prune")
+	}
+	if config.SyncDeletions && (config.Prune || config.SinglePageID != "" || config.PagesFile != "") {
+		// Only a run over whole collections tells which pages were not exported
+		return runConfig{}, configError("-sync-deletions cannot be used together with -prune, -page or -pages-file")
+	}
+	if config.FrontmatterOnly && config.OutputFormat == "json-ast" {
+		return runConfig{}, configError("-frontmatter-only cannot be used with -format json-ast")
+	}
+	if config.OutputPath != "" && config.SinglePageID == "" {
+		return runConfig{}, configError("-output can only be used together with -page")
+	}
+	if err := validateDatabases(config.Databases); err != nil {
+		return runConfig{}, configError("invalid databases in config file: %w", err)
+	}
+	for _, pattern := range []string{config.BlogFilename, config.DiaryFilename} {
+		if err := validateFilenamePattern(pattern); err != nil {
+			return runConfig{}, configError("invalid filename in config file: %w", err)
+		}
+	}
+	for _, name := range []string{config.BlogSlugStrategy, config.DiarySlugStrategy} {
+		if err := validateSlugStrategy(name); err != nil {
+			return runConfig{}, configError("invalid slugStrategy in config file: %w", err)
+		}
+	}
+	if err := validateQuery(config.BlogFilter, config.BlogSorts); err != nil {
+		return runConfig{}, configError("invalid blog query in config file: %w", err)
+	}
+	if err := validateQuery(config.DiaryFilter, config.DiarySorts); err != nil {
+		return runConfig{}, configError("invalid diary query in config file: %w", err)
+	}
+	_, additional := config.findDatabase(config.DatabaseType)
+	if config.SinglePageID != "" || config.PagesFile != "" {
+		// The database IDs are only needed to pick the page flavour, see processSinglePage
+		switch config.DatabaseType {
+		case "blog", "diary", "pages", "all":
+			return config, nil
+		}
+		if additional {
+			return config, nil
+		}
+	}
+
+	// Validate database ID based on the selected type
+	if config.DatabaseType == "blog" {
+		if config.NotionBlogDatabaseID == "" {
+			return runConfig{}, configError("NOTION_BLOG_DATABASE_ID environment variable is required for blog database")
+		}
+	} else if config.DatabaseType == "diary" {
+		if config.NotionDiaryDatabaseID == "" {
+			return runConfig{}, configError("NOTION_DIARY_DATABASE_ID environment variable is required for diary database")
+		}
+	} else if config.DatabaseType == "pages" {
+		if config.NotionRootPageID == "" {
+			return runConfig{}, configError("NOTION_ROOT_PAGE_ID environment variable or -root-page is required for pages mode")
+		}
+	} else if config.DatabaseType == "all" {
+		// With additional databases, the blog and diary databases are optional
+		if config.NotionBlogDatabaseID == "" && len(config.Databases) == 0 {
+			return runConfig{}, configError("NOTION_BLOG_DATABASE_ID environment variable is required for 'all' mode")
+		}
+		if config.NotionDiaryDatabaseID == "" && len(config.Databases) == 0 {
+			return runConfig{}, configError("NOTION_DIARY_DATABASE_ID environment variable is required for 'all' mode")
+		}
+	} else if !additional {
+		return runConfig{}, configError("invalid database type: %s. Must be 'blog', 'diary', 'pages', 'all' or the name of a database in the config file", config.DatabaseType)
+	}
+
+	return config, nil
+}
+
+// processDatabaseType processes a specific database type
+func processDatabaseType(config runConfig, dbType string) error {
+	config.logDebug("Processing database type: %s", dbType)
+
+	// Create a copy of the config for the specified database
+	dbConfig := config.databaseConfig(dbType)
+	config.logDebug("Created database-specific configuration")
+	if dbConfig.DatabaseType == "data" {
+		return exportDataCollection(dbConfig)
+	}
+
+	// Fetch database and pages
+	config.logDebug("Fetching database and pages...")
+	client, database, pages, errs, err := fetchDatabase(dbConfig)
+	if err != nil {
+		return err
+	}
+	if dbConfig.CollectionMetadataDir != "" {
+		dbConfig.Collection = newCollection(dbType, database)
+	}
+	if dbConfig.EmitAdjacentPosts && dbConfig.OutputFormat != "json-ast" {
+		dbConfig.Navigation = &Navigation{}
+	}
+	dbConfig.Progress = newProgress(dbConfig.collectionName(), 0, dbConfig.ProgressInterval, dbConfig.Logger)
+
+	// Process each article while the remaining pages are still being fetched
+	config.logDebug("Processing pages (%d in parallel)...", dbConfig.Concurrency)
+	count, err := processPages(pages, dbConfig.Concurrency, func(n int, page notionapi.Page) error {
+		if err := dbConfig.stopErr(); err != nil {
+			return err
+		}
+		config.logDebug("Processing page %d (ID: %s)", n, page.ID)
+		return processPage(client, page, dbConfig)
+	})
+	if err != nil {
+		return err
+	}
+	if err := <-errs; err != nil {
+		// Stop before pruning so pages that were not fetched are not treated as removed
+		return notionError("failed to query %s database: %w", dbType, err)
+	}
+	config.logInfo("Found %d articles in Notion database", count)
+
+	// Link each post to its neighbours now that all pages of the collection are known
+	if err := dbConfig.Navigation.Apply(dbConfig); err != nil {
+		config.logWarn("%v", err)
+	}
+
+	// Write collection-level metadata for index pages
+	if path, err := dbConfig.Collection.Save(dbConfig.CollectionMetadataDir, dbConfig.Workspace); err != nil {
+		config.logWarn("%v", err)
+	} else if path != "" {
+		if err := dbConfig.Manifest.RecordFile(path, database.ID.String()); err != nil {
+			config.logWarn("Failed to record collection metadata in manifest: %v", err)
+		}
+		config.logInfo("Wrote collection metadata: %s", path)
+	}
+
+	config.logDebug("Completed processing database type: %s", dbType)
+	return nil
+}
+
+// parseHeaderList parses IMAGE_HEADERS, "Name: value" pairs separated by semicolons
+func parseHeaderList(value string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, headerValue, ok := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%q is not a \"Name: value\" pair", strings.TrimSpace(pair))
+		}
+		headers[name] = strings.TrimSpace(headerValue)
+	}
+	return headers, nil
+}
+
+// runConversion exports the pages selected by the configuration. A run in which some pages
+// failed still writes the other pages and returns the error of RunReport.Err. Canceling ctx
+// stops the run: no page starts after it, and the pages in progress get interruptGracePeriod
+// to finish before their Notion requests are canceled. A run that stops early, interrupted or
+// for an error, records the pages it wrote in the manifest and the sync state, unless they
+// are staged, and its error is joined with the failures of the run.
+func runConversion(ctx context.Context, config runConfig) error {
+	if ctx.Err() != nil {
+		return interruptedError(ctx)
+	}
+	requests, cancelRequests := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancelRequests(nil)
+	defer context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(interruptGracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancelRequests(context.Cause(ctx))
+		case <-requests.Done():
+		}
+	})()
+	config.Stop, config.Context = ctx, requests
+	config.Notion = notion.NewTransport(config.Transport, config.NotionRequestsPerSec, config.NotionMaxAttempts, config.NotionMaxRequests, config.Logger)
+
+	// Create output directories if they don't exist (a single page export creates only its own)
+	if config.SinglePageID == "" && config.PagesFile == "" {
+		if config.DatabaseType != "pages" {
+			for _, name := range config.selectedDatabases() {
+				if err := os.MkdirAll(config.databaseConfig(name).outputDir(), 0755); err != nil {
+					return fmt.Errorf("failed to create %s output directory: %w", name, err)
+				}
+			}
+		}
+
+		if config.DatabaseType == "pages" {
+			if err := os.MkdirAll(config.PagesOutputDir, 0755); err != nil {
+				return fmt.Errorf("failed to create pages output directory: %w", err)
+			}
+		}
+	}
+
+	// Create images directory if it doesn't exist
+	if err := os.MkdirAll(config.ImagesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create images directory: %w", err)
+	}
+
+	// Keep the changes of the run out of the content directory until the whole run succeeded
+	if config.StagedWrites {
+		workspace, err := writer.New(config.StagingDir, config.Logger)
+		if err != nil {
+			return err
+		}
+		defer workspace.Discard()
+		config.Workspace = workspace
+	}
+
+	// Load the manifest of previously generated files
+	manifest, err := loadManifest(config.ManifestFile, config.Workspace)
+	if err != nil {
+		return err
+	}
+	config.Manifest = manifest
+	config.Report = newRunReport()
+
+	// Load cached user names to avoid repeated Users API lookups
+	users, err := loadUserDirectory(config.UserCacheFile, config.Workspace, config.Logger)
+	if err != nil {
+		return err
+	}
+	config.Users = users
+
+	// Load the redirects of previously renamed pages
+	if config.RedirectsFile != "" {
+		redirects, err := loadRedirectMap(config.RedirectsFile, config.RedirectsFormat, config.Workspace)
+		if err != nil {
+			return err
+		}
+		config.Redirects = redirects
+	}
+	if config.CheckLinks {
+		config.Links = newLinkChecker(config.CheckExternalLinks)
+	}
+	config.BodyCache = newBodyCache(config.BodyCacheDir, config.Workspace, config.Logger)
+
+	// Explicitly requested pages are always exported
+	if config.SinglePageID == "" && config.PagesFile == "" {
+		syncState, err := loadSyncState(config.SyncStateFile, config.Workspace)
+		if err != nil {
+			return err
+		}
+		config.SyncState = syncState
+
+		// The dashboard covers whole collections, so single pages do not update it
+		if config.StatsDashboardFile != "" {
+			config.Dashboard = &Dashboard{}
+		}
+	}
+
+	if config.SinglePageID != "" {
+		// Export a single page only
+		err = processSinglePage(config)
+	} else if config.PagesFile != "" {
+		// Export an explicit list of pages
+		err = processPageList(config)
+	} else if config.DatabaseType == "all" {
+		// Process the blog and diary databases and the additional databases side by side;
+		// their Notion requests share the rate limit
+		config.logInfo("Processing all database types...")
+		err = processDatabases(config.selectedDatabases(), func(name string) error {
+			return processDatabaseType(config, name)
+		})
+	} else if config.DatabaseType == "pages" {
+		// Export the child-page tree of the root page
+		err = processPageTree(config)
+	} else {
+		// Process the specified database type
+		err = processDatabaseType(config, config.DatabaseType)
+	}
+	interrupted := ctx.Err() != nil
+	if budgetErr := config.budgetErr(); budgetErr != nil {
+		// Requests failing for the budget are reported as such, not as Notion failures
+		err = budgetErr
+	} else if interrupted && ExitCode(err) != exitInterrupted {
+		err = errors.Join(interruptedError(ctx), err)
+	}
+	if err != nil && config.Workspace != nil {
+		// Keep the previous output rather than applying part of the run
+		config.Workspace.Discard()
+		return err
+	}
+	config.logDebug("Sent %d Notion API requests", config.Notion.Sent())
+	if err != nil {
+		// The pages written so far stay recorded for the next run; the pages that were not
+		// reached are neither removed nor checked
+		config.logWarn("Run stopped early; keeping the %d pages exported so far", len(config.Report.ExportedPages()))
+		config.Prune, config.SyncDeletions, config.Links, config.Dashboard = false, false, nil, nil
+	}
+	runErr := err
+
+	// Remove files of pages that were not exported in this run
+	if config.Prune {
+		pruneStaleFiles(config, prunedDirs(config))
+	}
+	// Remove files of pages that were archived, deleted or unpublished in Notion
+	if config.SyncDeletions {
+		client := config.notionClient()
+		for _, name := range config.selectedDatabases() {
+			syncDeletedPages(client, config.databaseConfig(name))
+		}
+	}
+
+	// Check links against the pages that remain after pruning
+	config.Links.Check(config)
+
+	if err := config.Manifest.Save(); err != nil {
+		config.Workspace.Discard()
+		return errors.Join(runErr, fmt.Errorf("failed to save manifest: %w", err))
+	}
+	if err := config.Users.Save(); err != nil {
+		config.logWarn("Failed to save user cache: %v", err)
+	}
+	if config.Dashboard != nil {
+		now := time.Now()
+		config.SyncState.RecordRun(now, len(config.Report.ExportedPages()), config.Report.UnchangedPages())
+		if err := config.Dashboard.Write(config.StatsDashboardFile, config, now); err != nil {
+			config.logWarn("%v", err)
+		} else {
+			config.logInfo("Wrote stats dashboard: %s", config.StatsDashboardFile)
+		}
+	}
+	if err := config.SyncState.Save(); err != nil {
+		config.logWarn("Failed to save sync state: %v", err)
+	}
+	if err := config.Redirects.Save(); err != nil {
+		config.logWarn("Failed to save redirects: %v", err)
+	}
+	if err := config.Workspace.Commit(); err != nil {
+		return fmt.Errorf("%w; the staged files are kept in %s", err, config.Workspace.Dir())
+	}
+
+	if runErr != nil {
+		config.Report.Print(config.Logger)
+		return errors.Join(runErr, config.Report.Err())
+	}
+
+	// Run the configured post-process command, e.g. a formatter or `astro check`
+	runPostProcess(config)
+
+	// Share the sync status with editors in Notion
+	if config.SummaryPageID != "" || config.SummaryDatabaseID != "" {
+		if err := postRunSummary(config.notionClient(), config, time.Now()); err != nil {
+			config.logWarn("Failed to post run summary: %v", err)
+		}
+	}
+
+	config.Report.Print(config.Logger)
+	if err := config.Report.Err(); err != nil {
+		return err
+	}
+	config.logInfo("Conversion completed!")
+	return nil
+}
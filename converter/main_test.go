@@ -0,0 +1,861 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+
+	"notion-to-astro-go/internal/mdrender"
+)
+
+// fakeBlockService serves block children from memory, keyed by parent block ID
+type fakeBlockService struct {
+	notionapi.BlockService
+	children map[notionapi.BlockID][]notionapi.Block
+}
+
+func (f *fakeBlockService) GetChildren(_ context.Context, id notionapi.BlockID, _ *notionapi.Pagination) (*notionapi.GetChildrenResponse, error) {
+	return &notionapi.GetChildrenResponse{Results: f.children[id]}, nil
+}
+
+// newFakeClient returns a client whose page "page" has the given top-level blocks
+func newFakeClient(blocks ...notionapi.Block) *notionapi.Client {
+	return &notionapi.Client{
+		Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{"page": blocks}},
+	}
+}
+
+func headingBlock(text string) notionapi.Block {
+	return &notionapi.Heading2Block{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeHeading2},
+		Heading2:   notionapi.Heading{RichText: richText(text)},
+	}
+}
+
+func richText(text string) []notionapi.RichText {
+	return []notionapi.RichText{{PlainText: text}}
+}
+
+func paragraphBlock(text string) notionapi.Block {
+	return &notionapi.ParagraphBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeParagraph},
+		Paragraph:  notionapi.Paragraph{RichText: richText(text)},
+	}
+}
+
+func bulletedBlock(text string) notionapi.Block {
+	return &notionapi.BulletedListItemBlock{
+		BasicBlock:       notionapi.BasicBlock{Type: notionapi.BlockTypeBulletedListItem},
+		BulletedListItem: notionapi.ListItem{RichText: richText(text)},
+	}
+}
+
+func numberedBlock(text string) notionapi.Block {
+	return &notionapi.NumberedListItemBlock{
+		BasicBlock:       notionapi.BasicBlock{Type: notionapi.BlockTypeNumberedListItem},
+		NumberedListItem: notionapi.ListItem{RichText: richText(text)},
+	}
+}
+
+// convertBlocks runs retrievePageContent against blocks and applies mdrender.ProcessEmptyLines like processPage does
+func convertBlocks(t *testing.T, config runConfig, blocks ...notionapi.Block) string {
+	t.Helper()
+	if config.LineBreakStyle == "" {
+		config.LineBreakStyle = "spaces"
+	}
+	markdown, _, err := retrievePageContent(newFakeClient(blocks...), "page", config)
+	if err != nil {
+		t.Fatalf("retrievePageContent() error = %v", err)
+	}
+	if config.EmptyParagraphs == "preserve" {
+		return markdown
+	}
+	return mdrender.ProcessEmptyLines(markdown)
+}
+
+func TestBlogDescriptionGeneration(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Short content without newlines",
+			content:  "This is a short blog post content.",
+			expected: "This is a short blog post content.",
+		},
+		{
+			name:     "Content with newlines",
+			content:  "This is a blog post\nwith newlines\nin the content.",
+			expected: "This is a blog post with newlines in the content.",
+		},
+		{
+			name:     "Long content with newlines",
+			content:  "This is a very long blog post content that exceeds 70 characters\nand has newlines\nin it. The description should be limited to 70 characters and newlines should be converted to spaces.",
+			expected: "This is a very long blog post content that exceeds 70 characters and h",
+		},
+		{
+			name:     "Content with multiple consecutive spaces",
+			content:  "This is a blog post  with   multiple    consecutive     spaces.",
+			expected: "This is a blog post with multiple consecutive spaces.",
+		},
+		{
+			name:     "Content with multiple newlines",
+			content:  "This is a blog post\n\nwith\n\n\nmultiple\n\nnewlines.",
+			expected: "This is a blog post with multiple newlines.",
+		},
+		{
+			name:     "Short Japanese content",
+			content:  "これは短い日本語のブログ記事です。",
+			expected: "これは短い日本語のブログ記事です。",
+		},
+		{
+			name:     "Japanese content with newlines",
+			content:  "これは日本語の\nブログ記事\nです。",
+			expected: "これは日本語の ブログ記事 です。",
+		},
+		{
+			name:     "Long Japanese content",
+			content:  "これは70文字を超える長い日本語のブログ記事です。日本語は1文字が複数バイトで表現されるため、バイト数ではなく文字数でカウントする必要があります。このテストでは、70文字を超える部分が正しく切り取られることを確認します。",
+			expected: "これは70文字を超える長い日本語のブログ記事です。日本語は1文字が複数バイトで表現されるため、バイト数ではなく文字数でカウントする必要があり",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Replace newlines with spaces
+			descriptionText := strings.ReplaceAll(tt.content, "\n", " ")
+			// Remove extra spaces
+			descriptionText = regexp.MustCompile(`\s+`).ReplaceAllString(descriptionText, " ")
+			// Trim spaces
+			descriptionText = strings.TrimSpace(descriptionText)
+			// Get first 70 characters or less if content is shorter
+			// Use runes to correctly handle multi-byte characters like Japanese
+			runes := []rune(descriptionText)
+			if len(runes) > 70 {
+				descriptionText = string(runes[:70])
+			}
+
+			if descriptionText != tt.expected {
+				t.Errorf("Blog description generation failed. Got: %q, Want: %q", descriptionText, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListGrouping(t *testing.T) {
+	tests := []struct {
+		name     string
+		blocks   []notionapi.Block
+		expected string
+	}{
+		{
+			name:     "List between paragraphs",
+			blocks:   []notionapi.Block{paragraphBlock("Intro"), bulletedBlock("a"), bulletedBlock("b"), paragraphBlock("After")},
+			expected: "Intro  \n\n- a  \n- b  \n\nAfter  \n",
+		},
+		{
+			name:     "Bulleted list followed by numbered list",
+			blocks:   []notionapi.Block{bulletedBlock("a"), numberedBlock("x")},
+			expected: "- a  \n\n1. x  ",
+		},
+		{
+			name:     "Paragraphs without lists",
+			blocks:   []notionapi.Block{paragraphBlock("First"), paragraphBlock("Second")},
+			expected: "First  \nSecond  \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := convertBlocks(t, runConfig{}, tt.blocks...)
+			if result != tt.expected {
+				t.Errorf("converted markdown = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNumberedListStart(t *testing.T) {
+	blocks := []notionapi.Block{
+		numberedBlock("one"), numberedBlock("two"),
+		paragraphBlock("Interruption"),
+		numberedBlock("three"),
+		headingBlock("Next section"),
+		numberedBlock("first again"),
+	}
+
+	tests := []struct {
+		name     string
+		resume   bool
+		expected string
+	}{
+		{
+			name:     "Restart numbering by default",
+			resume:   false,
+			expected: "1. one  \n2. two  \n\nInterruption  \n\n1. three  \n\n## Next section  \n\n1. first again  ",
+		},
+		{
+			name:     "Continue numbering after interruption",
+			resume:   true,
+			expected: "1. one  \n2. two  \n\nInterruption  \n\n3. three  \n\n## Next section  \n\n1. first again  ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := convertBlocks(t, runConfig{NumberedListContinue: tt.resume}, blocks...)
+			if result != tt.expected {
+				t.Errorf("converted markdown = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNestedBlocks(t *testing.T) {
+	parent := func(id notionapi.BlockID, blockType notionapi.BlockType) notionapi.BasicBlock {
+		return notionapi.BasicBlock{ID: id, Type: blockType, HasChildren: true}
+	}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {
+			&notionapi.BulletedListItemBlock{
+				BasicBlock:       parent("bullet", notionapi.BlockTypeBulletedListItem),
+				BulletedListItem: notionapi.ListItem{RichText: richText("a")},
+			},
+			&notionapi.NumberedListItemBlock{
+				BasicBlock:       parent("numbered", notionapi.BlockTypeNumberedListItem),
+				NumberedListItem: notionapi.ListItem{RichText: richText("x")},
+			},
+			&notionapi.ToggleBlock{
+				BasicBlock: parent("toggle", notionapi.BlockTypeToggle),
+				Toggle:     notionapi.Toggle{RichText: richText("More <details>")},
+			},
+			&notionapi.QuoteBlock{
+				BasicBlock: parent("quote", notionapi.BlockTypeQuote),
+				Quote:      notionapi.Quote{RichText: richText("Quoted")},
+			},
+		},
+		"bullet":   {bulletedBlock("b"), bulletedBlock("c")},
+		"numbered": {paragraphBlock("Detail")},
+		"toggle":   {paragraphBlock("Hidden")},
+		"quote":    {bulletedBlock("point")},
+	}}}
+
+	markdown, _, err := retrievePageContent(client, "page", runConfig{LineBreakStyle: "spaces"})
+	if err != nil {
+		t.Fatalf("retrievePageContent() error = %v", err)
+	}
+	expected := "- a  \n  - b  \n  - c  \n\n1. x  \n   Detail  \n\n" +
+		"<details>\n<summary>More &lt;details&gt;</summary>\n\nHidden  \n\n</details>  \n\n" +
+		"> Quoted  \n> \n> - point  \n"
+	if result := mdrender.ProcessEmptyLines(markdown); result != expected {
+		t.Errorf("converted markdown = %q, want %q", result, expected)
+	}
+}
+
+func TestToggleHeading(t *testing.T) {
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {
+			&notionapi.Heading2Block{
+				BasicBlock: notionapi.BasicBlock{ID: "faq", Type: notionapi.BlockTypeHeading2, HasChildren: true},
+				Heading2:   notionapi.Heading{RichText: richText("FAQ & more"), IsToggleable: true},
+			},
+			&notionapi.Heading3Block{
+				BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeHeading3},
+				Heading3:   notionapi.Heading{RichText: richText("Plain")},
+			},
+		},
+		"faq": {paragraphBlock("Answer")},
+	}}}
+
+	markdown, _, err := retrievePageContent(client, "page", runConfig{LineBreakStyle: "spaces"})
+	if err != nil {
+		t.Fatalf("retrievePageContent() error = %v", err)
+	}
+	expected := "<details>\n<summary><h2>FAQ &amp; more</h2></summary>\n\nAnswer  \n\n</details>  \n\n### Plain  \n"
+	if result := mdrender.ProcessEmptyLines(markdown); result != expected {
+		t.Errorf("converted markdown = %q, want %q", result, expected)
+	}
+}
+
+func TestEquations(t *testing.T) {
+	inline := []notionapi.RichText{
+		{PlainText: "Energy is "},
+		{Type: "equation", PlainText: "E = mc^2", Equation: &notionapi.Equation{Expression: " E = mc^2 "}},
+		{PlainText: " here"},
+	}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {
+			&notionapi.ParagraphBlock{
+				BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeParagraph},
+				Paragraph:  notionapi.Paragraph{RichText: inline},
+			},
+			&notionapi.EquationBlock{
+				BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeEquation},
+				Equation:   notionapi.Equation{Expression: "\\int_0^1 x\\,dx = \\frac{1}{2}"},
+			},
+		},
+	}}}
+
+	markdown, _, err := retrievePageContent(client, "page", runConfig{LineBreakStyle: "spaces"})
+	if err != nil {
+		t.Fatalf("retrievePageContent() error = %v", err)
+	}
+	expected := "Energy is $E = mc^2$ here  \n$$\n\\int_0^1 x\\,dx = \\frac{1}{2}\n$$  \n"
+	if result := mdrender.ProcessEmptyLines(markdown); result != expected {
+		t.Errorf("converted markdown = %q, want %q", result, expected)
+	}
+}
+
+func TestTableBlocks(t *testing.T) {
+	row := func(cells ...string) notionapi.Block {
+		block := &notionapi.TableRowBlock{BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeTableRowBlock}}
+		for _, cell := range cells {
+			block.TableRow.Cells = append(block.TableRow.Cells, richText(cell))
+		}
+		return block
+	}
+	table := func(id notionapi.BlockID, columnHeader, rowHeader bool) notionapi.Block {
+		return &notionapi.TableBlock{
+			BasicBlock: notionapi.BasicBlock{ID: id, Type: notionapi.BlockTypeTableBlock, HasChildren: true},
+			Table:      notionapi.Table{TableWidth: 2, HasColumnHeader: columnHeader, HasRowHeader: rowHeader},
+		}
+	}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page":   {paragraphBlock("Intro"), table("header", true, true), table("plain", false, false)},
+		"header": {row("Name", "Value"), row("a|b", "line\nbreak")},
+		"plain":  {row("x", "")},
+	}}}
+
+	markdown, _, err := retrievePageContent(client, "page", runConfig{LineBreakStyle: "spaces"})
+	if err != nil {
+		t.Fatalf("retrievePageContent() error = %v", err)
+	}
+	expected := "Intro  \n\n| Name | Value |\n| --- | --- |\n| **a\\|b** | line<br>break |\n\n" +
+		"|  |  |\n| --- | --- |\n| x |  |\n"
+	if result := mdrender.ProcessEmptyLines(markdown); result != expected {
+		t.Errorf("converted markdown = %q, want %q", result, expected)
+	}
+}
+
+func TestUnsupportedBlocksAreReported(t *testing.T) {
+	report := newRunReport()
+	blocks := []notionapi.Block{
+		paragraphBlock("Visible"),
+		&notionapi.TemplateBlock{BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeTemplate}},
+		&notionapi.UnsupportedBlock{},
+	}
+
+	result := convertBlocks(t, runConfig{Report: report}, blocks...)
+
+	if result != "Visible  \n" {
+		t.Errorf("converted markdown = %q, want only the paragraph", result)
+	}
+	if report.unsupportedBlocks["template"] != 1 || report.unsupportedBlocks["unsupported"] != 1 {
+		t.Errorf("unsupported blocks = %v, want one template and one unsupported", report.unsupportedBlocks)
+	}
+}
+
+func TestDownloadImageConditionalRefresh(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{ImagesDir: t.TempDir(), Manifest: manifest}
+	imageURL := server.URL + "/photo.png"
+
+	var filename string
+	for _, refresh := range []bool{false, false, true} {
+		config.RefreshImages = refresh
+		if filename, err = downloadImage(imageURL, "page", true, config); err != nil {
+			t.Fatalf("downloadImage() error = %v", err)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("expected 1 full download, got %d", downloads)
+	}
+	if etag, _ := manifest.ImageValidators(filepath.Join(config.ImagesDir, filename)); etag != `"v1"` {
+		t.Errorf("expected stored ETag, got %q", etag)
+	}
+}
+
+func TestDownloadImageKeepsLargeImages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	// 16 pixels exceed the limit, so the image is saved without being decoded
+	config := runConfig{ImagesDir: t.TempDir(), CompressImages: true, MaxDecodePixels: 10}
+	filename, err := downloadImage(server.URL+"/panorama.png", "page", true, config)
+	if err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(config.ImagesDir, filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Errorf("expected the original bytes to be saved")
+	}
+	if entries, _ := os.ReadDir(config.ImagesDir); len(entries) != 1 {
+		t.Errorf("expected only the image in the images dir, got %d entries", len(entries))
+	}
+}
+
+func TestDownloadImageSubdirectories(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	config := runConfig{
+		ImagesDir:         t.TempDir(),
+		ImagesURLPrefix:   "/assets/",
+		DatabaseType:      "diary",
+		DiaryImagesSubdir: "diary",
+		ImagesPerPage:     true,
+	}
+	filename, err := downloadImage(server.URL+"/photo.png", "page", true, config)
+	if err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+
+	if !regexp.MustCompile(`^diary/page/[0-9a-f]{16}\.png$`).MatchString(filename) {
+		t.Errorf("unexpected image path %q", filename)
+	}
+	if _, err := os.Stat(config.imagePath(filename)); err != nil {
+		t.Errorf("image not saved: %v", err)
+	}
+	if url := config.imageURL(filename); url != "/assets/"+filename {
+		t.Errorf("imageURL() = %q", url)
+	}
+}
+
+func TestDownloadImageColocated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := runConfig{
+		ImagesDir:        "./public/images",
+		ImagesURLPrefix:  "/images",
+		DatabaseType:     "blog",
+		BlogImagesSubdir: "blog",
+		ImagesPerPage:    true,
+		ImagesColocated:  true,
+		ImageFilename:    "{index}",
+	}.colocateImages(filepath.Join(dir, "blog", "my-post.md"))
+	filename, err := downloadImage(server.URL+"/cover.png", "page", true, config)
+	if err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+
+	if filename != "1.png" {
+		t.Errorf("unexpected image path %q", filename)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blog", "my-post", "1.png")); err != nil {
+		t.Errorf("image not saved next to the page: %v", err)
+	}
+	if url := config.imageURL(filename); url != "./my-post/1.png" {
+		t.Errorf("imageURL() = %q", url)
+	}
+	index := config.colocateImages(filepath.Join(dir, "docs", "index.md"))
+	if url := index.imageURL(filename); url != "./1.png" || index.ImagesDir != filepath.Join(dir, "docs") {
+		t.Errorf("index page: imageURL() = %q, ImagesDir = %q", url, index.ImagesDir)
+	}
+}
+
+func TestDownloadImageHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != "exporter/1.0" || r.Referer() != "https://example.com/" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	headers, err := parseHeaderList("Referer: https://example.com/; ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{ImagesDir: t.TempDir(), ImageUserAgent: "exporter/1.0", ImageHeaders: headers}
+	if _, err := downloadImage(server.URL+"/photo.png", "page", true, config); err != nil {
+		t.Fatalf("downloadImage() error = %v", err)
+	}
+
+	config.ImageUserAgent = ""
+	if _, err := downloadImage(server.URL+"/other.png", "page", true, config); err == nil {
+		t.Error("expected the download without the User-Agent to fail")
+	}
+	if _, err := parseHeaderList("Referer https://example.com/"); err == nil {
+		t.Error("expected an error for a header without a colon")
+	}
+}
+
+func TestDownloadImageDedupes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	manifest, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{ImagesDir: filepath.Join(dir, "images"), Manifest: manifest, DedupeImages: true}
+	download := func(imageURL, pageID string) string {
+		filename, err := downloadImage(imageURL, pageID, false, config)
+		if err != nil {
+			t.Fatalf("downloadImage() error = %v", err)
+		}
+		if err := manifest.RecordFile(config.imagePath(filename), pageID); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	// The same image on another page, and the same data uploaded again, link one file
+	first := download(server.URL+"/secure/1111/photo.png?X-Amz-Signature=a", "page-a")
+	if second := download(server.URL+"/secure/1111/photo.png?X-Amz-Signature=b", "page-b"); second != first {
+		t.Errorf("expected page-b to link %q, got %q", first, second)
+	}
+	if third := download(server.URL+"/secure/2222/copy.png", "page-b"); third != first {
+		t.Errorf("expected the upload to link %q, got %q", first, third)
+	}
+	entries, err := os.ReadDir(config.ImagesDir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected one image file, got %v (%v)", entries, err)
+	}
+
+	// The file stays with page-a and is kept for page-b when page-b is not exported again
+	key := filepath.ToSlash(config.imagePath(first))
+	if owner := manifest.Files[key].PageID; owner != "page-a" {
+		t.Errorf("expected the image to stay with page-a, got %s", owner)
+	}
+	if err := manifest.Save(); err != nil {
+		t.Fatal(err)
+	}
+	next, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next.KeepPage("page-b")
+	if stale := next.StaleFiles([]string{config.ImagesDir}); len(stale) != 0 {
+		t.Errorf("expected the shared image to be kept, got stale %v", stale)
+	}
+}
+
+// failingBlockService fails every block children request
+type failingBlockService struct {
+	notionapi.BlockService
+}
+
+func (f *failingBlockService) GetChildren(context.Context, notionapi.BlockID, *notionapi.Pagination) (*notionapi.GetChildrenResponse, error) {
+	return nil, errors.New("service unavailable")
+}
+
+func TestContentErrorPolicy(t *testing.T) {
+	client := &notionapi.Client{Block: &failingBlockService{}}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), ContentErrorPolicy: "placeholder", Report: newRunReport()}
+	page := *titledPage("page", "Title")
+
+	processPage(client, page, config)
+	files, _ := filepath.Glob(filepath.Join(config.BlogOutputDir, "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected a placeholder file, got %v", files)
+	}
+	if data, _ := os.ReadFile(files[0]); !strings.Contains(string(data), "could not be retrieved") {
+		t.Errorf("expected placeholder content, got:\n%s", data)
+	}
+
+	// A good file from an earlier run is neither overwritten nor pruned
+	if err := os.WriteFile(files[0], []byte("good"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Files[filepath.ToSlash(files[0])] = ManifestEntry{PageID: "page"}
+	config.Manifest, config.ContentErrorPolicy = manifest, "keep"
+
+	processPage(client, page, config)
+	if data, _ := os.ReadFile(files[0]); string(data) != "good" {
+		t.Errorf("existing file was overwritten:\n%s", data)
+	}
+	if stale := manifest.StaleFiles([]string{config.BlogOutputDir}); len(stale) != 0 {
+		t.Errorf("kept file would be pruned: %v", stale)
+	}
+	if len(config.Report.contentErrors) != 2 {
+		t.Errorf("expected both failures in the report, got %v", config.Report.contentErrors)
+	}
+	if !config.Report.Failed() {
+		t.Error("expected the run to be reported as failed")
+	}
+
+	// With fail the error stops the run instead of exiting
+	config.ContentErrorPolicy = "fail"
+	if err := processPage(client, page, config); err == nil || ExitCode(err) != exitFailure {
+		t.Errorf("processPage() = %v, want an error stopping the run", err)
+	}
+}
+
+func TestProcessPageWriteFailure(t *testing.T) {
+	// A file where the output directory should be makes the page fail, not the run
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: filepath.Join(blocked, "blog"), Report: newRunReport()}
+
+	if err := processPage(newFakeClient(paragraphBlock("Hello")), *titledPage("page", "Title"), config); err != nil {
+		t.Fatalf("processPage() = %v, want the failure recorded in the report", err)
+	}
+	if len(config.Report.failedPages) != 1 || !config.Report.Failed() {
+		t.Errorf("expected a failed page in the report, got %v", config.Report.failedPages)
+	}
+}
+
+func TestReadConfigInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notion-to-astro.yaml")
+	if err := os.WriteFile(path, []byte("blog: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfig(Options{ConfigFile: path}, "blog", nil); err == nil || ExitCode(err) != exitConfig {
+		t.Errorf("readConfig() = %v, want a configuration error", err)
+	}
+}
+
+func TestFrontmatterOnly(t *testing.T) {
+	client := &notionapi.Client{Block: &failingBlockService{}}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), FrontmatterOnly: true, Report: newRunReport()}
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Manifest = manifest
+
+	// The body of the earlier export is kept as written, including its blank lines
+	existing := filepath.Join(config.BlogOutputDir, "old-title.md")
+	if err := os.WriteFile(existing, []byte("---\ntitle: \"Old title\"\n---\n\nFirst  \n\nSecond  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest.Files[filepath.ToSlash(existing)] = ManifestEntry{PageID: "page"}
+
+	processPage(client, *titledPage("page", "Title"), config)
+	files, _ := filepath.Glob(filepath.Join(config.BlogOutputDir, "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected the renamed file only, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "title: Title\n") || !strings.HasSuffix(string(data), "---\n\nFirst  \n\nSecond  \n") {
+		t.Errorf("unexpected content:\n%s", data)
+	}
+	if len(config.Report.contentErrors) != 0 {
+		t.Errorf("blocks were fetched: %v", config.Report.contentErrors)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	client := newFakeClient(paragraphBlock("First"), paragraphBlock("Second"))
+	config := runConfig{DatabaseType: "diary", LineBreakStyle: "spaces", DiaryOutputDir: t.TempDir(), EmitContentHash: true, Report: newRunReport()}
+
+	processPage(client, *titledPage("page", "Title"), config)
+	files, _ := filepath.Glob(filepath.Join(config.DiaryOutputDir, "*.md"))
+	if len(files) != 1 {
+		t.Fatalf("expected one file, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	_, body, _ := strings.Cut(string(data), "---\n\n")
+	sum := sha256.Sum256([]byte(body))
+	if want := "contentHash: " + hex.EncodeToString(sum[:]) + "\n"; !strings.Contains(string(data), want) {
+		t.Errorf("expected %q in:\n%s", want, data)
+	}
+}
+
+func TestCalloutComponent(t *testing.T) {
+	emoji := notionapi.Emoji("💡")
+	callout := &notionapi.CalloutBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeCallout},
+		Callout:    notionapi.Callout{RichText: richText("Remember this"), Icon: &notionapi.Icon{Type: "emoji", Emoji: &emoji}},
+	}
+	config := runConfig{
+		DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), Report: newRunReport(),
+		CalloutStyle: "component", CalloutComponent: "Callout", CalloutImport: "@/components/Callout.astro",
+	}
+
+	processPage(newFakeClient(callout), *titledPage("page", "Title"), config)
+	data, err := os.ReadFile(filepath.Join(config.BlogOutputDir, "Title.mdx"))
+	if err != nil {
+		t.Fatalf("expected an MDX file: %v", err)
+	}
+	want := "---\n\nimport Callout from \"@/components/Callout.astro\";\n\n<Callout icon=\"💡\">\n\nRemember this  \n\n</Callout>  \n"
+	if !strings.HasSuffix(string(data), want) {
+		t.Errorf("unexpected content:\n%s", data)
+	}
+}
+
+func TestEmptyParagraphs(t *testing.T) {
+	blocks := []notionapi.Block{paragraphBlock("First"), paragraphBlock(""), paragraphBlock(""), paragraphBlock("Second"), paragraphBlock("Third")}
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"collapse", "First  \n  \nSecond  \nThird  \n"},
+		{"preserve", "First  \n\n  \n\n  \n\nSecond  \n\nThird  \n\n"},
+		{"br", "First  \n<br/>  \n<br/>  \nSecond  \nThird  \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			if result := convertBlocks(t, runConfig{EmptyParagraphs: tt.mode}, blocks...); result != tt.expected {
+				t.Errorf("convertBlocks() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateFrontmatterYAML(t *testing.T) {
+	frontmatter := Frontmatter{
+		ID:          "42",
+		Title:       `Q&A: "quotes" and 'apostrophes'`,
+		Description: "- starts like a list item",
+		PublishedAt: "2024-05-01",
+		Date:        "2024-04-30",
+		Tags:        []string{"Go", `say "hi"`, "日本語"},
+		Draft:       true,
+		NotionURL:   "https://www.notion.so/Page-abc#frag",
+		JSONLD:      &ArticleJSONLD{Headline: "@mention\nsecond line", Author: "* star"},
+		Sidebar:     &StarlightSidebar{Label: "[Guide]", Order: 3},
+	}
+	got, err := generateFrontmatterYAML(frontmatter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `id: 42
+title: 'Q&A: "quotes" and ''apostrophes'''
+description: '- starts like a list item'
+publishedAt: 2024-05-01
+date: 2024-04-30
+tags: ["Go", "say \"hi\"", "日本語"]
+draft: true
+notionUrl: https://www.notion.so/Page-abc#frag
+jsonLd:
+  headline: "@mention\nsecond line"
+  author: '* star'
+sidebar:
+  label: '[Guide]'
+  order: 3
+`
+	if got != expected {
+		t.Errorf("generateFrontmatterYAML() = %q, want %q", got, expected)
+	}
+
+	// Every special character survives a round trip
+	var parsed struct {
+		Title       string   `yaml:"title"`
+		Description string   `yaml:"description"`
+		Tags        []string `yaml:"tags"`
+		JSONLD      struct {
+			Headline string `yaml:"headline"`
+		} `yaml:"jsonLd"`
+	}
+	if err := yaml.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Title != frontmatter.Title || parsed.Description != frontmatter.Description ||
+		parsed.Tags[1] != `say "hi"` || parsed.JSONLD.Headline != frontmatter.JSONLD.Headline {
+		t.Errorf("parsed frontmatter = %+v", parsed)
+	}
+}
+
+func TestJSONLDDatePublished(t *testing.T) {
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Body")},
+	}}}
+	published := notionapi.Date(time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC))
+	scheduled := titledPage("page", "Scheduled")
+	scheduled.Properties["publishedAt"] = &notionapi.DateProperty{Date: &notionapi.DateObject{Start: &published}}
+	unscheduled := titledPage("page", "Unscheduled")
+
+	for name, tt := range map[string]struct {
+		page *notionapi.Page
+		want string
+	}{
+		"publication date": {scheduled, "  datePublished: 2024-05-01\n"},
+		"creation date":    {unscheduled, "  datePublished: 2024-03-10\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tt.page.CreatedTime = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+			dir := t.TempDir()
+			config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, EmitJSONLD: true, Report: newRunReport()}
+			if err := processPage(client, *tt.page, config); err != nil {
+				t.Fatalf("processPage() error = %v", err)
+			}
+			files, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+			if len(files) != 1 {
+				t.Fatalf("expected one page, got %v", files)
+			}
+			data, _ := os.ReadFile(files[0])
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("expected %q in:\n%s", tt.want, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// ManifestEntry records a generated file
+type ManifestEntry struct {
+	SHA256     string `json:"sha256"`
+	PageID     string `json:"pageId"`
+	ExportedAt string `json:"exportedAt"`
+
+	// HTTP validators of external images, used for conditional GETs on refresh runs
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+
+	// Hash of the URL an image was downloaded from, so templated image names stay unique
+	Source string `json:"source,omitempty"`
+
+	// Hash of the downloaded data of an image, and the other pages linking the image because
+	// they downloaded the same data (DEDUPE_IMAGES)
+	Content string           `json:"content,omitempty"`
+	Shared  []ImageReference `json:"shared,omitempty"`
+}
+
+// ImageReference is a page linking an image saved for another page, and the hash of the URL
+// it downloaded the image from
+type ImageReference struct {
+	PageID string `json:"pageId"`
+	Source string `json:"source"`
+}
+
+// referencedBy reports whether the file was generated for pageID or is an image pageID shares
+func (e ManifestEntry) referencedBy(pageID string) bool {
+	if e.PageID == pageID {
+		return true
+	}
+	for _, ref := range e.Shared {
+		if ref.PageID == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// Manifest maps every generated file to its checksum and source page.
+// A nil *Manifest is valid and records nothing.
+type Manifest struct {
+	Files map[string]ManifestEntry `json:"files"`
+
+	path      string
+	workspace *writer.Workspace // Workspace of the run the files are read from and saved to
+	seen      map[string]bool   // Files recorded during the current run
+	mu        sync.Mutex
+}
+
+// loadManifest reads the manifest at path through workspace; a missing file yields an empty
+// manifest
+func loadManifest(path string, workspace *writer.Workspace) (*Manifest, error) {
+	manifest := &Manifest{Files: map[string]ManifestEntry{}, path: path, workspace: workspace, seen: map[string]bool{}}
+
+	data, err := workspace.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]ManifestEntry{}
+	}
+	return manifest, nil
+}
+
+// RecordFile hashes the file at path and records it as generated from pageID.
+// The previous exportedAt is kept when the content is unchanged, and a shared image keeps the
+// page it was saved for.
+func (m *Manifest) RecordFile(path, pageID string) error {
+	if m == nil {
+		return nil
+	}
+
+	data, err := m.workspace.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for manifest: %v", path, err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := filepath.ToSlash(filepath.Clean(path))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := ManifestEntry{
+		SHA256:     hash,
+		PageID:     pageID,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if previous, ok := m.Files[key]; ok {
+		if previous.SHA256 == hash {
+			entry.ExportedAt = previous.ExportedAt
+		}
+		entry.ETag, entry.LastModified = previous.ETag, previous.LastModified
+		entry.Source, entry.Content, entry.Shared = previous.Source, previous.Content, previous.Shared
+		if previous.referencedBy(pageID) {
+			entry.PageID = previous.PageID
+		}
+	}
+	m.Files[key] = entry
+	m.seen[key] = true
+	return nil
+}
+
+// ImageValidators returns the stored ETag and Last-Modified of an image
+func (m *Manifest) ImageValidators(path string) (etag, lastModified string) {
+	if m == nil {
+		return "", ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry := m.Files[filepath.ToSlash(filepath.Clean(path))]
+	return entry.ETag, entry.LastModified
+}
+
+// SetImageValidators stores the ETag and Last-Modified of a downloaded image
+func (m *Manifest) SetImageValidators(path, etag, lastModified string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := filepath.ToSlash(filepath.Clean(path))
+	entry := m.Files[key]
+	entry.ETag, entry.LastModified = etag, lastModified
+	m.Files[key] = entry
+}
+
+// SetImageContent stores the hash of the downloaded data of an image
+func (m *Manifest) SetImageContent(path, content string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := filepath.ToSlash(filepath.Clean(path))
+	entry := m.Files[key]
+	entry.Content = content
+	m.Files[key] = entry
+}
+
+// ImageByContent returns a recorded image downloaded with the given data hash, or ""
+func (m *Manifest) ImageByContent(content string) string {
+	if m == nil || content == "" {
+		return ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found []string
+	for key, entry := range m.Files {
+		if entry.Content == content {
+			found = append(found, key)
+		}
+	}
+	// The first name is taken, so all pages link the same file
+	sort.Strings(found)
+	if len(found) == 0 {
+		return ""
+	}
+	return found[0]
+}
+
+// ShareImage records that pageID links the image at path, downloaded from source
+func (m *Manifest) ShareImage(path, pageID, source string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := filepath.ToSlash(filepath.Clean(path))
+	entry := m.Files[key]
+	m.seen[key] = true
+	if entry.PageID == pageID && entry.Source == source {
+		return
+	}
+	for _, ref := range entry.Shared {
+		if ref.PageID == pageID && ref.Source == source {
+			return
+		}
+	}
+	entry.Shared = append(entry.Shared, ImageReference{PageID: pageID, Source: source})
+	m.Files[key] = entry
+}
+
+// ClaimImage reserves path for the image of pageID downloaded from source. The path cannot be
+// claimed while it holds an image of another page or another image of this run. The previous
+// source of the path is returned so the caller can tell whether the file can be reused.
+// Without a manifest every path can be claimed.
+func (m *Manifest) ClaimImage(path, pageID, source string) (string, bool) {
+	if m == nil {
+		return "", true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := filepath.ToSlash(filepath.Clean(path))
+	entry, exists := m.Files[key]
+	if exists && entry.Source != source && (entry.PageID != pageID || m.seen[key]) {
+		return "", false
+	}
+	previous := entry.Source
+	entry.PageID, entry.Source = pageID, source
+	m.Files[key] = entry
+	m.seen[key] = true
+	return previous, true
+}
+
+// ReleaseImage drops the claim on an image file, for an image that is saved under another name
+func (m *Manifest) ReleaseImage(path string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := filepath.ToSlash(filepath.Clean(path))
+	delete(m.Files, key)
+	delete(m.seen, key)
+}
+
+// ImageBySource returns the recorded image of pageID downloaded from source, or ""
+func (m *Manifest) ImageBySource(pageID, source string) string {
+	if m == nil {
+		return ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.Files {
+		if entry.PageID == pageID && entry.Source == source {
+			return key
+		}
+		for _, ref := range entry.Shared {
+			if ref.PageID == pageID && ref.Source == source {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// Pages returns the page ID of every recorded file, keyed by path
+func (m *Manifest) Pages() map[string]string {
+	pages := map[string]string{}
+	if m == nil {
+		return pages
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for path, entry := range m.Files {
+		pages[path] = entry.PageID
+	}
+	return pages
+}
+
+// StaleFiles returns the files of previous runs that were not generated again in this run:
+// files under one of dirs, and other files (images, sidecars) of pages exported in this run
+// or of pages whose files under dirs are stale
+func (m *Manifest) StaleFiles(dirs []string) []string {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exportedPages := map[string]bool{}
+	for key := range m.seen {
+		exportedPages[m.Files[key].PageID] = true
+	}
+	removedPages := map[string]bool{}
+	for key, entry := range m.Files {
+		if !m.seen[key] && !exportedPages[entry.PageID] && underAnyDir(key, dirs) {
+			removedPages[entry.PageID] = true
+		}
+	}
+
+	var stale []string
+	for key, entry := range m.Files {
+		if m.seen[key] {
+			continue
+		}
+		if exportedPages[entry.PageID] || removedPages[entry.PageID] || underAnyDir(key, dirs) {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// KeepPage marks the files of pageID from earlier runs as generated by this run, so a page
+// that could not be exported again keeps its files when pruning
+func (m *Manifest) KeepPage(pageID string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.Files {
+		if entry.referencedBy(pageID) {
+			m.seen[key] = true
+		}
+	}
+}
+
+// KeepAssets marks the files of pageID from earlier runs other than its markdown files as
+// generated by this run, e.g. the images of a body that is reused without converting it
+func (m *Manifest) KeepAssets(pageID string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, entry := range m.Files {
+		if ext := filepath.Ext(key); entry.referencedBy(pageID) && ext != ".md" && ext != ".mdx" {
+			m.seen[key] = true
+		}
+	}
+}
+
+// SeenFiles returns the files of pageID recorded during this run
+func (m *Manifest) SeenFiles(pageID string) []string {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var files []string
+	for key := range m.seen {
+		if m.Files[key].referencedBy(pageID) {
+			files = append(files, key)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// PreviousOutputs returns the files of pageID with the same extension as path that were
+// generated by earlier runs but not by this one, e.g. the post written under its old title
+func (m *Manifest) PreviousOutputs(pageID, path string) []string {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current := filepath.ToSlash(filepath.Clean(path))
+	var previous []string
+	for key, entry := range m.Files {
+		if entry.PageID != pageID || key == current || m.seen[key] {
+			continue
+		}
+		if filepath.Ext(key) == filepath.Ext(current) && !strings.HasSuffix(key, ".stats.json") {
+			previous = append(previous, key)
+		}
+	}
+	sort.Strings(previous)
+	return previous
+}
+
+// Forget removes files from the manifest
+func (m *Manifest) Forget(paths ...string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, path := range paths {
+		delete(m.Files, filepath.ToSlash(filepath.Clean(path)))
+	}
+}
+
+// underAnyDir reports whether the manifest key is inside one of dirs
+func underAnyDir(key string, dirs []string) bool {
+	for _, dir := range dirs {
+		prefix := filepath.ToSlash(filepath.Clean(dir)) + "/"
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the manifest as indented JSON
+func (m *Manifest) Save() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	if err := m.workspace.WriteFile(m.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest %s: %v", m.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManifestRecordFile(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(dir, "manifest.json")
+	filePath := filepath.Join(dir, "post.md")
+
+	manifest, err := loadManifest(manifestPath, nil)
+	if err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+
+	if err := os.WriteFile(filePath, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.RecordFile(filePath, "page-1"); err != nil {
+		t.Fatalf("RecordFile() error = %v", err)
+	}
+
+	key := filepath.ToSlash(filePath)
+	entry := manifest.Files[key]
+	if entry.SHA256 != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("SHA256 = %s, want sha256 of %q", entry.SHA256, "hello")
+	}
+	if entry.PageID != "page-1" {
+		t.Errorf("PageID = %s, want page-1", entry.PageID)
+	}
+
+	// Unchanged content keeps the original export time
+	manifest.Files[key] = ManifestEntry{SHA256: entry.SHA256, PageID: "page-1", ExportedAt: "2024-01-01T00:00:00Z"}
+	if err := manifest.RecordFile(filePath, "page-1"); err != nil {
+		t.Fatalf("RecordFile() error = %v", err)
+	}
+	if got := manifest.Files[key].ExportedAt; got != "2024-01-01T00:00:00Z" {
+		t.Errorf("ExportedAt = %s, want unchanged timestamp", got)
+	}
+
+	if err := manifest.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := loadManifest(manifestPath, nil)
+	if err != nil {
+		t.Fatalf("loadManifest() error = %v", err)
+	}
+	if !reflect.DeepEqual(reloaded.Files[key], manifest.Files[key]) {
+		t.Errorf("reloaded entry = %+v, want %+v", reloaded.Files[key], manifest.Files[key])
+	}
+}
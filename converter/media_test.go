@@ -0,0 +1,44 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/mdrender"
+)
+
+func TestMediaBlocks(t *testing.T) {
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {
+			&notionapi.VideoBlock{
+				BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeVideo},
+				Video: notionapi.Video{
+					Type:     notionapi.FileTypeExternal,
+					External: &notionapi.FileObject{URL: "https://youtu.be/dQw4w9WgXcQ"},
+					Caption:  richText("Demo"),
+				},
+			},
+			&notionapi.EmbedBlock{
+				BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeEmbed},
+				Embed:      notionapi.Embed{URL: "https://codepen.io/pen/abc"},
+			},
+			&notionapi.BookmarkBlock{
+				BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeBookmark},
+				Bookmark:   notionapi.Bookmark{URL: "https://example.com/a (b)", Caption: richText("Example [site]")},
+			},
+		},
+	}}}
+
+	markdown, _, err := retrievePageContent(client, "page", runConfig{LineBreakStyle: "spaces"})
+	if err != nil {
+		t.Fatalf("retrievePageContent() error = %v", err)
+	}
+	expected := "<iframe src=\"https://www.youtube.com/embed/dQw4w9WgXcQ\" title=\"Demo\" width=\"560\" height=\"315\" loading=\"lazy\" allow=\"autoplay; encrypted-media; picture-in-picture; fullscreen\" allowfullscreen></iframe>  \n" +
+		"Demo  \n" +
+		"[https://codepen.io/pen/abc](https://codepen.io/pen/abc)  \n" +
+		"[Example \\[site\\]](https://example.com/a%20%28b%29)  \n"
+	if result := mdrender.ProcessEmptyLines(markdown); result != expected {
+		t.Errorf("converted markdown = %q, want %q", result, expected)
+	}
+}
@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// adjacentPost is a page taking part in the previous/next navigation of its collection
+type adjacentPost struct {
+	pageID string
+	path   string
+	date   string // publishedAt, or date when the page has none
+}
+
+// Navigation collects the pages of a database so that, once all of them are known, each one
+// gets the slugs of the previous and next post as prev/next in its frontmatter.
+// A nil *Navigation records nothing.
+type Navigation struct {
+	mu    sync.Mutex
+	posts []adjacentPost
+}
+
+// AddPage records the file of a page for the navigation. Drafts are left out.
+func (n *Navigation) AddPage(pageID, path string, frontmatter Frontmatter) {
+	if n == nil || frontmatter.Draft {
+		return
+	}
+
+	date := frontmatter.publishedDate()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.posts = append(n.posts, adjacentPost{pageID: pageID, path: path, date: date})
+}
+
+// Apply sorts the recorded pages by date and writes prev (the older post) and next (the newer
+// post) into the frontmatter of each file. Files whose navigation did not change are left as
+// they are; rewritten files are recorded again in the manifest and the sync state.
+func (n *Navigation) Apply(config runConfig) error {
+	if n == nil {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sort.SliceStable(n.posts, func(i, j int) bool {
+		if n.posts[i].date != n.posts[j].date {
+			return n.posts[i].date < n.posts[j].date
+		}
+		return n.posts[i].path < n.posts[j].path
+	})
+
+	var errs []string
+	for i, post := range n.posts {
+		var prev, next string
+		if i > 0 {
+			prev = postSlug(n.posts[i-1].path)
+		}
+		if i < len(n.posts)-1 {
+			next = postSlug(n.posts[i+1].path)
+		}
+		changed, err := writeAdjacentPosts(post.path, prev, next, config.Workspace)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if err := config.Manifest.RecordFile(post.path, post.pageID); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if err := config.SyncState.Rehash(post.pageID, post.path); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to write adjacent posts: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// postSlug returns the slug Astro gives the entry written to path
+func postSlug(path string) string {
+	name := filepath.Base(path)
+	return astroSlug(strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// writeAdjacentPosts replaces the prev/next fields in the frontmatter of the file at path and
+// reports whether the file changed. An empty slug removes the field.
+func writeAdjacentPosts(path, prev, next string, workspace *writer.Workspace) (bool, error) {
+	data, err := workspace.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	content := string(data)
+	updated, err := setAdjacentPosts(content, prev, next)
+	if err != nil {
+		return false, fmt.Errorf("%s %v", path, err)
+	}
+	if updated == content {
+		return false, nil
+	}
+	if err := workspace.WriteFile(path, []byte(updated), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// setAdjacentPosts replaces the prev/next fields in the frontmatter of content
+func setAdjacentPosts(content, prev, next string) (string, error) {
+	if !strings.HasPrefix(content, "---\n") {
+		return "", fmt.Errorf("has no frontmatter")
+	}
+	end := strings.Index(content[4:], "\n---\n")
+	if end < 0 {
+		return "", fmt.Errorf("has no end of frontmatter")
+	}
+	frontmatter, body := content[4:4+end+1], content[4+end+1:]
+
+	var lines []string
+	for _, line := range strings.SplitAfter(frontmatter, "\n") {
+		if line != "" && !strings.HasPrefix(line, "prev: ") && !strings.HasPrefix(line, "next: ") {
+			lines = append(lines, line)
+		}
+	}
+	if prev != "" {
+		lines = append(lines, fmt.Sprintf("prev: %s\n", yamlString(prev)))
+	}
+	if next != "" {
+		lines = append(lines, fmt.Sprintf("next: %s\n", yamlString(next)))
+	}
+	return "---\n" + strings.Join(lines, "") + body, nil
+}
@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNavigationApply(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, frontmatter string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("---\n"+frontmatter+"---\n\nBody\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	first := write("First Post.md", "title: First\ndate: 2024-01-01\n")
+	second := write("Second.md", "title: Second\ndate: 2024-02-01\nprev: stale\nnext: stale\n")
+	third := write("Third.md", "title: Third\ndate: 2024-01-15\n")
+	draft := write("Draft.md", "title: Draft\ndate: 2024-01-20\n")
+
+	navigation := &Navigation{}
+	navigation.AddPage("2", second, Frontmatter{Date: "2024-02-01"})
+	navigation.AddPage("1", first, Frontmatter{Date: "2024-01-01"})
+	navigation.AddPage("3", third, Frontmatter{Date: "2024-03-01", PublishedAt: "2024-01-15"})
+	navigation.AddPage("4", draft, Frontmatter{Date: "2024-01-20", Draft: true})
+	if err := navigation.Apply(runConfig{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		first:  "---\ntitle: First\ndate: 2024-01-01\nnext: third\n---\n\nBody\n",
+		third:  "---\ntitle: Third\ndate: 2024-01-15\nprev: first-post\nnext: second\n---\n\nBody\n",
+		second: "---\ntitle: Second\ndate: 2024-02-01\nprev: third\n---\n\nBody\n",
+		draft:  "---\ntitle: Draft\ndate: 2024-01-20\n---\n\nBody\n",
+	}
+	for path, content := range want {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s =\n%s\nwant\n%s", filepath.Base(path), data, content)
+		}
+	}
+
+	changed, err := writeAdjacentPosts(third, "first-post", "second", nil)
+	if err != nil || changed {
+		t.Errorf("writeAdjacentPosts() = %v, %v; want the file left unchanged", changed, err)
+	}
+}
+
+func TestWriteAdjacentPostsWithoutFrontmatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(path, []byte("Body\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeAdjacentPosts(path, "a", "b", nil); err == nil || !strings.Contains(err.Error(), "no frontmatter") {
+		t.Errorf("writeAdjacentPosts() error = %v, want missing frontmatter", err)
+	}
+}
@@ -0,0 +1,197 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// defaultTokenFile stores the OAuth token written by `login`
+const defaultTokenFile = ".notion-to-astro-token.json"
+
+// OAuth app credentials of a public integration, overridable with -ldflags
+// "-X notion-to-astro-go/converter.oauthClientID=... -X notion-to-astro-go/converter.oauthClientSecret=..."
+// so a build can be shared without extra setup. NOTION_OAUTH_CLIENT_ID/SECRET take precedence.
+var (
+	oauthClientID     = ""
+	oauthClientSecret = ""
+)
+
+// Notion OAuth endpoints, variables so tests can point them at a local server
+var (
+	notionOAuthAuthorizeURL = "https://api.notion.com/v1/oauth/authorize"
+	notionOAuthTokenURL     = "https://api.notion.com/v1/oauth/token"
+)
+
+// StoredToken is the OAuth token saved in the token file
+type StoredToken struct {
+	AccessToken   string `json:"accessToken"`
+	RefreshToken  string `json:"refreshToken,omitempty"`
+	ExpiresAt     string `json:"expiresAt,omitempty"` // RFC 3339; empty if the token does not expire
+	WorkspaceName string `json:"workspaceName,omitempty"`
+	BotID         string `json:"botId,omitempty"`
+}
+
+// oauthTokenResponse is the response of the Notion token endpoint
+type oauthTokenResponse struct {
+	AccessToken   string `json:"access_token"`
+	RefreshToken  string `json:"refresh_token"`
+	ExpiresIn     int    `json:"expires_in"`
+	WorkspaceName string `json:"workspace_name"`
+	BotID         string `json:"bot_id"`
+}
+
+// oauthCredentials returns the OAuth client ID and secret from env or the build
+func oauthCredentials(env settings) (string, string) {
+	return env.get("NOTION_OAUTH_CLIENT_ID", oauthClientID), env.get("NOTION_OAUTH_CLIENT_SECRET", oauthClientSecret)
+}
+
+// TokenFile returns the path of the token file of the settings in env: NOTION_TOKEN_FILE or
+// .notion-to-astro-token.json
+func TokenFile(env map[string]string) string {
+	return settings(env).get("NOTION_TOKEN_FILE", defaultTokenFile)
+}
+
+// AuthorizeURL returns the URL that asks the user to connect the public integration of the
+// settings in env to their workspace and redirects to redirectURI with the authorization code
+// and state
+func AuthorizeURL(env map[string]string, redirectURI, state string) (string, error) {
+	clientID, clientSecret := oauthCredentials(env)
+	if clientID == "" || clientSecret == "" {
+		return "", configError("NOTION_OAUTH_CLIENT_ID and NOTION_OAUTH_CLIENT_SECRET are required for login")
+	}
+	return notionOAuthAuthorizeURL + "?" + url.Values{
+		"client_id":     {clientID},
+		"response_type": {"code"},
+		"owner":         {"user"},
+		"redirect_uri":  {redirectURI},
+		"state":         {state},
+	}.Encode(), nil
+}
+
+// SaveAuthorizationCode exchanges the authorization code of the redirect to redirectURI for a
+// token and saves it in tokenFile, where Convert finds it when no token is configured
+func SaveAuthorizationCode(env map[string]string, code, redirectURI, tokenFile string) (StoredToken, error) {
+	clientID, clientSecret := oauthCredentials(env)
+	token, err := requestOAuthToken(clientID, clientSecret, map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         code,
+		"redirect_uri": redirectURI,
+	})
+	if err != nil {
+		return StoredToken{}, fmt.Errorf("failed to get access token: %w", err)
+	}
+	if err := saveStoredToken(tokenFile, token); err != nil {
+		return StoredToken{}, err
+	}
+	return token, nil
+}
+
+// StoredAccessToken returns the access token saved by login in the token file of env,
+// refreshing it when it has expired. Without a token file it returns an empty token.
+func StoredAccessToken(env map[string]string) (string, error) {
+	return storedAccessToken(TokenFile(env), env, nil)
+}
+
+// requestOAuthToken calls the Notion token endpoint with the given grant
+func requestOAuthToken(clientID, clientSecret string, grant map[string]string) (StoredToken, error) {
+	body, err := json.Marshal(grant)
+	if err != nil {
+		return StoredToken{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, notionOAuthTokenURL, bytes.NewReader(body))
+	if err != nil {
+		return StoredToken{}, err
+	}
+	req.SetBasicAuth(clientID, clientSecret)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Notion-Version", NotionAPIVersion)
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return StoredToken{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return StoredToken{}, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var tokenResp oauthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return StoredToken{}, fmt.Errorf("failed to decode token response: %v", err)
+	}
+	token := StoredToken{
+		AccessToken:   tokenResp.AccessToken,
+		RefreshToken:  tokenResp.RefreshToken,
+		WorkspaceName: tokenResp.WorkspaceName,
+		BotID:         tokenResp.BotID,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second).UTC().Format(time.RFC3339)
+	}
+	return token, nil
+}
+
+// storedAccessToken returns the access token saved by `login`, refreshing it when it has expired.
+// A missing token file yields an empty token.
+func storedAccessToken(path string, env settings, logger *slog.Logger) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file %s: %v", path, err)
+	}
+	var token StoredToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", fmt.Errorf("failed to parse token file %s: %v", path, err)
+	}
+
+	// Refresh a minute early so the token does not expire mid-run
+	expiresAt, err := time.Parse(time.RFC3339, token.ExpiresAt)
+	if err != nil || time.Now().Add(time.Minute).Before(expiresAt) {
+		return token.AccessToken, nil
+	}
+	if token.RefreshToken == "" {
+		return "", fmt.Errorf("the saved Notion token has expired; run `notion-to-astro-go login` again")
+	}
+
+	logf(logger, slog.LevelInfo, "Refreshing the saved Notion token...")
+	clientID, clientSecret := oauthCredentials(env)
+	refreshed, err := requestOAuthToken(clientID, clientSecret, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": token.RefreshToken,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh the saved Notion token: %v", err)
+	}
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	if refreshed.WorkspaceName == "" {
+		refreshed.WorkspaceName, refreshed.BotID = token.WorkspaceName, token.BotID
+	}
+	if err := saveStoredToken(path, refreshed); err != nil {
+		return "", err
+	}
+	return refreshed.AccessToken, nil
+}
+
+// saveStoredToken writes the token file readable by the current user only
+func saveStoredToken(path string, token StoredToken) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %v", err)
+	}
+	if err := writer.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write token file %s: %v", path, err)
+	}
+	return nil
+}
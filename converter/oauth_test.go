@@ -0,0 +1,89 @@
+package converter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoredAccessTokenRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var grant map[string]string
+		json.NewDecoder(r.Body).Decode(&grant)
+		if id, _, _ := r.BasicAuth(); id != "client" || grant["grant_type"] != "refresh_token" || grant["refresh_token"] != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-2", "refresh_token": "refresh-2", "expires_in": 3600})
+	}))
+	defer server.Close()
+	defer func(previous string) { notionOAuthTokenURL = previous }(notionOAuthTokenURL)
+	notionOAuthTokenURL = server.URL
+	env := settings{"NOTION_OAUTH_CLIENT_ID": "client", "NOTION_OAUTH_CLIENT_SECRET": "secret"}
+
+	path := filepath.Join(t.TempDir(), "token.json")
+	expired := StoredToken{
+		AccessToken:   "access-1",
+		RefreshToken:  "refresh-1",
+		ExpiresAt:     time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		WorkspaceName: "Team",
+	}
+	if err := saveStoredToken(path, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := storedAccessToken(path, env, nil)
+	if err != nil {
+		t.Fatalf("storedAccessToken() error = %v", err)
+	}
+	if token != "access-2" {
+		t.Errorf("storedAccessToken() = %q, want refreshed token", token)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved StoredToken
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.RefreshToken != "refresh-2" || saved.WorkspaceName != "Team" {
+		t.Errorf("unexpected saved token: %+v", saved)
+	}
+
+	// A valid token is used as is
+	if token, err := storedAccessToken(path, env, nil); err != nil || token != "access-2" {
+		t.Errorf("storedAccessToken() = %q, %v", token, err)
+	}
+}
+
+func TestStoredAccessTokenMissingFile(t *testing.T) {
+	token, err := storedAccessToken(filepath.Join(t.TempDir(), "missing.json"), nil, nil)
+	if err != nil || token != "" {
+		t.Errorf("storedAccessToken() = %q, %v; want empty token", token, err)
+	}
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	if _, err := AuthorizeURL(nil, "http://localhost:8976/callback", "state"); ExitCode(err) != exitConfig {
+		t.Errorf("AuthorizeURL() = %v, want a configuration error without credentials", err)
+	}
+	env := map[string]string{"NOTION_OAUTH_CLIENT_ID": "client", "NOTION_OAUTH_CLIENT_SECRET": "secret"}
+	authorizeURL, err := AuthorizeURL(env, "http://localhost:8976/callback", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authorizeURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query := parsed.Query(); query.Get("client_id") != "client" || query.Get("state") != "state" || query.Get("redirect_uri") != "http://localhost:8976/callback" {
+		t.Errorf("unexpected authorization URL %s", authorizeURL)
+	}
+}
@@ -0,0 +1,39 @@
+package converter
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// oversizedPage returns how an exported page exceeds WARN_PAGE_SIZE_KB, WARN_PAGE_IMAGES and
+// WARN_PAGE_ASSETS_MB: the size of its file, and the number and total size of the images and
+// other files saved for it in this run. A post full of photos is reported before it lands in
+// git; it is still exported.
+func oversizedPage(config runConfig, pageID string, size int) []string {
+	var problems []string
+	if limit := int64(config.WarnPageSizeKB) * 1024; limit > 0 && int64(size) > limit {
+		problems = append(problems, fmt.Sprintf("file is %s (limit %s)", formatByteSize(int64(size)), formatByteSize(limit)))
+	}
+
+	var assets []string
+	for _, path := range config.Manifest.SeenFiles(pageID) {
+		if ext := filepath.Ext(path); ext != ".md" && ext != ".mdx" {
+			assets = append(assets, path)
+		}
+	}
+	if limit := config.WarnPageImages; limit > 0 && len(assets) > limit {
+		problems = append(problems, fmt.Sprintf("%d images (limit %d)", len(assets), limit))
+	}
+	if limit := int64(config.WarnPageAssetsMB) * 1024 * 1024; limit > 0 {
+		var total int64
+		for _, path := range assets {
+			if info, err := config.Workspace.Stat(filepath.FromSlash(path)); err == nil {
+				total += info.Size()
+			}
+		}
+		if total > limit {
+			problems = append(problems, fmt.Sprintf("images are %s (limit %s)", formatByteSize(total), formatByteSize(limit)))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,58 @@
+package converter
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOversizedPage(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"a.jpg": 700 * 1024, "b.jpg": 400 * 1024, "post.md": 10} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := manifest.RecordFile(path, "page"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := runConfig{Manifest: manifest}
+
+	// No limits are checked by default
+	if problems := oversizedPage(config, "page", 500*1024); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+
+	config.WarnPageSizeKB, config.WarnPageImages, config.WarnPageAssetsMB = 100, 1, 1
+	problems := oversizedPage(config, "page", 500*1024)
+	want := []string{"file is 500.0 KB (limit 100.0 KB)", "2 images (limit 1)", "images are 1.1 MB (limit 1.0 MB)"}
+	if strings.Join(problems, "; ") != strings.Join(want, "; ") {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+
+	// The images of other pages do not count
+	config.WarnPageSizeKB = 0
+	if problems := oversizedPage(config, "other", 500*1024); len(problems) != 0 {
+		t.Errorf("expected no problems for another page, got %v", problems)
+	}
+}
+
+func TestReportOversizedPages(t *testing.T) {
+	out, _ := captureLog(t, slog.LevelInfo, "text")
+	report := newRunReport()
+	report.AddOversizedPage("src/content/diary/2024-01-01.md", []string{"12 images (limit 10)"})
+	report.Print(nil)
+	if !strings.Contains(out.String(), "Found 1 oversized pages:\n  src/content/diary/2024-01-01.md: 12 images (limit 10)\n") {
+		t.Errorf("unexpected summary:\n%s", out)
+	}
+	if report.Failed() {
+		t.Error("oversized pages should not fail the run")
+	}
+}
@@ -0,0 +1,103 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/notion"
+)
+
+// processPageTree exports the root page and all of its descendant child pages,
+// mirroring the page hierarchy as directories under PagesOutputDir
+func processPageTree(config runConfig) error {
+	config.logInfo("Processing page tree from root page: %s", config.NotionRootPageID)
+
+	treeConfig := config
+	treeConfig.DatabaseType = "pages"
+	treeConfig.Progress = newProgress("the page tree", 0, config.ProgressInterval, config.Logger)
+
+	client := config.notionClient()
+	root, err := client.Page.Get(config.context(), notionapi.PageID(config.NotionRootPageID))
+	if err != nil {
+		return notionError("failed to get root page: %w", err)
+	}
+
+	count, err := exportPageTree(client, *root, treeConfig)
+	if err != nil {
+		return err
+	}
+	config.logInfo("Completed processing page tree (%d pages)", count)
+	return nil
+}
+
+// exportPageTree exports a page into config.PageTreeDir and its child pages into
+// a subdirectory named after the page. With the starlight profile, a page that has
+// children is written as index.md of that subdirectory. Returns the number of pages visited,
+// and the error that stops the run, if any.
+func exportPageTree(client *notionapi.Client, page notionapi.Page, config runConfig) (int, error) {
+	// The child pages of an excluded page are excluded with it
+	if reason, ok := excludedPage(page, config); ok {
+		config.logInfo("Skipping page %s and its child pages: %s", page.ID, reason)
+		return 0, nil
+	}
+	childIDs, err := childPageIDs(config.context(), client, notionapi.BlockID(page.ID), config.Logger)
+	if err != nil {
+		config.logWarn("Failed to list child pages of %s: %v", page.ID, err)
+		config.Report.AddFailedPage(page.ID.String(), fmt.Errorf("failed to list child pages: %w", err))
+	}
+
+	childConfig := config
+	childConfig.PageTreeDir = filepath.Join(config.PageTreeDir, strings.TrimSuffix(generateFilename(page, ""), ".md"))
+	childConfig.SectionIndex = false
+
+	pageConfig := config
+	if config.OutputProfile == "starlight" && len(childIDs) > 0 {
+		// Starlight uses <section>/index.md as the page of a sidebar group
+		pageConfig.PageTreeDir = childConfig.PageTreeDir
+		pageConfig.SectionIndex = true
+	}
+	if err := processPage(client, page, pageConfig); err != nil {
+		return 0, err
+	}
+	count := 1
+
+	for i, childID := range childIDs {
+		if err := config.stopErr(); err != nil {
+			return count, err
+		}
+		child, err := client.Page.Get(config.context(), notionapi.PageID(childID))
+		if err != nil {
+			config.logWarn("Failed to get child page %s: %v", childID, err)
+			config.Report.AddFailedPage(string(childID), err)
+			continue
+		}
+		childConfig.SidebarOrder = i + 1
+		n, err := exportPageTree(client, *child, childConfig)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// childPageIDs returns the IDs of the child_page blocks directly inside a block
+func childPageIDs(ctx context.Context, client *notionapi.Client, blockID notionapi.BlockID, logger *slog.Logger) ([]notionapi.BlockID, error) {
+	blocks, err := notion.FetchBlockChildren(ctx, client, blockID, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []notionapi.BlockID
+	for _, block := range blocks {
+		if block.GetType() == notionapi.BlockTypeChildPage {
+			ids = append(ids, block.GetID())
+		}
+	}
+	return ids, nil
+}
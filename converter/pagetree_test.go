@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// fakePageService serves pages from memory, keyed by page ID, and fails like the API for others
+type fakePageService struct {
+	notionapi.PageService
+	pages map[notionapi.PageID]*notionapi.Page
+}
+
+func (f *fakePageService) Get(_ context.Context, id notionapi.PageID) (*notionapi.Page, error) {
+	if page, ok := f.pages[id]; ok {
+		return page, nil
+	}
+	return nil, &notionapi.Error{Status: 404, Code: "object_not_found", Message: "Could not find page with ID: " + id.String()}
+}
+
+func titledPage(id, title string) *notionapi.Page {
+	return &notionapi.Page{
+		ID: notionapi.ObjectID(id),
+		Properties: notionapi.Properties{
+			"title": &notionapi.TitleProperty{Title: richText(title)},
+		},
+	}
+}
+
+func childPageBlock(id string) notionapi.Block {
+	return &notionapi.ChildPageBlock{
+		BasicBlock: notionapi.BasicBlock{ID: notionapi.BlockID(id), Type: notionapi.BlockTypeChildPage},
+	}
+}
+
+func TestExportPageTree(t *testing.T) {
+	client := &notionapi.Client{
+		Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+			"root":    {paragraphBlock("Welcome"), childPageBlock("guide")},
+			"guide":   {childPageBlock("install")},
+			"install": {paragraphBlock("Run it")},
+		}},
+		Page: &fakePageService{pages: map[notionapi.PageID]*notionapi.Page{
+			"guide":   titledPage("guide", "Guide"),
+			"install": titledPage("install", "Install"),
+		}},
+	}
+
+	outputDir := t.TempDir()
+	config := runConfig{DatabaseType: "pages", PagesOutputDir: outputDir, LineBreakStyle: "spaces"}
+	if got, err := exportPageTree(client, *titledPage("root", "Home"), config); err != nil || got != 3 {
+		t.Errorf("exportPageTree() visited %d pages (%v), want 3", got, err)
+	}
+
+	for _, path := range []string{"Home.md", "Home/Guide.md", "Home/Guide/Install.md"} {
+		if _, err := os.Stat(filepath.Join(outputDir, path)); err != nil {
+			t.Errorf("expected %s to be exported: %v", path, err)
+		}
+	}
+}
+
+func TestExportPageTreeStarlight(t *testing.T) {
+	client := &notionapi.Client{
+		Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+			"root":  {childPageBlock("intro"), childPageBlock("guide")},
+			"guide": {childPageBlock("install")},
+		}},
+		Page: &fakePageService{pages: map[notionapi.PageID]*notionapi.Page{
+			"intro":   titledPage("intro", "Intro"),
+			"guide":   titledPage("guide", "Guide"),
+			"install": titledPage("install", "Install"),
+		}},
+	}
+
+	outputDir := t.TempDir()
+	config := runConfig{DatabaseType: "pages", PagesOutputDir: outputDir, LineBreakStyle: "spaces", OutputProfile: "starlight"}
+	exportPageTree(client, *titledPage("root", "Home"), config)
+
+	tests := []struct {
+		path  string
+		order string
+	}{
+		{"Home/index.md", ""},
+		{"Home/Intro.md", "  order: 1\n"},
+		{"Home/Guide/index.md", "  order: 2\n"},
+		{"Home/Guide/Install.md", "  order: 1\n"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join(outputDir, tt.path))
+		if err != nil {
+			t.Errorf("expected %s to be exported: %v", tt.path, err)
+			continue
+		}
+		content := string(data)
+		if tt.order == "" && strings.Contains(content, "sidebar:") {
+			t.Errorf("%s: unexpected sidebar frontmatter:\n%s", tt.path, content)
+		}
+		if tt.order != "" && !strings.Contains(content, "sidebar:\n"+tt.order) {
+			t.Errorf("%s: want sidebar %q, got:\n%s", tt.path, tt.order, content)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package converter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// trashDir receives files removed by -prune in trash mode, one subdirectory per run
+const trashDir = ".notion-to-astro-trash"
+
+// pruneStaleFiles removes files of pages that are no longer exported from dirs.
+// In trash mode the files are moved to trashDir/<timestamp>/ instead of being deleted, and in
+// list mode they are only listed.
+// With PruneArchiveDir each file is first copied to <PruneArchiveDir>/<page ID>/<date>/.
+func pruneStaleFiles(config runConfig, dirs []string) {
+	stale := config.Manifest.StaleFiles(dirs)
+	if len(stale) == 0 {
+		config.logInfo("No stale files to prune")
+		return
+	}
+	pruneFiles(config, stale)
+}
+
+// pruneFiles removes, moves to the trash or lists the stale files as set by PRUNE_MODE and
+// forgets the removed ones
+func pruneFiles(config runConfig, stale []string) {
+	pages := config.Manifest.Pages()
+	if config.PruneMode == "list" {
+		message := fmt.Sprintf("%d stale files would be pruned (PRUNE_MODE=list):", len(stale))
+		for _, path := range stale {
+			message += fmt.Sprintf("\n  %s (page %s)", path, pages[path])
+		}
+		config.logInfo("%s", message)
+		return
+	}
+
+	now := time.Now()
+	runTrashDir := filepath.Join(trashDir, now.Format("20060102-150405"))
+	var pruned []string
+	archived := 0
+	for _, path := range stale {
+		if config.PruneArchiveDir != "" {
+			// Keep the file when it cannot be archived, so its content is not lost
+			if err := archiveFile(path, filepath.Join(config.PruneArchiveDir, pages[path], now.Format("2006-01-02")), config.Workspace); err != nil {
+				if !os.IsNotExist(err) {
+					config.logWarn("Failed to archive %s, keeping it: %v", path, err)
+					continue
+				}
+			} else {
+				archived++
+			}
+		}
+
+		var err error
+		if config.PruneMode == "trash" {
+			err = moveToTrash(path, runTrashDir, config.Workspace)
+		} else {
+			err = config.Workspace.Remove(path)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			config.logWarn("Failed to prune %s: %v", path, err)
+			continue
+		}
+		config.logDebug("Pruned %s", path)
+		pruned = append(pruned, path)
+	}
+	config.Manifest.Forget(pruned...)
+
+	if archived > 0 {
+		config.logInfo("Archived %d stale files to %s", archived, config.PruneArchiveDir)
+	}
+	if config.PruneMode == "trash" {
+		config.logInfo("Moved %d stale files to %s", len(pruned), runTrashDir)
+	} else {
+		config.logInfo("Deleted %d stale files", len(pruned))
+	}
+}
+
+// prunedDirs returns the output directories fully regenerated by the current run,
+// including the image subdirectories of the exported collections
+func prunedDirs(config runConfig) []string {
+	var dirs []string
+	for _, name := range config.selectedDatabases() {
+		dirs = append(dirs, config.databaseConfig(name).collectionDirs()...)
+	}
+	return dirs
+}
+
+// collectionDirs returns the output directory and the image subdirectory of the database
+// being processed
+func (c runConfig) collectionDirs() []string {
+	var dirs []string
+	// The data file of a data database is written on every run, next to files of others
+	if c.DatabaseType != "data" {
+		dirs = append(dirs, c.outputDir())
+	}
+	// Images of a collection can only be pruned when they are not shared with another one
+	if subdir := c.imagesSubdir(); subdir != "" {
+		dirs = append(dirs, c.imagePath(subdir))
+	}
+	return dirs
+}
+
+// moveToTrash moves path into runTrashDir, keeping its relative location
+func moveToTrash(path, runTrashDir string, workspace *writer.Workspace) error {
+	target := filepath.Join(runTrashDir, trashRelativePath(path))
+	if err := workspace.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return workspace.Rename(path, target)
+}
+
+// archiveFile copies path into dir, keeping its relative location
+func archiveFile(path, dir string, workspace *writer.Workspace) error {
+	src, err := workspace.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	target := filepath.Join(dir, trashRelativePath(path))
+	if err := workspace.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	dst, err := workspace.Create(target)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	return dst.Commit()
+}
+
+// trashRelativePath turns path into a relative path that stays inside the trash directory
+func trashRelativePath(path string) string {
+	path = filepath.Clean(path)
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	kept := parts[:0]
+	for _, part := range parts {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			part = "__"
+		}
+		kept = append(kept, part)
+	}
+	return filepath.Join(kept...)
+}
+
+// EmptyTrash permanently deletes the files moved to the trash by PRUNE_MODE=trash and reports
+// it on w
+func EmptyTrash(w io.Writer) error {
+	if err := os.RemoveAll(trashDir); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+	fmt.Fprintf(w, "Emptied %s\n", trashDir)
+	return nil
+}
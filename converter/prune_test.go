@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneStaleFilesToTrash(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	write := func(path string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A previous run exported two posts with an image each, and a diary entry
+	previous, err := loadManifest("manifest.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, pageID := range map[string]string{
+		"content/blog/kept.md":      "kept",
+		"images/kept_old.png":       "kept",
+		"content/blog/archived.md":  "archived",
+		"images/archived_photo.png": "archived",
+		"content/diary/entry.md":    "entry",
+	} {
+		write(path)
+		if err := previous.RecordFile(path, pageID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := previous.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// This run only exports the blog post "kept", which no longer has its old image
+	manifest, err := loadManifest("manifest.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.RecordFile("content/blog/kept.md", "kept"); err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{DatabaseType: "blog", BlogOutputDir: "./content/blog", PruneMode: "trash", Manifest: manifest}
+	pruneStaleFiles(config, prunedDirs(config))
+
+	for _, path := range []string{"content/blog/kept.md", "content/diary/entry.md"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+	for _, path := range []string{"content/blog/archived.md", "images/archived_photo.png", "images/kept_old.png"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be pruned", path)
+		}
+		if _, ok := manifest.Files[path]; ok {
+			t.Errorf("expected %s to be removed from the manifest", path)
+		}
+		trashed, _ := filepath.Glob(filepath.Join(trashDir, "*", path))
+		if len(trashed) != 1 {
+			t.Errorf("expected %s to be moved to the trash, found %v", path, trashed)
+		}
+	}
+}
+
+func TestPruneStaleFilesArchive(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	manifest, err := loadManifest("manifest.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"content/blog/removed.md", "images/removed_photo.png"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := manifest.RecordFile(path, "removed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest.seen = map[string]bool{}
+
+	config := runConfig{DatabaseType: "blog", BlogOutputDir: "./content/blog", PruneMode: "delete", PruneArchiveDir: "archive", Manifest: manifest}
+	pruneStaleFiles(config, prunedDirs(config))
+
+	for _, path := range []string{"content/blog/removed.md", "images/removed_photo.png"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be pruned", path)
+		}
+		archived, _ := filepath.Glob(filepath.Join("archive", "removed", "*", path))
+		if len(archived) != 1 {
+			t.Fatalf("expected %s to be archived, found %v", path, archived)
+		}
+		data, err := os.ReadFile(archived[0])
+		if err != nil || string(data) != path {
+			t.Errorf("archived %s = %q, %v", path, data, err)
+		}
+	}
+}
+
+func TestPruneStaleFilesList(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blogDir := filepath.Join(dir, "blog")
+	stalePath := filepath.Join(blogDir, "archived.md")
+	if err := os.MkdirAll(blogDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stalePath, []byte("archived"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest.Files[filepath.ToSlash(stalePath)] = ManifestEntry{PageID: "archived"}
+
+	// Listing leaves the file and its manifest entry for a later run
+	config := runConfig{DatabaseType: "blog", BlogOutputDir: blogDir, PruneMode: "list", Manifest: manifest}
+	pruneStaleFiles(config, prunedDirs(config))
+	if _, err := os.Stat(stalePath); err != nil {
+		t.Errorf("expected the stale file to be kept: %v", err)
+	}
+	if stale := manifest.StaleFiles(prunedDirs(config)); len(stale) != 1 {
+		t.Errorf("expected the stale file to stay in the manifest, got %v", stale)
+	}
+}
@@ -0,0 +1,30 @@
+package converter
+
+import (
+	"github.com/jomei/notionapi"
+)
+
+// markPublished checks the published checkbox of an exported page (-mark-published), so the
+// page is not matched by the query of the next run. Pages outside the blog and diary
+// databases, and databases mapped to no published checkbox, are left alone.
+func markPublished(client *notionapi.Client, page notionapi.Page, title string, config runConfig) {
+	if !config.MarkPublished || (config.DatabaseType != "blog" && config.DatabaseType != "diary") {
+		return
+	}
+	published := config.properties().publishedProperty()
+	if published == "" {
+		return
+	}
+
+	request := &notionapi.PageUpdateRequest{
+		Properties: notionapi.Properties{
+			published: notionapi.CheckboxProperty{Type: notionapi.PropertyTypeCheckbox, Checkbox: true},
+		},
+	}
+	if _, err := client.Page.Update(config.context(), notionapi.PageID(page.ID), request); err != nil {
+		config.logWarn("Failed to mark page %s as published: %v", page.ID, err)
+		config.Report.AddWriteBackFailure(title, err)
+		return
+	}
+	config.logInfo("Marked page %s as published", page.ID)
+}
@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// updatingPageService records page updates and fails for the pages in fail
+type updatingPageService struct {
+	notionapi.PageService
+	updated map[notionapi.PageID]notionapi.Properties
+	fail    map[notionapi.PageID]bool
+}
+
+func (f *updatingPageService) Update(_ context.Context, id notionapi.PageID, request *notionapi.PageUpdateRequest) (*notionapi.Page, error) {
+	if f.fail[id] {
+		return nil, errors.New("restricted resource")
+	}
+	f.updated[id] = request.Properties
+	return &notionapi.Page{}, nil
+}
+
+func TestMarkPublished(t *testing.T) {
+	pages := &updatingPageService{updated: map[notionapi.PageID]notionapi.Properties{}, fail: map[notionapi.PageID]bool{"locked": true}}
+	client := &notionapi.Client{
+		Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{}},
+		Page:  pages,
+	}
+	report := newRunReport()
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), MarkPublished: true, Report: report}
+
+	processPage(client, *titledPage("page", "Post"), config)
+	checkbox, ok := pages.updated["page"]["published"].(notionapi.CheckboxProperty)
+	if !ok || !checkbox.Checkbox {
+		t.Errorf("published was not checked: %#v", pages.updated["page"])
+	}
+
+	processPage(client, *titledPage("locked", "Locked"), config)
+	if len(report.writeBackFailures) != 1 {
+		t.Errorf("write-back failures = %v, want the locked page", report.writeBackFailures)
+	}
+
+	// Without the flag, and for pages outside the databases, Notion is not modified
+	pages.updated = map[notionapi.PageID]notionapi.Properties{}
+	config.MarkPublished = false
+	processPage(client, *titledPage("page", "Post"), config)
+	config.MarkPublished, config.DatabaseType, config.PagesOutputDir = true, "pages", t.TempDir()
+	processPage(client, *titledPage("page", "Post"), config)
+	if len(pages.updated) != 0 {
+		t.Errorf("pages updated unexpectedly: %v", pages.updated)
+	}
+}
@@ -0,0 +1,171 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jomei/notionapi"
+)
+
+// QueryFilter is a filter of the Notion API query, written in the config file as the JSON of
+// the API in YAML, e.g. the conditions of a database view:
+//
+//	filter:
+//	  property: Status
+//	  status:
+//	    equals: Ready to publish
+//
+// Pages must also meet the built-in published and done conditions.
+type QueryFilter map[string]any
+
+// QuerySort orders the pages of the Notion API query by a property or a timestamp
+type QuerySort struct {
+	Property  string `yaml:"property,omitempty"`
+	Timestamp string `yaml:"timestamp,omitempty"` // "created_time" or "last_edited_time"
+	Direction string `yaml:"direction,omitempty"` // "ascending" (default) or "descending"
+}
+
+// rawFilter is a condition of a QueryFilter, sent to Notion as written. It embeds a notionapi
+// filter only to satisfy notionapi.Filter.
+type rawFilter struct {
+	notionapi.PropertyFilter
+	data json.RawMessage
+}
+
+func (f rawFilter) MarshalJSON() ([]byte, error) {
+	return f.data, nil
+}
+
+// conditions returns the conditions of f. The conditions of a top-level "and" are returned one
+// by one, so they join the built-in conditions without counting against the nesting limit of
+// Notion.
+func (f QueryFilter) conditions() ([]notionapi.Filter, error) {
+	if len(f) == 0 {
+		return nil, nil
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	items := []any{map[string]any(f)}
+	if and, ok := f["and"].([]any); ok && len(f) == 1 {
+		items = and
+	}
+	var conditions []notionapi.Filter
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %v", err)
+		}
+		conditions = append(conditions, rawFilter{data: data})
+	}
+	return conditions, nil
+}
+
+// validate reports a filter that is not a property, timestamp or compound filter of the Notion
+// API. The conditions themselves are checked by Notion when the database is queried.
+func (f QueryFilter) validate() error {
+	return validateFilter(map[string]any(f))
+}
+
+// validateFilter reports a filter, or a filter nested in and/or, without a property or a
+// timestamp and one condition
+func validateFilter(filter any) error {
+	// YAML decodes the nested filters as QueryFilter, and Go callers may pass plain maps
+	var condition map[string]any
+	switch filter := filter.(type) {
+	case QueryFilter:
+		condition = filter
+	case map[string]any:
+		condition = filter
+	default:
+		return fmt.Errorf("invalid filter %v; must be a mapping", filter)
+	}
+	for _, operator := range []string{"and", "or"} {
+		if nested, ok := condition[operator]; ok {
+			list, ok := nested.([]any)
+			if !ok || len(condition) != 1 {
+				return fmt.Errorf("invalid filter: %q must be the only key and hold a list of filters", operator)
+			}
+			for _, item := range list {
+				if err := validateFilter(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	_, property := condition["property"]
+	_, timestamp := condition["timestamp"]
+	if property == timestamp || len(condition) != 2 {
+		return fmt.Errorf("invalid filter %v; must have a property or timestamp and one condition, or and/or", filter)
+	}
+	return nil
+}
+
+// sortObjects converts sorts to the sorts of the Notion API query
+func sortObjects(sorts []QuerySort) ([]notionapi.SortObject, error) {
+	var objects []notionapi.SortObject
+	for _, sort := range sorts {
+		if (sort.Property == "") == (sort.Timestamp == "") {
+			return nil, fmt.Errorf("invalid sort: set either property or timestamp")
+		}
+		if sort.Timestamp != "" && sort.Timestamp != "created_time" && sort.Timestamp != "last_edited_time" {
+			return nil, fmt.Errorf("invalid sort timestamp %q; must be 'created_time' or 'last_edited_time'", sort.Timestamp)
+		}
+		direction := orDefault(sort.Direction, "ascending")
+		if direction != "ascending" && direction != "descending" {
+			return nil, fmt.Errorf("invalid sort direction %q; must be 'ascending' or 'descending'", sort.Direction)
+		}
+		objects = append(objects, notionapi.SortObject{
+			Property:  sort.Property,
+			Timestamp: notionapi.TimestampType(sort.Timestamp),
+			Direction: notionapi.SortOrder(direction),
+		})
+	}
+	return objects, nil
+}
+
+// validateQuery reports an invalid filter or sort of a database in the config file
+func validateQuery(filter QueryFilter, sorts []QuerySort) error {
+	if _, err := filter.conditions(); err != nil {
+		return err
+	}
+	_, err := sortObjects(sorts)
+	return err
+}
+
+// queryFilter returns the filter of the current database type from the config file
+func (c runConfig) queryFilter() QueryFilter {
+	if c.DatabaseType == "diary" {
+		return c.DiaryFilter
+	}
+	return c.BlogFilter
+}
+
+// querySorts returns the sorts of the current database type from the config file
+func (c runConfig) querySorts() []QuerySort {
+	if c.DatabaseType == "diary" {
+		return c.DiarySorts
+	}
+	return c.BlogSorts
+}
+
+// databaseQuery returns the query of the pages of the current database: the built-in
+// conditions of databaseQueryFilter with the filter and the sorts of the config file
+func databaseQuery(config runConfig) (*notionapi.DatabaseQueryRequest, error) {
+	query := &notionapi.DatabaseQueryRequest{
+		PageSize: 100,
+	}
+	filter := databaseQueryFilter(config.properties())
+	conditions, err := config.queryFilter().conditions()
+	if err != nil {
+		return nil, err
+	}
+	if filter = append(filter, conditions...); len(filter) > 0 {
+		query.Filter = filter
+	}
+	if query.Sorts, err = sortObjects(config.querySorts()); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDatabaseQuery(t *testing.T) {
+	fileConfig, err := parseFileConfig([]byte(`
+blog:
+  properties:
+    done: "-"
+  filter:
+    and:
+      - property: Status
+        status:
+          equals: Ready to publish
+      - or:
+          - property: Tags
+            multi_select:
+              contains: Go
+          - timestamp: created_time
+            created_time:
+              after: "2024-01-01"
+  sorts:
+    - property: Date
+      direction: descending
+    - timestamp: created_time
+databases:
+  - name: books
+    profile: data
+    databaseId: books-db
+    dataFile: books.json
+    filter:
+      property: Read
+      checkbox:
+        equals: true
+`), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := runConfig{
+		DatabaseType:   "blog",
+		BlogProperties: fileConfig.Blog.Properties,
+		BlogFilter:     fileConfig.Blog.Filter,
+		BlogSorts:      fileConfig.Blog.Sorts,
+		Databases:      fileConfig.Databases,
+	}
+
+	tests := []struct {
+		name   string
+		config runConfig
+		want   string
+	}{
+		{
+			// The conditions of a top-level and join the built-in ones
+			"blog", config,
+			`{"sorts":[{"property":"Date","direction":"descending"},{"timestamp":"created_time","direction":"ascending"}],"page_size":100,` +
+				`"filter":{"and":[{"property":"published","checkbox":{"does_not_equal":true}},` +
+				`{"property":"Status","status":{"equals":"Ready to publish"}},` +
+				`{"or":[{"multi_select":{"contains":"Go"},"property":"Tags"},{"created_time":{"after":"2024-01-01"},"timestamp":"created_time"}]}]}}`,
+		},
+		{
+			"additional database", config.databaseConfig("books"),
+			`{"page_size":100,"filter":{"and":[{"checkbox":{"equals":true},"property":"Read"}]}}`,
+		},
+		{
+			"no filter", runConfig{DatabaseType: "diary", DiaryProperties: PropertyMapping{Published: "-", Done: "-"}},
+			`{"page_size":100}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := databaseQuery(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("query = %s\nwant %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter QueryFilter
+		sorts  []QuerySort
+		valid  bool
+	}{
+		{"property filter", QueryFilter{"property": "Status", "status": map[string]any{"equals": "Done"}}, nil, true},
+		{"no condition", QueryFilter{"property": "Status"}, nil, false},
+		{"property and timestamp", QueryFilter{"property": "Status", "timestamp": "created_time"}, nil, false},
+		{"and with other keys", QueryFilter{"and": []any{}, "property": "Status"}, nil, false},
+		{"nested filter without a condition", QueryFilter{"or": []any{map[string]any{"property": "Status"}}}, nil, false},
+		{"sort", nil, []QuerySort{{Property: "Date", Direction: "descending"}}, true},
+		{"sort without a property", nil, []QuerySort{{Direction: "descending"}}, false},
+		{"unknown timestamp", nil, []QuerySort{{Timestamp: "published_time"}}, false},
+		{"unknown direction", nil, []QuerySort{{Property: "Date", Direction: "newest"}}, false},
+	}
+	for _, tt := range tests {
+		if err := validateQuery(tt.filter, tt.sorts); (err == nil) != tt.valid {
+			t.Errorf("%s: validateQuery() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// RedirectMap collects old → new routes of renamed pages and redirect_from properties.
+// It is stored as an Astro `redirects` JSON object, a Netlify `_redirects` file or the
+// `redirects` list of vercel.json. A nil *RedirectMap records nothing.
+type RedirectMap struct {
+	mu        sync.Mutex
+	redirects map[string]string
+	path      string
+	format    string            // "astro", "netlify" or "vercel"
+	workspace *writer.Workspace // Workspace of the run the file is read from and saved to
+	dirty     bool
+}
+
+// vercelRedirect is an entry of the redirects list in vercel.json
+type vercelRedirect struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Permanent   bool   `json:"permanent"`
+}
+
+// loadRedirectMap reads the redirects file at path through workspace; a missing file yields an
+// empty map
+func loadRedirectMap(path, format string, workspace *writer.Workspace) (*RedirectMap, error) {
+	r := &RedirectMap{redirects: map[string]string{}, path: path, format: format, workspace: workspace}
+
+	data, err := workspace.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redirects %s: %v", path, err)
+	}
+
+	switch format {
+	case "netlify":
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && !strings.HasPrefix(fields[0], "#") {
+				r.redirects[fields[0]] = fields[1]
+			}
+		}
+	case "vercel":
+		var vercel struct {
+			Redirects []vercelRedirect `json:"redirects"`
+		}
+		if err := json.Unmarshal(data, &vercel); err != nil {
+			return nil, fmt.Errorf("failed to parse redirects %s: %v", path, err)
+		}
+		for _, redirect := range vercel.Redirects {
+			r.redirects[redirect.Source] = redirect.Destination
+		}
+	default:
+		if err := json.Unmarshal(data, &r.redirects); err != nil {
+			return nil, fmt.Errorf("failed to parse redirects %s: %v", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Add redirects from to to. Earlier redirects to from are pointed at to as well, so
+// a page renamed several times never redirects through a chain.
+func (r *RedirectMap) Add(from, to string) {
+	if r == nil || from == to {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.redirects[from] == to {
+		return
+	}
+	for source, target := range r.redirects {
+		if target == from {
+			r.redirects[source] = to
+		}
+	}
+	// The page may have been renamed back to an old title
+	delete(r.redirects, to)
+	r.redirects[from] = to
+	r.dirty = true
+}
+
+// Save writes the redirects file if redirects were added
+func (r *RedirectMap) Save() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+
+	sources := make([]string, 0, len(r.redirects))
+	for source := range r.redirects {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var data []byte
+	var err error
+	switch r.format {
+	case "netlify":
+		var buf bytes.Buffer
+		buf.WriteString("# Generated by notion-to-astro-go\n")
+		for _, source := range sources {
+			fmt.Fprintf(&buf, "%s %s 301\n", source, r.redirects[source])
+		}
+		data = buf.Bytes()
+	case "vercel":
+		data, err = r.vercelJSON(sources)
+	default:
+		data, err = json.MarshalIndent(r.redirects, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode redirects: %v", err)
+	}
+
+	if err := r.workspace.WriteFile(r.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write redirects %s: %v", r.path, err)
+	}
+	r.dirty = false
+	return nil
+}
+
+// vercelJSON replaces the redirects list of the existing vercel.json, keeping its other settings
+func (r *RedirectMap) vercelJSON(sources []string) ([]byte, error) {
+	settings := map[string]json.RawMessage{}
+	if data, err := r.workspace.ReadFile(r.path); err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return nil, err
+		}
+	}
+
+	redirects := make([]vercelRedirect, len(sources))
+	for i, source := range sources {
+		redirects[i] = vercelRedirect{Source: source, Destination: r.redirects[source], Permanent: true}
+	}
+	list, err := json.Marshal(redirects)
+	if err != nil {
+		return nil, err
+	}
+	settings["redirects"] = list
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// redirectFromPaths returns the old paths listed in the redirect_from property of a page.
+// Rich text values may hold several paths separated by commas or whitespace.
+func redirectFromPaths(page notionapi.Page, property string) []string {
+	prop, ok := lookupProperty(page.Properties, property, "redirect_from", "RedirectFrom")
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	switch p := prop.(type) {
+	case *notionapi.RichTextProperty:
+		for _, rt := range p.RichText {
+			values = append(values, strings.FieldsFunc(rt.PlainText, func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\n' || r == '\t'
+			})...)
+		}
+	case *notionapi.MultiSelectProperty:
+		for _, option := range p.MultiSelect {
+			values = append(values, option.Name)
+		}
+	}
+
+	var paths []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			paths = append(paths, "/"+strings.Trim(value, "/"))
+		}
+	}
+	return paths
+}
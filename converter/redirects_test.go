@@ -0,0 +1,76 @@
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestRedirectMapFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	// Other vercel.json settings are kept
+	vercelPath := filepath.Join(dir, "vercel.json")
+	if err := os.WriteFile(vercelPath, []byte(`{"cleanUrls": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format   string
+		path     string
+		expected string
+	}{
+		{"astro", filepath.Join(dir, "redirects.json"), "{\n  \"/old\": \"/new\"\n}\n"},
+		{"netlify", filepath.Join(dir, "_redirects"), "# Generated by notion-to-astro-go\n/old /new 301\n"},
+		{"vercel", vercelPath, "{\n  \"cleanUrls\": true,\n  \"redirects\": [\n    {\n      \"source\": \"/old\",\n      \"destination\": \"/new\",\n      \"permanent\": true\n    }\n  ]\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			redirects, err := loadRedirectMap(tt.path, tt.format, nil)
+			if err != nil {
+				t.Fatalf("loadRedirectMap() error = %v", err)
+			}
+			redirects.Add("/old", "/new")
+			if err := redirects.Save(); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			data, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Save() wrote:\n%s\nwant:\n%s", data, tt.expected)
+			}
+
+			// The saved file is read back in the same format
+			reloaded, err := loadRedirectMap(tt.path, tt.format, nil)
+			if err != nil {
+				t.Fatalf("loadRedirectMap() error = %v", err)
+			}
+			if !reflect.DeepEqual(reloaded.redirects, map[string]string{"/old": "/new"}) {
+				t.Errorf("reloaded redirects = %v", reloaded.redirects)
+			}
+		})
+	}
+}
+
+func TestRedirectFromPaths(t *testing.T) {
+	page := notionapi.Page{Properties: notionapi.Properties{
+		"redirect_from": &notionapi.RichTextProperty{RichText: richText("/old-post, blog/legacy/\n/2019/01/post")},
+	}}
+	expected := []string{"/old-post", "/blog/legacy", "/2019/01/post"}
+	if got := redirectFromPaths(page, ""); !reflect.DeepEqual(got, expected) {
+		t.Errorf("redirectFromPaths() = %v, want %v", got, expected)
+	}
+
+	data, _ := json.Marshal(redirectFromPaths(notionapi.Page{}, ""))
+	if string(data) != "null" {
+		t.Errorf("expected no paths for a page without the property, got %s", data)
+	}
+}
@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// removeRenamedOutputs removes the files a page was written to under its previous title.
+// The old route is redirected to the new one when a redirects file is configured. With
+// PRUNE_MODE=list the files are only listed.
+func removeRenamedOutputs(config runConfig, pageID, outputPath string) {
+	for _, oldPath := range config.Manifest.PreviousOutputs(pageID, outputPath) {
+		config.logInfo("Page %s was renamed: %s -> %s", pageID, oldPath, outputPath)
+		if config.PruneMode == "list" {
+			config.logInfo("Would remove %s (renamed to %s; PRUNE_MODE=list)", oldPath, outputPath)
+			continue
+		}
+
+		oldFiles := []string{oldPath}
+		if sidecar := statsSidecarPath(oldPath); sidecar != oldPath {
+			oldFiles = append(oldFiles, sidecar)
+		}
+		for _, path := range oldFiles {
+			var err error
+			if config.PruneMode == "trash" {
+				err = moveToTrash(path, filepath.Join(trashDir, time.Now().Format("20060102-150405")), config.Workspace)
+			} else {
+				err = config.Workspace.Remove(path)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				config.logWarn("Failed to remove %s: %v", path, err)
+			}
+		}
+		config.Manifest.Forget(oldFiles...)
+
+		from, fromOK := pageRoute(config, oldPath)
+		to, toOK := pageRoute(config, outputPath)
+		if fromOK && toOK && from != to {
+			config.Redirects.Add(from, to)
+		}
+		config.logInfo("Removed %s (renamed to %s)", oldPath, outputPath)
+	}
+}
+
+// pageRoute returns the site route of a generated file: /<type>/<slug> for posts (/<name>/<slug>
+// for additional databases) and /<path>/<slug> for page trees, with index pages routed to their
+// directory
+func pageRoute(config runConfig, path string) (string, bool) {
+	switch config.DatabaseType {
+	case "blog", "diary", "pages":
+	default:
+		return "", false
+	}
+	root, prefix := config.outputDir(), config.routePrefix()
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	rel = strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+	rel = strings.TrimSuffix(strings.TrimSuffix(rel, "index"), "/")
+
+	route := prefix
+	for _, segment := range strings.Split(rel, "/") {
+		if segment != "" {
+			route += "/" + astroSlug(segment)
+		}
+	}
+	if route == "" {
+		route = "/"
+	}
+	return route, true
+}
+
+// astroSlug slugifies a path segment the way Astro content collections do:
+// lowercase, spaces to hyphens, other punctuation removed
+func astroSlug(segment string) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(segment) {
+		switch {
+		case r == ' ':
+			slug.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r):
+			slug.WriteRune(r)
+		}
+	}
+	return slug.String()
+}
@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestRenamedPageReplacesOldFile(t *testing.T) {
+	dir := t.TempDir()
+	blogDir := filepath.Join(dir, "blog")
+	if err := os.MkdirAll(blogDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The page was exported under its old title by a previous run
+	oldPath := filepath.Join(blogDir, "Old Title.md")
+	if err := os.WriteFile(oldPath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := previous.RecordFile(oldPath, "page"); err != nil {
+		t.Fatal(err)
+	}
+	if err := previous.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := loadManifest(filepath.Join(dir, "manifest.json"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirects, err := loadRedirectMap(filepath.Join(dir, "redirects.json"), "astro", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirects.Add("/blog/first-title", "/blog/old-title")
+
+	client := &notionapi.Client{
+		Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{"page": {paragraphBlock("Body")}}},
+	}
+	config := runConfig{
+		DatabaseType:   "blog",
+		BlogOutputDir:  blogDir,
+		LineBreakStyle: "spaces",
+		PruneMode:      "delete",
+		Manifest:       manifest,
+		Redirects:      redirects,
+	}
+	processPage(client, *titledPage("page", "New Title"), config)
+
+	if _, err := os.Stat(filepath.Join(blogDir, "New Title.md")); err != nil {
+		t.Errorf("expected the renamed post to be written: %v", err)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("expected the old post to be removed")
+	}
+	if _, ok := manifest.Files[filepath.ToSlash(oldPath)]; ok {
+		t.Errorf("expected the old post to be removed from the manifest")
+	}
+
+	expected := map[string]string{
+		"/blog/first-title": "/blog/new-title",
+		"/blog/old-title":   "/blog/new-title",
+	}
+	if len(redirects.redirects) != len(expected) {
+		t.Errorf("redirects = %v, want %v", redirects.redirects, expected)
+	}
+	for from, to := range expected {
+		if redirects.redirects[from] != to {
+			t.Errorf("redirect %s = %q, want %q", from, redirects.redirects[from], to)
+		}
+	}
+}
+
+func TestPageRoute(t *testing.T) {
+	config := runConfig{BlogOutputDir: "content/blog", PagesOutputDir: "content/docs"}
+	tests := []struct {
+		dbType   string
+		path     string
+		expected string
+	}{
+		{"blog", "content/blog/Hello World!.md", "/blog/hello-world"},
+		{"blog", "content/blog/日本語の記事.md", "/blog/日本語の記事"},
+		{"pages", "content/docs/Guide/Install.md", "/guide/install"},
+		{"pages", "content/docs/Guide/index.md", "/guide"},
+	}
+
+	for _, tt := range tests {
+		config.DatabaseType = tt.dbType
+		if got, ok := pageRoute(config, tt.path); !ok || got != tt.expected {
+			t.Errorf("pageRoute(%q) = %q, %v; want %q", tt.path, got, ok, tt.expected)
+		}
+	}
+}
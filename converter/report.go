@@ -0,0 +1,327 @@
+package converter
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress prints how many pages were processed every LOG_PROGRESS_EVERY pages, so long runs
+// show they are moving without printing the steps of every page.
+// A nil *Progress prints nothing.
+type Progress struct {
+	name     string
+	total    int // 0 when the number of pages is not known in advance
+	interval int
+	logger   *slog.Logger // Logger of the run
+	mu       sync.Mutex
+	done     int
+}
+
+// newProgress returns the progress of the pages of name logged to logger, or nil when interval
+// is 0
+func newProgress(name string, total, interval int, logger *slog.Logger) *Progress {
+	if interval <= 0 {
+		return nil
+	}
+	return &Progress{name: name, total: total, interval: interval, logger: logger}
+}
+
+// PageDone counts a processed page, whether it was exported or skipped
+func (p *Progress) PageDone() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.done%p.interval != 0 {
+		return
+	}
+	if p.total > 0 {
+		logf(p.logger, slog.LevelInfo, "Processed %d of %d pages of %s", p.done, p.total, p.name)
+	} else {
+		logf(p.logger, slog.LevelInfo, "Processed %d pages of %s", p.done, p.name)
+	}
+}
+
+// RunReport collects run-wide results shown in the summary at the end of a run.
+// A nil *RunReport is valid and records nothing.
+type RunReport struct {
+	mu                sync.Mutex
+	unsupportedBlocks map[string]int
+	linkProblems      []string
+	hookFailures      []string
+	bannedContent     []string
+	validationErrors  []string
+	contentErrors     []string
+	exportedPages     []string
+	unchangedPages    int
+	incompletePages   []string
+	oversizedPages    []string
+	writeBackFailures []string
+	failedPages       []string
+	problems          []error // Problems of all pages, wrapping their causes, for Err
+	started           time.Time
+}
+
+// newRunReport creates an empty run report
+func newRunReport() *RunReport {
+	return &RunReport{unsupportedBlocks: map[string]int{}, started: time.Now()}
+}
+
+// CountUnsupportedBlock records a block that was skipped because it cannot be exported
+func (r *RunReport) CountUnsupportedBlock(blockType string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.unsupportedBlocks[blockType] == 0 {
+		r.problems = append(r.problems, fmt.Errorf("%w: %s", ErrUnsupportedBlock, blockType))
+	}
+	r.unsupportedBlocks[blockType]++
+}
+
+// AddLinkProblem records a broken or questionable link found by -check-links
+func (r *RunReport) AddLinkProblem(file, link, reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.linkProblems = append(r.linkProblems, fmt.Sprintf("%s: %s (%s)", file, link, reason))
+}
+
+// AddHookFailure records a post-process command that failed
+func (r *RunReport) AddHookFailure(command string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hookFailures = append(r.hookFailures, fmt.Sprintf("%s: %v", command, err))
+}
+
+// AddBannedContent records banned content found in a page
+func (r *RunReport) AddBannedContent(page, finding string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bannedContent = append(r.bannedContent, fmt.Sprintf("%s: %s", page, finding))
+}
+
+// AddValidationError records a page skipped because its frontmatter could not be generated
+func (r *RunReport) AddValidationError(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.validationErrors = append(r.validationErrors, err.Error())
+	r.problems = append(r.problems, withCause(err, ErrPageSkipped))
+}
+
+// AddContentError records a page whose content could not be retrieved, and what was done with it
+func (r *RunReport) AddContentError(page string, err error, action string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contentErrors = append(r.contentErrors, fmt.Sprintf("%s (%s): %v", page, action, err))
+	problem := fmt.Errorf("%s (%s): %w", page, action, err)
+	if action == "skipped" {
+		problem = withCause(problem, ErrPageSkipped)
+	}
+	r.problems = append(r.problems, problem)
+}
+
+// AddExportedPage records a page file written by the run
+func (r *RunReport) AddExportedPage(path string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exportedPages = append(r.exportedPages, filepath.ToSlash(path))
+}
+
+// AddIncompletePage records a page skipped because required properties are empty
+func (r *RunReport) AddIncompletePage(page string, missing []string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.incompletePages = append(r.incompletePages, fmt.Sprintf("%s (missing %s)", page, strings.Join(missing, ", ")))
+	r.problems = append(r.problems, withCause(fmt.Errorf("%s: missing %s", page, strings.Join(missing, ", ")), ErrPageSkipped))
+}
+
+// AddOversizedPage records an exported page larger than the WARN_PAGE_* limits
+func (r *RunReport) AddOversizedPage(path string, problems []string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.oversizedPages = append(r.oversizedPages, fmt.Sprintf("%s: %s", path, strings.Join(problems, ", ")))
+}
+
+// AddWriteBackFailure records a page whose published checkbox could not be updated in Notion
+func (r *RunReport) AddWriteBackFailure(page string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeBackFailures = append(r.writeBackFailures, fmt.Sprintf("%s: %v", page, err))
+}
+
+// AddFailedPage records a page that could not be exported, e.g. because its file could not be written
+func (r *RunReport) AddFailedPage(page string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failedPages = append(r.failedPages, fmt.Sprintf("%s: %v", page, err))
+	r.problems = append(r.problems, withCause(fmt.Errorf("%s: %w", page, err), ErrPageSkipped))
+}
+
+// Failed reports whether some pages could not be exported as they are in Notion: pages that
+// failed, whose content could not be retrieved or whose frontmatter was invalid
+func (r *RunReport) Failed() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.failedPages) > 0 || len(r.contentErrors) > 0 || len(r.validationErrors) > 0
+}
+
+// Err returns the error of a run in which some pages could not be exported, or nil when
+// Failed is false. The error wraps the problems of all pages, also those of pages that were
+// skipped for empty required properties and of unsupported blocks.
+func (r *RunReport) Err() error {
+	if !r.Failed() {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return pagesFailedError(append([]error(nil), r.problems...))
+}
+
+// AddUnchangedPage counts a page skipped because it did not change since the last run
+func (r *RunReport) AddUnchangedPage() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unchangedPages++
+}
+
+// UnchangedPages returns the number of pages skipped because they did not change
+func (r *RunReport) UnchangedPages() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unchangedPages
+}
+
+// ExportedPages returns the page files written by the run in the order they were written
+func (r *RunReport) ExportedPages() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.exportedPages...)
+}
+
+// reportSection is one part of the run summary: a title line followed by indented items
+type reportSection struct {
+	title string
+	items []string
+}
+
+// sections returns the run summary, starting with the number of exported pages and the run time
+func (r *RunReport) sections() []reportSection {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := time.Since(r.started).Round(time.Second)
+	sections := []reportSection{{title: fmt.Sprintf("Exported %d pages in %s", len(r.exportedPages), elapsed)}}
+	if r.unchangedPages > 0 {
+		sections = append(sections, reportSection{title: fmt.Sprintf("Skipped %d unchanged pages (use -force to export them)", r.unchangedPages)})
+	}
+
+	if len(r.unsupportedBlocks) > 0 {
+		types := make([]string, 0, len(r.unsupportedBlocks))
+		for blockType := range r.unsupportedBlocks {
+			types = append(types, blockType)
+		}
+		sort.Strings(types)
+
+		counts := make([]string, len(types))
+		for i, blockType := range types {
+			counts[i] = fmt.Sprintf("%s: %d", blockType, r.unsupportedBlocks[blockType])
+		}
+		sections = append(sections, reportSection{title: fmt.Sprintf("Skipped unsupported blocks (%s)", strings.Join(counts, ", "))})
+	}
+
+	if len(r.linkProblems) > 0 {
+		sort.Strings(r.linkProblems)
+		sections = append(sections, reportSection{fmt.Sprintf("Found %d link problems:", len(r.linkProblems)), r.linkProblems})
+	}
+	if len(r.hookFailures) > 0 {
+		sections = append(sections, reportSection{fmt.Sprintf("%d post-process commands failed:", len(r.hookFailures)), r.hookFailures})
+	}
+	if len(r.bannedContent) > 0 {
+		sections = append(sections, reportSection{"Found banned content in exported pages:", r.bannedContent})
+	}
+	if len(r.incompletePages) > 0 {
+		sections = append(sections, reportSection{fmt.Sprintf("Skipped %d pages with empty required properties:", len(r.incompletePages)), r.incompletePages})
+	}
+	if len(r.oversizedPages) > 0 {
+		sort.Strings(r.oversizedPages)
+		sections = append(sections, reportSection{fmt.Sprintf("Found %d oversized pages:", len(r.oversizedPages)), r.oversizedPages})
+	}
+	if len(r.writeBackFailures) > 0 {
+		sections = append(sections, reportSection{fmt.Sprintf("Failed to mark %d pages as published:", len(r.writeBackFailures)), r.writeBackFailures})
+	}
+	if len(r.validationErrors) > 0 {
+		sections = append(sections, reportSection{fmt.Sprintf("Skipped %d pages with invalid frontmatter values:", len(r.validationErrors)), r.validationErrors})
+	}
+	if len(r.contentErrors) > 0 {
+		sections = append(sections, reportSection{fmt.Sprintf("Failed to retrieve content of %d pages:", len(r.contentErrors)), r.contentErrors})
+	}
+	if len(r.failedPages) > 0 {
+		sections = append(sections, reportSection{fmt.Sprintf("Failed to export %d pages:", len(r.failedPages)), r.failedPages})
+	}
+	return sections
+}
+
+// Print logs the run summary to logger, a message per section with its items on indented lines
+func (r *RunReport) Print(logger *slog.Logger) {
+	if r == nil {
+		return
+	}
+	for _, section := range r.sections() {
+		message := section.title
+		for _, item := range section.items {
+			message += "\n  " + item
+		}
+		logf(logger, slog.LevelInfo, "%s", message)
+	}
+}
@@ -0,0 +1,33 @@
+package converter
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	buf, _ := captureLog(t, slog.LevelInfo, "text")
+
+	progress := newProgress("blog", 0, 2, nil)
+	for i := 0; i < 5; i++ {
+		progress.PageDone()
+	}
+	if expected := "Processed 2 pages of blog\nProcessed 4 pages of blog\n"; buf.String() != expected {
+		t.Errorf("progress = %q, want %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	progress = newProgress("pages.txt", 3, 3, nil)
+	for i := 0; i < 3; i++ {
+		progress.PageDone()
+	}
+	if expected := "Processed 3 of 3 pages of pages.txt\n"; buf.String() != expected {
+		t.Errorf("progress = %q, want %q", buf.String(), expected)
+	}
+
+	// Without an interval there is no progress
+	newProgress("blog", 0, 0, nil).PageDone()
+	if buf.String() != "Processed 3 of 3 pages of pages.txt\n" {
+		t.Errorf("unexpected progress %q", buf.String())
+	}
+}
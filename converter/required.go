@@ -0,0 +1,73 @@
+package converter
+
+import (
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/mdrender"
+)
+
+// requiredProperties returns the properties a page of the current database must fill to be exported
+func (c runConfig) requiredProperties() []string {
+	switch c.DatabaseType {
+	case "blog":
+		return c.BlogRequired
+	case "diary":
+		return c.DiaryRequired
+	}
+	return nil
+}
+
+// missingRequiredProperties returns the required properties that are empty on page. "cover"
+// and "icon" refer to the page cover and icon instead of a property.
+func missingRequiredProperties(page notionapi.Page, required []string) []string {
+	var missing []string
+	for _, name := range required {
+		var filled bool
+		switch name {
+		case "cover":
+			filled = page.Cover != nil && page.Cover.GetURL() != ""
+		case "icon":
+			filled = page.Icon != nil
+		default:
+			prop, ok := page.Properties[name]
+			filled = ok && !propertyEmpty(prop)
+		}
+		if !filled {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// propertyEmpty reports whether a property has no value. Checkboxes and numbers always have one.
+func propertyEmpty(prop notionapi.Property) bool {
+	switch p := prop.(type) {
+	case *notionapi.TitleProperty:
+		return mdrender.PlainText(p.Title) == ""
+	case *notionapi.RichTextProperty:
+		return mdrender.PlainText(p.RichText) == ""
+	case *notionapi.SelectProperty:
+		return p.Select.Name == ""
+	case *notionapi.StatusProperty:
+		return p.Status.Name == ""
+	case *notionapi.MultiSelectProperty:
+		return len(p.MultiSelect) == 0
+	case *notionapi.DateProperty:
+		return p.Date == nil || p.Date.Start == nil
+	case *notionapi.URLProperty:
+		return p.URL == ""
+	case *notionapi.EmailProperty:
+		return p.Email == ""
+	case *notionapi.PhoneNumberProperty:
+		return p.PhoneNumber == ""
+	case *notionapi.FilesProperty:
+		return len(p.Files) == 0
+	case *notionapi.PeopleProperty:
+		return len(p.People) == 0
+	case *notionapi.RelationProperty:
+		return len(p.Relation) == 0
+	case *notionapi.FormulaProperty:
+		return p.Formula.Type == notionapi.FormulaTypeString && p.Formula.String == ""
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestMissingRequiredProperties(t *testing.T) {
+	page := notionapi.Page{
+		Properties: notionapi.Properties{
+			"Description": &notionapi.RichTextProperty{RichText: richText("  ")},
+			"Tags":        &notionapi.MultiSelectProperty{MultiSelect: []notionapi.Option{{Name: "go"}}},
+			"Category":    &notionapi.SelectProperty{},
+			"Featured":    &notionapi.CheckboxProperty{},
+		},
+		Cover: &notionapi.Image{Type: notionapi.FileTypeExternal, External: &notionapi.FileObject{URL: "https://example.com/cover.png"}},
+	}
+
+	missing := missingRequiredProperties(page, []string{"Description", "Tags", "Category", "Featured", "cover", "icon", "Missing"})
+	expected := []string{"Description", "Category", "icon", "Missing"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("missingRequiredProperties() = %v, want %v", missing, expected)
+	}
+}
+
+func TestIncompletePageSkipped(t *testing.T) {
+	dir := t.TempDir()
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Draft")},
+	}}}
+	report := newRunReport()
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, BlogRequired: []string{"cover"}, Report: report}
+
+	processPage(client, *titledPage("page", "Half done"), config)
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("incomplete page was written: %v", files)
+	}
+	if len(report.incompletePages) != 1 || report.incompletePages[0] != "Half done (missing cover)" {
+		t.Errorf("incomplete pages = %v", report.incompletePages)
+	}
+
+	// Diary pages are checked against their own list
+	config.DatabaseType, config.DiaryOutputDir = "diary", dir
+	processPage(client, *titledPage("page", "Diary"), config)
+	if files, _ := filepath.Glob(filepath.Join(dir, "*Diary.md")); len(files) != 1 {
+		t.Errorf("diary page without required properties was not written: %v", files)
+	}
+}
+
+func TestSkippedPagesKeepFilesWhenPruning(t *testing.T) {
+	dir := t.TempDir()
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page": {paragraphBlock("Body")},
+	}}}
+	banned, err := newContentFilter([]string{"Body"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidDate := titledPage("page", "Invalid")
+	invalidDate.Properties["publishedAt"] = &notionapi.DateProperty{}
+	failingClient := &notionapi.Client{Block: &failingBlockService{}}
+
+	tests := []struct {
+		name   string
+		page   *notionapi.Page
+		config func(*runConfig)
+		client *notionapi.Client
+		kept   bool
+	}{
+		{"missing required property", titledPage("page", "Half done"), func(c *runConfig) { c.BlogRequired = []string{"cover"} }, client, true},
+		{"invalid frontmatter value", invalidDate, func(*runConfig) {}, client, true},
+		{"no title", titledPage("page", ""), func(*runConfig) {}, client, true},
+		// ON_CONTENT_ERROR=skip is documented to prune the files of the page, unlike keep
+		{"content error skipped", titledPage("page", "Broken"), func(c *runConfig) { c.ContentErrorPolicy = "skip" }, failingClient, false},
+		{"content error kept", titledPage("page", "Broken"), func(c *runConfig) { c.ContentErrorPolicy = "keep" }, failingClient, true},
+		{"strict banned content", titledPage("page", "Leaky"), func(c *runConfig) { c.BannedContent = banned }, client, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The page was exported by an earlier run
+			manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest.Files[filepath.Join(dir, "live.md")] = ManifestEntry{PageID: "page"}
+			config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, Manifest: manifest, Report: newRunReport()}
+			tt.config(&config)
+
+			processPage(tt.client, *tt.page, config)
+
+			stale := manifest.StaleFiles([]string{dir})
+			if kept := len(stale) == 0; kept != tt.kept {
+				t.Errorf("stale files = %v, want the file kept: %v", stale, tt.kept)
+			}
+			if files, _ := filepath.Glob(filepath.Join(dir, "*.md")); len(files) != 0 {
+				t.Errorf("skipped page was written: %v", files)
+			}
+		})
+	}
+}
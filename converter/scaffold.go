@@ -0,0 +1,201 @@
+package converter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// scaffoldFile is a file generated by `scaffold astro`
+type scaffoldFile struct {
+	path     string // Relative to the Astro project
+	template *template.Template
+	data     interface{}
+}
+
+// astroContentConfigTemplate defines collections whose schema matches the frontmatter of generateFrontmatterYAML
+var astroContentConfigTemplate = template.Must(template.New("config.ts").Parse(`// Generated by notion-to-astro-go scaffold astro.
+// The schema matches the frontmatter written by notion-to-astro-go.
+import { defineCollection, z } from 'astro:content';
+import { glob } from 'astro/loaders';
+
+const notionSchema = z.object({
+  id: z.string().optional(),
+  title: z.string(),
+  description: z.string().optional(),
+  publishedAt: z.coerce.date().optional(),
+  date: z.coerce.date().optional(),
+  tags: z.array(z.string()).default([]),
+  coverImage: z.string().optional(),
+  heroImage: z.string().optional(),
+  icon: z.string().optional(),
+  layout: z.string().optional(),
+  template: z.string().optional(),
+  draft: z.boolean().default(false),
+  weather: z.string().optional(),
+  dayOfWeek: z.string().optional(),
+  dateLabel: z.string().optional(),
+  robots: z.string().optional(),
+  sitemap: z.boolean().optional(),
+  notionUrl: z.string().url().optional(),
+  contentHash: z.string().optional(),
+  exportedAt: z.coerce.date().optional(),
+  sourceLastEdited: z.coerce.date().optional(),
+  prev: z.string().optional(),
+  next: z.string().optional(),
+  jsonLd: z
+    .object({
+      headline: z.string(),
+      datePublished: z.coerce.date().optional(),
+      dateModified: z.coerce.date().optional(),
+      author: z.string().optional(),
+      image: z.string().optional(),
+    })
+    .optional(),
+});
+
+const blog = defineCollection({
+  loader: glob({ pattern: '**/*.{md,mdx}', base: '{{js .BlogDir}}' }),
+  schema: notionSchema,
+});
+
+const diary = defineCollection({
+  loader: glob({ pattern: '**/*.{md,mdx}', base: '{{js .DiaryDir}}' }),
+  schema: notionSchema,
+});
+
+export const collections = { blog, diary };
+`))
+
+// astroLayoutTemplate is a minimal layout for exported pages
+var astroLayoutTemplate = template.Must(template.New("NotionLayout.astro").Parse(`---
+interface Props {
+  title: string;
+  description?: string;
+  date?: Date;
+  tags?: string[];
+  robots?: string;
+}
+
+const { title, description, date, tags = [], robots } = Astro.props;
+---
+
+<html lang="ja">
+  <head>
+    <meta charset="utf-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1" />
+    <title>{title}</title>
+    {description && <meta name="description" content={description} />}
+    {robots && <meta name="robots" content={robots} />}
+  </head>
+  <body>
+    <article>
+      <h1>{title}</h1>
+      {date && <time datetime={date.toISOString()}>{date.toLocaleDateString()}</time>}
+      {tags.length > 0 && (
+        <ul>
+          {tags.map((tag) => <li>{tag}</li>)}
+        </ul>
+      )}
+      <slot />
+    </article>
+  </body>
+</html>
+`))
+
+// astroEntryPageTemplate renders the entries of a collection at /<collection>/<slug>, the routes expected by -check-links
+var astroEntryPageTemplate = template.Must(template.New("[slug].astro").Parse(`---
+import { getCollection, render } from 'astro:content';
+import NotionLayout from '../../layouts/NotionLayout.astro';
+
+export async function getStaticPaths() {
+  const entries = await getCollection('{{.}}', ({ data }) => !data.draft);
+  return entries.map((entry) => ({ params: { slug: entry.id }, props: { entry } }));
+}
+
+const { entry } = Astro.props;
+const { Content } = await render(entry);
+const { title, description, publishedAt, date, tags, robots } = entry.data;
+---
+
+<NotionLayout title={title} description={description} date={publishedAt ?? date} tags={tags} robots={robots}>
+  <Content />
+</NotionLayout>
+`))
+
+// ScaffoldAstro writes a content collection config, a layout and an entry page for each
+// collection of the configuration of opts into the Astro project at dir, and lists the files
+// it created or kept on w. Existing files are kept unless force is set.
+func ScaffoldAstro(opts Options, dir string, force bool, w io.Writer) error {
+	config, err := readConfig(opts, "all", nil)
+	if err != nil {
+		return err
+	}
+	if err := scaffoldAstro(dir, config, force, w); err != nil {
+		return fmt.Errorf("failed to scaffold Astro project: %w", err)
+	}
+	return nil
+}
+
+// scaffoldAstro writes the files of ScaffoldAstro for config, listing them on w
+func scaffoldAstro(dir string, config runConfig, force bool, w io.Writer) error {
+	blogDir, err := projectRelativePath(dir, config.BlogOutputDir)
+	if err != nil {
+		return err
+	}
+	diaryDir, err := projectRelativePath(dir, config.DiaryOutputDir)
+	if err != nil {
+		return err
+	}
+
+	files := []scaffoldFile{
+		{"src/content/config.ts", astroContentConfigTemplate, map[string]string{"BlogDir": blogDir, "DiaryDir": diaryDir}},
+		{"src/layouts/NotionLayout.astro", astroLayoutTemplate, nil},
+		{"src/pages/blog/[slug].astro", astroEntryPageTemplate, "blog"},
+		{"src/pages/diary/[slug].astro", astroEntryPageTemplate, "diary"},
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.path))
+		if _, err := os.Stat(path); err == nil && !force {
+			fmt.Fprintf(w, "Skipping %s: already exists (use -force to overwrite)\n", path)
+			continue
+		}
+
+		var content strings.Builder
+		if err := file.template.Execute(&content, file.data); err != nil {
+			return fmt.Errorf("failed to render %s: %v", file.path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		fmt.Fprintf(w, "Created %s\n", path)
+	}
+	return nil
+}
+
+// projectRelativePath returns path relative to the project root dir in the "./" form used by Astro loaders
+func projectRelativePath(dir, path string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return "", fmt.Errorf("output directory %s is not inside the project: %v", path, err)
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel, nil
+}
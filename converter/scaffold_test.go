@@ -0,0 +1,58 @@
+package converter
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldAstro(t *testing.T) {
+	dir := t.TempDir()
+	config := runConfig{
+		BlogOutputDir:  filepath.Join(dir, "src", "content", "blog"),
+		DiaryOutputDir: filepath.Join(dir, "..", "diary"),
+	}
+
+	layout := filepath.Join(dir, "src", "layouts", "NotionLayout.astro")
+	if err := os.MkdirAll(filepath.Dir(layout), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(layout, []byte("custom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := scaffoldAstro(dir, config, false, io.Discard); err != nil {
+		t.Fatalf("scaffoldAstro() error = %v", err)
+	}
+
+	contentConfig, err := os.ReadFile(filepath.Join(dir, "src", "content", "config.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"base: './src/content/blog'", "base: '../diary'", "tags: z.array(z.string())"} {
+		if !strings.Contains(string(contentConfig), want) {
+			t.Errorf("config.ts does not contain %q", want)
+		}
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "src", "pages", "diary", "[slug].astro"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), "getCollection('diary'") {
+		t.Errorf("diary page does not read the diary collection:\n%s", page)
+	}
+
+	// Existing files are kept unless forced
+	if data, _ := os.ReadFile(layout); string(data) != "custom" {
+		t.Errorf("existing layout was overwritten")
+	}
+	if err := scaffoldAstro(dir, config, true, io.Discard); err != nil {
+		t.Fatalf("scaffoldAstro() error = %v", err)
+	}
+	if data, _ := os.ReadFile(layout); string(data) == "custom" {
+		t.Errorf("layout was not overwritten with -force")
+	}
+}
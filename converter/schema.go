@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jomei/notionapi"
+)
+
+// SchemaProperty describes a single database property in `schema dump` output
+type SchemaProperty struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Options []string `json:"options,omitempty"`
+}
+
+// DatabaseSchema describes a database in `schema dump` output
+type DatabaseSchema struct {
+	ID         string           `json:"id"`
+	Title      string           `json:"title"`
+	Properties []SchemaProperty `json:"properties"`
+}
+
+// DumpSchema writes the properties of a database to w, as a "table" or as "json". The database
+// is databaseID, or else the database of opts.Type ("blog" by default) in the configuration
+// of opts.
+func DumpSchema(ctx context.Context, opts Options, databaseID, format string, w io.Writer) error {
+	write := writeSchemaTable
+	switch format {
+	case "", "table":
+	case "json":
+		write = writeSchemaJSON
+	default:
+		return configError("invalid format: %s. Must be 'table' or 'json'", format)
+	}
+
+	dbType := orDefault(opts.Type, "blog")
+	config, err := readConfig(opts, dbType, nil)
+	if err != nil {
+		return err
+	}
+	if config.NotionAPIToken == "" {
+		return missingTokenError()
+	}
+	if databaseID == "" {
+		_, additional := config.findDatabase(dbType)
+		if dbType != "blog" && dbType != "diary" && !additional {
+			return configError("invalid database type: %s. Must be 'blog', 'diary' or the name of a database in the config file", dbType)
+		}
+		databaseID = config.databaseConfig(dbType).databaseID()
+	}
+	if databaseID == "" {
+		return configError("no database ID configured for %s; pass -database", dbType)
+	}
+
+	database, err := config.notionClient().Database.Get(ctx, notionapi.DatabaseID(databaseID))
+	if err != nil {
+		return notionError("failed to get database: %w", err)
+	}
+	if err := write(w, buildDatabaseSchema(database)); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
+
+// buildDatabaseSchema converts a database's property configs into a schema sorted by property name
+func buildDatabaseSchema(database *notionapi.Database) DatabaseSchema {
+	schema := DatabaseSchema{
+		ID:    database.ID.String(),
+		Title: DatabaseTitle(database),
+	}
+
+	for name, config := range database.Properties {
+		property := SchemaProperty{
+			Name: name,
+			Type: string(config.GetType()),
+			ID:   string(config.GetID()),
+		}
+
+		// Include option names so select values can be mapped without opening Notion
+		var options []notionapi.Option
+		switch c := config.(type) {
+		case *notionapi.SelectPropertyConfig:
+			options = c.Select.Options
+		case *notionapi.MultiSelectPropertyConfig:
+			options = c.MultiSelect.Options
+		case *notionapi.StatusPropertyConfig:
+			options = c.Status.Options
+		}
+		for _, option := range options {
+			property.Options = append(property.Options, option.Name)
+		}
+
+		schema.Properties = append(schema.Properties, property)
+	}
+
+	sort.Slice(schema.Properties, func(i, j int) bool {
+		return schema.Properties[i].Name < schema.Properties[j].Name
+	})
+	return schema
+}
+
+// writeSchemaJSON writes the schema as indented JSON
+func writeSchemaJSON(w io.Writer, schema DatabaseSchema) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+// writeSchemaTable writes the schema as an aligned text table
+func writeSchemaTable(w io.Writer, schema DatabaseSchema) error {
+	fmt.Fprintf(w, "%s (%s)\n\n", schema.Title, schema.ID)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTYPE\tOPTIONS")
+	for _, property := range schema.Properties {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", property.Name, property.Type, strings.Join(property.Options, ", "))
+	}
+	return tw.Flush()
+}
+
+// ListDatabases returns every database shared with the integration of token
+func ListDatabases(ctx context.Context, token string) ([]*notionapi.Database, error) {
+	client := newNotionClient(token)
+	var databases []*notionapi.Database
+	request := &notionapi.SearchRequest{
+		Filter:   notionapi.SearchFilter{Property: "object", Value: "database"},
+		PageSize: 100,
+	}
+	for {
+		resp, err := client.Search.Do(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range resp.Results {
+			if db, ok := result.(*notionapi.Database); ok {
+				databases = append(databases, db)
+			}
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			return databases, nil
+		}
+		request.StartCursor = resp.NextCursor
+	}
+}
+
+// DatabaseTitle returns the plain-text title of a database
+func DatabaseTitle(db *notionapi.Database) string {
+	var title strings.Builder
+	for _, rt := range db.Title {
+		title.WriteString(rt.PlainText)
+	}
+	if title.Len() == 0 {
+		return "(untitled)"
+	}
+	return title.String()
+}
@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// schemaDatabase is a database with a title, a select and a multi-select property
+func schemaDatabase() *notionapi.Database {
+	return &notionapi.Database{
+		ID:    "blog-db",
+		Title: richText("Blog"),
+		Properties: notionapi.PropertyConfigs{
+			"Title": &notionapi.TitlePropertyConfig{ID: "title", Type: notionapi.PropertyConfigTypeTitle},
+			"Tags": &notionapi.MultiSelectPropertyConfig{ID: "tags", Type: notionapi.PropertyConfigTypeMultiSelect,
+				MultiSelect: notionapi.Select{Options: []notionapi.Option{{Name: "go"}, {Name: "astro"}}}},
+			"Category": &notionapi.SelectPropertyConfig{ID: "cat", Type: notionapi.PropertyConfigTypeSelect,
+				Select: notionapi.Select{Options: []notionapi.Option{{Name: "tech"}}}},
+		},
+	}
+}
+
+func TestBuildDatabaseSchema(t *testing.T) {
+	schema := buildDatabaseSchema(schemaDatabase())
+
+	expected := DatabaseSchema{
+		ID:    "blog-db",
+		Title: "Blog",
+		Properties: []SchemaProperty{
+			{Name: "Category", Type: "select", ID: "cat", Options: []string{"tech"}},
+			{Name: "Tags", Type: "multi_select", ID: "tags", Options: []string{"go", "astro"}},
+			{Name: "Title", Type: "title", ID: "title"},
+		},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("buildDatabaseSchema() = %+v, want %+v", schema, expected)
+	}
+}
+
+func TestWriteSchemaTable(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeSchemaTable(&out, buildDatabaseSchema(schemaDatabase())); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Blog (blog-db)\n\n" +
+		"NAME      TYPE          OPTIONS\n" +
+		"Category  select        tech\n" +
+		"Tags      multi_select  go, astro\n" +
+		"Title     title         \n"
+	if out.String() != expected {
+		t.Errorf("writeSchemaTable() =\n%s\nwant\n%s", out.String(), expected)
+	}
+}
+
+func TestWriteSchemaJSON(t *testing.T) {
+	var out bytes.Buffer
+	schema := buildDatabaseSchema(schemaDatabase())
+	if err := writeSchemaJSON(&out, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded DatabaseSchema
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, schema) {
+		t.Errorf("decoded %+v, want %+v", decoded, schema)
+	}
+	// Properties without options leave out the options key
+	if bytes.Contains(out.Bytes(), []byte(`"options": null`)) {
+		t.Errorf("unexpected null options in\n%s", out.String())
+	}
+}
+
+func TestDumpSchemaInvalidOptions(t *testing.T) {
+	t.Chdir(t.TempDir())
+	config := []byte("blog:\n  databaseId: blog-db\n")
+	tests := []struct {
+		name   string
+		opts   Options
+		format string
+	}{
+		{"format", Options{Token: "secret", ConfigData: config}, "xml"},
+		{"token", Options{ConfigData: config}, "table"},
+		{"type", Options{Type: "news", Token: "secret", ConfigData: config}, "table"},
+		{"database", Options{Type: "diary", Token: "secret", ConfigData: config}, "table"},
+	}
+	for _, tt := range tests {
+		if err := DumpSchema(context.Background(), tt.opts, "", tt.format, io.Discard); ExitCode(err) != exitConfig {
+			t.Errorf("%s: DumpSchema() = %v, want a configuration error", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestInterruptedError(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("received interrupt"))
+	if err := interruptedError(ctx); ExitCode(err) != exitInterrupted || err.Error() != "run interrupted: received interrupt" {
+		t.Errorf("interruptedError() = %v (exit code %d)", err, ExitCode(err))
+	}
+}
+
+func TestExportPageListInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// No page is requested once the run is interrupted
+	config := runConfig{Context: ctx, Report: newRunReport()}
+	err := exportPageList(nil, []string{"page"}, config)
+	if ExitCode(err) != exitInterrupted || !errors.Is(err, context.Canceled) {
+		t.Errorf("exportPageList() = %v, want an interrupted run", err)
+	}
+}
+
+// contextBlockService answers block requests with the error of their context
+type contextBlockService struct {
+	notionapi.BlockService
+}
+
+func (contextBlockService) GetChildren(ctx context.Context, id notionapi.BlockID, _ *notionapi.Pagination) (*notionapi.GetChildrenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &notionapi.GetChildrenResponse{}, nil
+}
+
+func TestProcessPageInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A page whose requests were canceled is neither written nor reported as failed
+	client := &notionapi.Client{Block: contextBlockService{}}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: t.TempDir(), ContentErrorPolicy: "placeholder", Report: newRunReport(), Context: ctx}
+	err := processPage(client, *titledPage("page", "Title"), config)
+	if ExitCode(err) != exitInterrupted || !errors.Is(err, context.Canceled) {
+		t.Errorf("processPage() = %v, want an interrupted run", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(config.BlogOutputDir, "*.md")); len(files) != 0 {
+		t.Errorf("expected no files, got %v", files)
+	}
+	if err := config.Report.Err(); err != nil {
+		t.Errorf("Err() = %v, want no failed pages", err)
+	}
+}
+
+// heldNotionAPI answers like fakeNotionAPI, but holds the block requests of the page: arrived
+// is closed when the first one is sent, and each answers once release is closed or fails with
+// the error of its context
+type heldNotionAPI struct {
+	*fakeNotionAPI
+	arrived chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (api *heldNotionAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.HasSuffix(req.URL.Path, "/children") {
+		api.once.Do(func() { close(api.arrived) })
+		select {
+		case <-api.release:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return api.fakeNotionAPI.RoundTrip(req)
+}
+
+// convertInterrupted exports the page of api and cancels the run once its blocks are requested
+func convertInterrupted(api *heldNotionAPI) error {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	go func() {
+		<-api.arrived
+		cancel(errors.New("received interrupt"))
+		// The page is left to finish, unless its requests are canceled before
+		time.Sleep(50 * time.Millisecond)
+		close(api.release)
+	}()
+	return Convert(ctx, Options{
+		Page:       "page",
+		Token:      "secret",
+		ConfigData: []byte("blog:\n  databaseId: blog-db\n  outputDir: content\n"),
+		Env:        map[string]string{"MANIFEST_FILE": "manifest.json"},
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Transport:  api,
+	})
+}
+
+func TestConvertInterruptedFinishesPages(t *testing.T) {
+	t.Chdir(t.TempDir())
+	api := &heldNotionAPI{fakeNotionAPI: newFakeNotionAPI(1), arrived: make(chan struct{}), release: make(chan struct{})}
+	err := convertInterrupted(api)
+	if ExitCode(err) != exitInterrupted {
+		t.Fatalf("Convert() = %v, want an interrupted run", err)
+	}
+
+	// The page in progress is written and recorded for the next run
+	if data, err := os.ReadFile(filepath.Join("content", "Hello.md")); err != nil || !strings.Contains(string(data), "Body") {
+		t.Errorf("page file = %q, %v", data, err)
+	}
+	if data, err := os.ReadFile("manifest.json"); err != nil || !strings.Contains(string(data), "Hello.md") {
+		t.Errorf("manifest = %q, %v", data, err)
+	}
+}
+
+func TestConvertInterruptedGracePeriod(t *testing.T) {
+	t.Chdir(t.TempDir())
+	gracePeriod := interruptGracePeriod
+	interruptGracePeriod = 10 * time.Millisecond
+	t.Cleanup(func() { interruptGracePeriod = gracePeriod })
+
+	// The requests of a page that does not finish in time are canceled, leaving its file as it is
+	api := &heldNotionAPI{fakeNotionAPI: newFakeNotionAPI(1), arrived: make(chan struct{}), release: make(chan struct{})}
+	err := convertInterrupted(api)
+	if ExitCode(err) != exitInterrupted || !strings.Contains(err.Error(), "received interrupt") {
+		t.Fatalf("Convert() = %v, want an interrupted run", err)
+	}
+	if _, err := os.Stat(filepath.Join("content", "Hello.md")); !os.IsNotExist(err) {
+		t.Errorf("expected no page file, got %v", err)
+	}
+}
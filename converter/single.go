@@ -0,0 +1,141 @@
+package converter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// stdout returns the writer receiving the converted page when a single page is exported with
+// -output -
+func (c runConfig) stdout() io.Writer {
+	if c.Stdout == nil {
+		return os.Stdout
+	}
+	return c.Stdout
+}
+
+// processSinglePage exports only config.SinglePageID
+func processSinglePage(config runConfig) error {
+	config.logInfo("Processing single page: %s", config.SinglePageID)
+
+	client := config.notionClient()
+	page, err := client.Page.Get(config.context(), notionapi.PageID(config.SinglePageID))
+	if err != nil {
+		return notionError("failed to get page: %w", err)
+	}
+
+	pageType := singlePageType(*page, config)
+	pageConfig := config.databaseConfig(pageType)
+	config.logInfo("Exporting page as %s", pageType)
+	return processPage(client, *page, pageConfig)
+}
+
+// processPageList exports the pages listed in config.PagesFile
+func processPageList(config runConfig) error {
+	ids, err := readPageIDs(config.PagesFile)
+	if err != nil {
+		return err
+	}
+	config.logInfo("Exporting %d pages from %s", len(ids), config.PagesFile)
+	return exportPageList(config.notionClient(), ids, config)
+}
+
+// exportPageList exports each page of ids. A page that cannot be retrieved is reported and skipped
+// so one deleted or unshared page does not stop the batch.
+func exportPageList(client *notionapi.Client, ids []string, config runConfig) error {
+	config.Progress = newProgress(config.PagesFile, len(ids), config.ProgressInterval, config.Logger)
+	for i, id := range ids {
+		if err := config.stopErr(); err != nil {
+			return err
+		}
+		config.logDebug("Processing page %d of %d: %s", i+1, len(ids), id)
+		page, err := client.Page.Get(config.context(), notionapi.PageID(id))
+		if err != nil {
+			config.logWarn("Failed to get page %s: %v", id, err)
+			config.Report.AddContentError(id, err, "skipped")
+			config.Progress.PageDone()
+			continue
+		}
+
+		pageConfig := config.databaseConfig(singlePageType(*page, config))
+		if err := processPage(client, *page, pageConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPageIDs reads the page IDs or page URLs listed one per line in path ("-" reads stdin).
+// Blank lines and lines starting with # are ignored, and duplicates are exported once.
+func readPageIDs(path string) ([]string, error) {
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open pages file: %v", err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(input)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id := parsePageID(line)
+		if id == "" {
+			return nil, fmt.Errorf("%s:%d: not a Notion page ID or URL: %s", path, lineNumber, line)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pages file: %v", err)
+	}
+	return ids, nil
+}
+
+// parsePageID returns the dashless page ID of a page ID or Notion page URL, or "" if there is none
+func parsePageID(value string) string {
+	if parsed, err := url.Parse(value); err == nil && parsed.Host != "" {
+		// The ID ends the path of page URLs; queries such as ?v= or ?p= are ignored
+		value = strings.TrimSuffix(parsed.Path, "/")
+	}
+	return strings.ReplaceAll(notionPageIDPattern.FindString(strings.ToLower(value)), "-", "")
+}
+
+// singlePageType returns the type or additional database a single page is exported as. With
+// -type all it is chosen from the database the page belongs to, defaulting to blog.
+func singlePageType(page notionapi.Page, config runConfig) string {
+	if config.DatabaseType != "all" {
+		return config.DatabaseType
+	}
+	if page.Parent.Type == notionapi.ParentTypeDatabaseID {
+		if sameNotionID(string(page.Parent.DatabaseID), config.NotionDiaryDatabaseID) {
+			return "diary"
+		}
+		for _, database := range config.Databases {
+			if sameNotionID(string(page.Parent.DatabaseID), database.DatabaseID) {
+				return database.Name
+			}
+		}
+	}
+	return "blog"
+}
+
+// sameNotionID compares Notion IDs with or without dashes
+func sameNotionID(a, b string) bool {
+	return a != "" && strings.ReplaceAll(a, "-", "") == strings.ReplaceAll(b, "-", "")
+}
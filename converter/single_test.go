@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestProcessPageToStdout(t *testing.T) {
+	var result bytes.Buffer
+
+	client := &notionapi.Client{
+		Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+			"page": {paragraphBlock("Hello")},
+		}},
+	}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", OutputPath: "-", BlogOutputDir: t.TempDir(), Stdout: &result}
+	processPage(client, *titledPage("page", "Title"), config)
+
+	if got := result.String(); !strings.HasPrefix(got, "---\n") || !strings.Contains(got, "title: Title\n") || !strings.Contains(got, "Hello") {
+		t.Errorf("unexpected stdout output:\n%s", got)
+	}
+}
+
+func TestSinglePageType(t *testing.T) {
+	diaryPage := notionapi.Page{Parent: notionapi.Parent{Type: notionapi.ParentTypeDatabaseID, DatabaseID: "1234abcd-0000"}}
+	tests := []struct {
+		name     string
+		page     notionapi.Page
+		dbType   string
+		expected string
+	}{
+		{"Explicit type", diaryPage, "blog", "blog"},
+		{"Diary database page", diaryPage, "all", "diary"},
+		{"Other page", notionapi.Page{}, "all", "blog"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := runConfig{DatabaseType: tt.dbType, NotionDiaryDatabaseID: "1234abcd0000"}
+			if got := singlePageType(tt.page, config); got != tt.expected {
+				t.Errorf("singlePageType() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadPageIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages.txt")
+	list := `# changed pages
+1234567890abcdef1234567890abcdef
+
+https://www.notion.so/workspace/Hello-World-abcdef12345678901234567890abcdef?pvs=4
+12345678-90ab-cdef-1234-567890abcdef
+`
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := readPageIDs(path)
+	if err != nil {
+		t.Fatalf("readPageIDs() error = %v", err)
+	}
+	expected := []string{"1234567890abcdef1234567890abcdef", "abcdef12345678901234567890abcdef"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("readPageIDs() = %v, want %v", ids, expected)
+	}
+
+	if err := os.WriteFile(path, []byte("not-a-page\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPageIDs(path); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("readPageIDs() error = %v, want the line of the invalid entry", err)
+	}
+}
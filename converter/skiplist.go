@@ -0,0 +1,42 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jomei/notionapi"
+)
+
+// parseSkipPages returns the dashless IDs of the page IDs or page URLs listed in values,
+// separated by commas or whitespace
+func parseSkipPages(values ...string) ([]string, error) {
+	var ids []string
+	for _, value := range values {
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			id := parsePageID(field)
+			if id == "" {
+				return nil, fmt.Errorf("not a Notion page ID or URL: %s", field)
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// excludedPage reports why page must never be exported: it is on the skip list or its
+// no-export checkbox is checked. Exclusion applies whatever the query and filters select.
+func excludedPage(page notionapi.Page, config runConfig) (string, bool) {
+	if slices.Contains(config.SkipPages, strings.ReplaceAll(page.ID.String(), "-", "")) {
+		return "on the skip list", true
+	}
+	if name := config.properties().noExportProperty(); name != "" {
+		if cp, ok := page.Properties[name].(*notionapi.CheckboxProperty); ok && cp.Checkbox {
+			return fmt.Sprintf("%s is checked", name), true
+		}
+	}
+	return "", false
+}
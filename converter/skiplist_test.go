@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestParseSkipPages(t *testing.T) {
+	ids, err := parseSkipPages(
+		"1234567890abcdef1234567890abcdef",
+		"https://www.notion.so/Secret-12345678-90ab-cdef-1234-567890abcdef, fedcba0987654321fedcba0987654321",
+		"",
+	)
+	if err != nil {
+		t.Fatalf("parseSkipPages() error = %v", err)
+	}
+	expected := []string{"1234567890abcdef1234567890abcdef", "fedcba0987654321fedcba0987654321"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("parseSkipPages() = %v, want %v", ids, expected)
+	}
+
+	if _, err := parseSkipPages("not-a-page"); err == nil {
+		t.Error("expected an error for a value that is not a page ID")
+	}
+}
+
+func TestExcludedPageSkipped(t *testing.T) {
+	dir := t.TempDir()
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"12345678-90ab-cdef-1234-567890abcdef": {paragraphBlock("Secret")},
+		"checked":                              {paragraphBlock("Private")},
+		"public":                               {paragraphBlock("Hello")},
+	}}}
+	config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, SkipPages: []string{"1234567890abcdef1234567890abcdef"}}
+
+	checked := titledPage("checked", "Private")
+	checked.Properties["no-export"] = &notionapi.CheckboxProperty{Checkbox: true}
+	for _, page := range []*notionapi.Page{titledPage("12345678-90ab-cdef-1234-567890abcdef", "Secret"), checked, titledPage("public", "Hello")} {
+		processPage(client, *page, config)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "Hello.md" {
+		t.Errorf("expected only the public page to be written, got %v", files)
+	}
+
+	config.BlogProperties.NoExport = noProperty
+	if _, ok := excludedPage(*checked, config); ok {
+		t.Error("expected the no-export checkbox to be ignored when disabled")
+	}
+}
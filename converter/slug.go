@@ -0,0 +1,211 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/jomei/notionapi"
+)
+
+// SlugStrategy decides the slug of a page: slug in frontmatter, {slug} in file names and the
+// file name with EMIT_SLUG. A strategy is chosen per database by name with slugStrategy in
+// the config file. Programs embedding the converter can add named strategies with
+// RegisterSlugStrategy, or set Options.SlugStrategy to use one for every database.
+type SlugStrategy interface {
+	Slug(page notionapi.Page, title string, props PropertyMapping) string
+}
+
+// SlugStrategyFunc adapts a function to a SlugStrategy
+type SlugStrategyFunc func(page notionapi.Page, title string, props PropertyMapping) string
+
+// Slug calls f
+func (f SlugStrategyFunc) Slug(page notionapi.Page, title string, props PropertyMapping) string {
+	return f(page, title, props)
+}
+
+// slugStrategies are the strategies slugStrategy selects by name
+var slugStrategies = struct {
+	sync.RWMutex
+	byName map[string]SlugStrategy
+}{byName: map[string]SlugStrategy{
+	"property":       SlugStrategyFunc(pageSlug),
+	"transliterated": SlugStrategyFunc(transliteratedSlug),
+	"title":          SlugStrategyFunc(titleSlug),
+	"id":             SlugStrategyFunc(idSlug),
+}}
+
+// RegisterSlugStrategy makes strategy available to slugStrategy in the config file as name,
+// replacing the strategy registered before under name. Register strategies before Convert
+// reads a config file using them.
+func RegisterSlugStrategy(name string, strategy SlugStrategy) {
+	slugStrategies.Lock()
+	defer slugStrategies.Unlock()
+	slugStrategies.byName[name] = strategy
+}
+
+// lookupSlugStrategy returns the strategy registered as name
+func lookupSlugStrategy(name string) (SlugStrategy, bool) {
+	slugStrategies.RLock()
+	defer slugStrategies.RUnlock()
+	strategy, ok := slugStrategies.byName[name]
+	return strategy, ok
+}
+
+// defaultSlugStrategy is used when a database sets no slugStrategy
+const defaultSlugStrategy = "property"
+
+// slugStrategy returns the slug strategy of the database being processed
+func (c runConfig) slugStrategy() SlugStrategy {
+	if c.SlugStrategy != nil {
+		return c.SlugStrategy
+	}
+	name := c.BlogSlugStrategy
+	if c.DatabaseType == "diary" {
+		name = c.DiarySlugStrategy
+	}
+	if strategy, ok := lookupSlugStrategy(orDefault(name, defaultSlugStrategy)); ok {
+		return strategy
+	}
+	strategy, _ := lookupSlugStrategy(defaultSlugStrategy)
+	return strategy
+}
+
+// validateSlugStrategy reports a slug strategy name that is not registered
+func validateSlugStrategy(name string) error {
+	if _, ok := lookupSlugStrategy(name); name == "" || ok {
+		return nil
+	}
+	slugStrategies.RLock()
+	var names []string
+	for name := range slugStrategies.byName {
+		names = append(names, name)
+	}
+	slugStrategies.RUnlock()
+	sort.Strings(names)
+	return fmt.Errorf("unknown slugStrategy %q; use %s", name, strings.Join(names, ", "))
+}
+
+// kanaRomaji is the Hepburn romanization of the hiragana syllables; katakana are looked up
+// as their hiragana counterparts
+var kanaRomaji = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n", 'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o", 'ゎ': "wa",
+}
+
+// latinFolding spells accented Latin letters without their accents
+var latinFolding = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+// slugify turns a title into a URL slug of lowercase ASCII letters, digits and hyphens.
+// Kana are romanized and accents dropped; other scripts such as kanji and emoji cannot be
+// spelled in ASCII and separate words like punctuation does.
+func slugify(title string) string {
+	var words strings.Builder
+	runes := []rune(strings.ToLower(title))
+	for i := 0; i < len(runes); i++ {
+		r := kanaToHiragana(runes[i])
+		if r >= '！' && r <= '～' {
+			// Fullwidth forms of ASCII characters
+			r = unicode.ToLower(r - 0xFEE0)
+		}
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			words.WriteRune(r)
+		case latinFolding[r] != "":
+			words.WriteString(latinFolding[r])
+		case r == 'っ' && i+1 < len(runes):
+			// The small tsu doubles the consonant that follows
+			if next := kanaRomaji[kanaToHiragana(runes[i+1])]; next != "" && !strings.ContainsRune("aiueon", rune(next[0])) {
+				words.WriteByte(next[0])
+			}
+		case r == 'ー':
+			// Long vowels are written as a single vowel
+		case kanaRomaji[r] != "":
+			syllable := kanaRomaji[r]
+			if i+1 < len(runes) && strings.HasSuffix(syllable, "i") {
+				if small := kanaToHiragana(runes[i+1]); small == 'ゃ' || small == 'ゅ' || small == 'ょ' {
+					syllable = youon(syllable, small)
+					i++
+				}
+			}
+			words.WriteString(syllable)
+		default:
+			words.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(words.String()), "-")
+}
+
+// kanaToHiragana returns the hiragana of a katakana, and any other rune as it is
+func kanaToHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
+
+// youon combines an i-row syllable with a small ya, yu or yo: き+ゃ is kya, し+ゃ is sha
+func youon(syllable string, small rune) string {
+	vowel := map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}[small]
+	stem := strings.TrimSuffix(syllable, "i")
+	if stem == "sh" || stem == "ch" || stem == "j" {
+		return stem + vowel
+	}
+	return stem + "y" + vowel
+}
+
+// pageSlug returns the slug of a page: the slug property when it has one, otherwise the
+// slugified title, and the page ID if neither gives any letters
+func pageSlug(page notionapi.Page, title string, props PropertyMapping) string {
+	if prop, ok := lookupProperty(page.Properties, props.Slug, "slug", "Slug"); ok {
+		if slug := slugify(propertyText(prop)); slug != "" {
+			return slug
+		}
+	}
+	return transliteratedSlug(page, title, props)
+}
+
+// transliteratedSlug returns the slugified title, ignoring the slug property, or the page ID
+// if the title gives no letters
+func transliteratedSlug(page notionapi.Page, title string, props PropertyMapping) string {
+	if slug := slugify(title); slug != "" {
+		return slug
+	}
+	return idSlug(page, title, props)
+}
+
+// titleSlug returns the title slugified like Astro does, keeping kanji and other letters
+// outside ASCII, or the page ID if the title has no letters
+func titleSlug(page notionapi.Page, title string, props PropertyMapping) string {
+	if slug := strings.Trim(astroSlug(strings.TrimSpace(title)), "-"); slug != "" {
+		return slug
+	}
+	return idSlug(page, title, props)
+}
+
+// idSlug returns the page ID without hyphens
+func idSlug(page notionapi.Page, _ string, _ PropertyMapping) string {
+	return strings.ReplaceAll(page.ID.String(), "-", "")
+}
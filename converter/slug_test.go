@@ -0,0 +1,90 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title, want string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"Café Crème Brûlée", "cafe-creme-brulee"},
+		{"すしとラーメン", "sushitoramen"},
+		{"きょうのニュース", "kyounonyusu"},
+		{"ちょっとカップ", "chottokappu"},
+		{"Ｇｏ言語入門 2024", "go-2024"},
+		{"🎉 Release notes", "release-notes"},
+		{"東京旅行", ""},
+	}
+	for _, tt := range tests {
+		if got := slugify(tt.title); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestPageSlug(t *testing.T) {
+	page := notionapi.Page{ID: "1234-abcd", Properties: notionapi.Properties{}}
+	if got := pageSlug(page, "東京旅行", PropertyMapping{}); got != "1234abcd" {
+		t.Errorf("pageSlug() without letters = %q, want the page ID", got)
+	}
+	if got := pageSlug(page, "Tokyo Trip", PropertyMapping{}); got != "tokyo-trip" {
+		t.Errorf("pageSlug() = %q, want the slugified title", got)
+	}
+
+	page.Properties["URL"] = &notionapi.RichTextProperty{RichText: richText("Trip to Tokyo")}
+	if got := pageSlug(page, "東京旅行", PropertyMapping{Slug: "URL"}); got != "trip-to-tokyo" {
+		t.Errorf("pageSlug() = %q, want the slug property", got)
+	}
+}
+
+func TestSlugStrategies(t *testing.T) {
+	page := notionapi.Page{ID: "1234-abcd", Properties: notionapi.Properties{
+		"slug": &notionapi.RichTextProperty{RichText: richText("tokyo")},
+	}}
+	tests := []struct {
+		strategy, title, want string
+	}{
+		{"", "東京 旅行", "tokyo"},
+		{"property", "東京 旅行", "tokyo"},
+		{"transliterated", "すし Bar", "sushi-bar"},
+		{"transliterated", "東京旅行", "1234abcd"},
+		{"title", "東京 旅行!", "東京-旅行"},
+		{"title", "🎉", "1234abcd"},
+		{"id", "Tokyo", "1234abcd"},
+	}
+
+	for _, tt := range tests {
+		config := runConfig{DatabaseType: "diary", DiarySlugStrategy: tt.strategy}
+		if got := config.slugStrategy().Slug(page, tt.title, PropertyMapping{}); got != tt.want {
+			t.Errorf("%q strategy: Slug(%q) = %q, want %q", tt.strategy, tt.title, got, tt.want)
+		}
+	}
+
+	// A strategy set by code replaces the named ones
+	config := runConfig{BlogSlugStrategy: "id", SlugStrategy: SlugStrategyFunc(func(page notionapi.Page, title string, props PropertyMapping) string {
+		return "custom-" + slugify(title)
+	})}
+	if got := config.slugStrategy().Slug(page, "Tokyo", PropertyMapping{}); got != "custom-tokyo" {
+		t.Errorf("Slug() = %q, want the custom strategy", got)
+	}
+
+	if err := validateSlugStrategy("uuid"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+
+	// A registered strategy is selected by name like the built-in ones
+	RegisterSlugStrategy("test-prefixed", SlugStrategyFunc(func(page notionapi.Page, title string, props PropertyMapping) string {
+		return "post-" + slugify(title)
+	}))
+	if err := validateSlugStrategy("test-prefixed"); err != nil {
+		t.Errorf("validateSlugStrategy() = %v for a registered strategy", err)
+	}
+	config = runConfig{BlogSlugStrategy: "test-prefixed"}
+	if got := config.slugStrategy().Slug(page, "Tokyo", PropertyMapping{}); got != "post-tokyo" {
+		t.Errorf("Slug() = %q, want the registered strategy", got)
+	}
+}
@@ -0,0 +1,48 @@
+package converter
+
+import (
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// StarlightSidebar holds the Starlight sidebar frontmatter of a page
+type StarlightSidebar struct {
+	Label string `yaml:"label,omitempty" json:"label,omitempty"`
+	Order int    `yaml:"order,omitempty" json:"order,omitempty"`
+}
+
+// defaultCalloutStyle returns the callout style used when CALLOUT_STYLE is not set
+func defaultCalloutStyle(outputProfile string) string {
+	if outputProfile == "starlight" {
+		return "aside"
+	}
+	return "blockquote"
+}
+
+// extractSidebar builds the Starlight sidebar frontmatter from the label/order properties.
+// defaultOrder (the position among sibling pages, 0 if unknown) is used when there is no order property.
+// An order stored as text is converted to a number; other text is reported as an error.
+func extractSidebar(page notionapi.Page, title string, props PropertyMapping, defaultOrder int) (*StarlightSidebar, error) {
+	sidebar := StarlightSidebar{Order: defaultOrder}
+
+	if labelProp, ok := lookupProperty(page.Properties, props.Label, "label", "Label", "sidebar_label"); ok {
+		if rtp, ok := labelProp.(*notionapi.RichTextProperty); ok && len(rtp.RichText) > 0 {
+			sidebar.Label = strings.TrimSpace(rtp.RichText[0].PlainText)
+		}
+	}
+	if name, orderProp, ok := lookupNamedProperty(page.Properties, props.Order, "order", "Order", "sidebar_order"); ok {
+		order, ok, err := coerceNumber(title, name, orderProp)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			sidebar.Order = int(order)
+		}
+	}
+
+	if sidebar.Label == "" && sidebar.Order == 0 {
+		return nil, nil
+	}
+	return &sidebar, nil
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"notion-to-astro-go/internal/mdrender"
+	"notion-to-astro-go/internal/writer"
+)
+
+// PostStats holds computed statistics written to the sidecar JSON of a post
+type PostStats struct {
+	WordCount     int           `json:"wordCount"`
+	ImageCount    int           `json:"imageCount"`
+	OutboundLinks []string      `json:"outboundLinks"`
+	Headings      []PostHeading `json:"headings"`
+}
+
+// PostHeading is a heading found in a post
+type PostHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+var (
+	statsImagePattern   = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	statsLinkPattern    = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+	statsHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*$`)
+)
+
+// computePostStats computes statistics for a markdown body.
+// Words are whitespace-separated tokens, except that each CJK character counts as one word.
+func computePostStats(body string) PostStats {
+	stats := PostStats{
+		OutboundLinks: []string{},
+		Headings:      []PostHeading{},
+	}
+
+	stats.ImageCount = len(statsImagePattern.FindAllString(body, -1))
+	withoutImages := statsImagePattern.ReplaceAllString(body, "")
+
+	seen := map[string]bool{}
+	for _, match := range statsLinkPattern.FindAllStringSubmatch(withoutImages, -1) {
+		url := match[2]
+		if (strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")) && !seen[url] {
+			seen[url] = true
+			stats.OutboundLinks = append(stats.OutboundLinks, url)
+		}
+	}
+
+	for _, line := range strings.Split(withoutImages, "\n") {
+		if match := statsHeadingPattern.FindStringSubmatch(line); match != nil {
+			stats.Headings = append(stats.Headings, PostHeading{
+				Level: len(match[1]),
+				Text:  mdrender.LinksToPlainText(match[2]),
+			})
+		}
+	}
+
+	stats.WordCount = countWords(mdrender.LinksToPlainText(withoutImages))
+	return stats
+}
+
+// countWords counts whitespace-separated words, counting each CJK character as a word
+func countWords(text string) int {
+	count := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			count++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				count++
+				inWord = true
+			}
+		case unicode.IsSpace(r):
+			inWord = false
+		}
+	}
+	return count
+}
+
+// statsSidecarPath returns the sidecar JSON path for a markdown file
+func statsSidecarPath(markdownPath string) string {
+	return strings.TrimSuffix(markdownPath, filepath.Ext(markdownPath)) + ".stats.json"
+}
+
+// writeStatsSidecar writes the stats of body next to the markdown file and returns the sidecar path
+func writeStatsSidecar(markdownPath, body string, workspace *writer.Workspace) (string, error) {
+	data, err := json.MarshalIndent(computePostStats(body), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode stats: %v", err)
+	}
+	path := statsSidecarPath(markdownPath)
+	if err := workspace.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write stats %s: %v", path, err)
+	}
+	return path, nil
+}
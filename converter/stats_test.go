@@ -0,0 +1,27 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputePostStats(t *testing.T) {
+	body := "# Title  \n\nHello [world](https://example.com) and [again](https://example.com)  \n\n![Image](/images/a.jpg)  \n\n## 日本語の見出し  \n\nこんにちは世界  \n[local](/blog/other)"
+
+	stats := computePostStats(body)
+
+	if stats.ImageCount != 1 {
+		t.Errorf("ImageCount = %d, want 1", stats.ImageCount)
+	}
+	if want := []string{"https://example.com"}; !reflect.DeepEqual(stats.OutboundLinks, want) {
+		t.Errorf("OutboundLinks = %v, want %v", stats.OutboundLinks, want)
+	}
+	wantHeadings := []PostHeading{{Level: 1, Text: "Title"}, {Level: 2, Text: "日本語の見出し"}}
+	if !reflect.DeepEqual(stats.Headings, wantHeadings) {
+		t.Errorf("Headings = %v, want %v", stats.Headings, wantHeadings)
+	}
+	// Title, Hello, world, and, again, local + 7 heading characters + 7 body characters
+	if stats.WordCount != 20 {
+		t.Errorf("WordCount = %d, want 20", stats.WordCount)
+	}
+}
@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// maxSummaryItems caps the listed items per section; Notion accepts at most 100 blocks per request
+const maxSummaryItems = 20
+
+// maxRichTextLength is the longest text Notion accepts in a single rich text object
+const maxRichTextLength = 2000
+
+// postRunSummary writes the run summary into NOTION_SUMMARY_PAGE_ID (appended at the end of the
+// page) and NOTION_SUMMARY_DATABASE_ID (as a new entry per run), so editors see the sync status in Notion
+func postRunSummary(client *notionapi.Client, config runConfig, finished time.Time) error {
+	title := "Sync " + finished.Format("2006-01-02 15:04")
+	blocks := summaryBlocks(config.Report)
+
+	if config.SummaryPageID != "" {
+		children := append([]notionapi.Block{summaryHeading(title)}, blocks...)
+		request := &notionapi.AppendBlockChildrenRequest{Children: children}
+		if _, err := client.Block.AppendChildren(config.context(), notionapi.BlockID(config.SummaryPageID), request); err != nil {
+			return fmt.Errorf("failed to append run summary to page %s: %w", config.SummaryPageID, err)
+		}
+	}
+
+	if config.SummaryDatabaseID != "" {
+		database, err := client.Database.Get(config.context(), notionapi.DatabaseID(config.SummaryDatabaseID))
+		if err != nil {
+			return fmt.Errorf("failed to get summary database: %w", err)
+		}
+		titleProperty := ""
+		for name, property := range database.Properties {
+			if property.GetType() == notionapi.PropertyConfigTypeTitle {
+				titleProperty = name
+			}
+		}
+		if titleProperty == "" {
+			return fmt.Errorf("summary database %s has no title property", config.SummaryDatabaseID)
+		}
+		request := &notionapi.PageCreateRequest{
+			Parent: notionapi.Parent{Type: notionapi.ParentTypeDatabaseID, DatabaseID: notionapi.DatabaseID(config.SummaryDatabaseID)},
+			Properties: notionapi.Properties{
+				titleProperty: notionapi.TitleProperty{Type: notionapi.PropertyTypeTitle, Title: summaryText(title)},
+			},
+			Children: blocks,
+		}
+		if _, err := client.Page.Create(config.context(), request); err != nil {
+			return fmt.Errorf("failed to create run summary in database %s: %v", config.SummaryDatabaseID, err)
+		}
+	}
+	return nil
+}
+
+// summaryBlocks converts the run summary into a paragraph per section followed by its items,
+// listing the exported pages first
+func summaryBlocks(report *RunReport) []notionapi.Block {
+	if report == nil {
+		return nil
+	}
+	sections := report.sections()
+	sections[0].items = report.ExportedPages()
+
+	var blocks []notionapi.Block
+	for _, section := range sections {
+		blocks = append(blocks, &notionapi.ParagraphBlock{
+			BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeParagraph},
+			Paragraph:  notionapi.Paragraph{RichText: summaryText(section.title)},
+		})
+		items := section.items
+		if len(items) > maxSummaryItems {
+			items = append(items[:maxSummaryItems:maxSummaryItems], fmt.Sprintf("... and %d more", len(section.items)-maxSummaryItems))
+		}
+		for _, item := range items {
+			blocks = append(blocks, &notionapi.BulletedListItemBlock{
+				BasicBlock:       notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeBulletedListItem},
+				BulletedListItem: notionapi.ListItem{RichText: summaryText(item)},
+			})
+		}
+	}
+	return blocks
+}
+
+// summaryHeading is the heading that starts each run summary appended to a page
+func summaryHeading(title string) notionapi.Block {
+	return &notionapi.Heading3Block{
+		BasicBlock: notionapi.BasicBlock{Object: notionapi.ObjectTypeBlock, Type: notionapi.BlockTypeHeading3},
+		Heading3:   notionapi.Heading{RichText: summaryText(title)},
+	}
+}
+
+// summaryText returns text as rich text, shortened to the length Notion accepts
+func summaryText(text string) []notionapi.RichText {
+	if runes := []rune(text); len(runes) > maxRichTextLength {
+		text = string(runes[:maxRichTextLength-1]) + "…"
+	}
+	return []notionapi.RichText{{Type: notionapi.ObjectTypeText, Text: &notionapi.Text{Content: text}}}
+}
@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// appendingBlockService records the blocks appended to each block
+type appendingBlockService struct {
+	notionapi.BlockService
+	appended map[notionapi.BlockID][]notionapi.Block
+}
+
+func (f *appendingBlockService) AppendChildren(_ context.Context, id notionapi.BlockID, request *notionapi.AppendBlockChildrenRequest) (*notionapi.AppendBlockChildrenResponse, error) {
+	f.appended[id] = append(f.appended[id], request.Children...)
+	return &notionapi.AppendBlockChildrenResponse{}, nil
+}
+
+// summaryDatabaseService returns a database whose title property is "Run"
+type summaryDatabaseService struct {
+	notionapi.DatabaseService
+}
+
+func (summaryDatabaseService) Get(_ context.Context, id notionapi.DatabaseID) (*notionapi.Database, error) {
+	return &notionapi.Database{Properties: notionapi.PropertyConfigs{
+		"Run":    &notionapi.TitlePropertyConfig{Type: notionapi.PropertyConfigTypeTitle},
+		"Status": &notionapi.SelectPropertyConfig{Type: notionapi.PropertyConfigTypeSelect},
+	}}, nil
+}
+
+// creatingPageService records created pages
+type creatingPageService struct {
+	notionapi.PageService
+	created []*notionapi.PageCreateRequest
+}
+
+func (f *creatingPageService) Create(_ context.Context, request *notionapi.PageCreateRequest) (*notionapi.Page, error) {
+	f.created = append(f.created, request)
+	return &notionapi.Page{}, nil
+}
+
+func blockText(block notionapi.Block) string {
+	switch b := block.(type) {
+	case *notionapi.Heading3Block:
+		return b.Heading3.RichText[0].Text.Content
+	case *notionapi.ParagraphBlock:
+		return b.Paragraph.RichText[0].Text.Content
+	case *notionapi.BulletedListItemBlock:
+		return "- " + b.BulletedListItem.RichText[0].Text.Content
+	}
+	return fmt.Sprintf("%T", block)
+}
+
+func TestPostRunSummary(t *testing.T) {
+	report := newRunReport()
+	report.AddExportedPage("content/blog/hello.md")
+	report.AddContentError("page-1", errors.New("not found"), "skipped")
+
+	blocks := &appendingBlockService{appended: map[notionapi.BlockID][]notionapi.Block{}}
+	pages := &creatingPageService{}
+	client := &notionapi.Client{Block: blocks, Database: summaryDatabaseService{}, Page: pages}
+	config := runConfig{SummaryPageID: "status", SummaryDatabaseID: "runs", Report: report}
+
+	finished := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	if err := postRunSummary(client, config, finished); err != nil {
+		t.Fatalf("postRunSummary() error = %v", err)
+	}
+
+	var texts []string
+	for _, block := range blocks.appended["status"] {
+		texts = append(texts, blockText(block))
+	}
+	expected := []string{
+		"Sync 2024-05-01 09:30",
+		"Exported 1 pages in 0s",
+		"- content/blog/hello.md",
+		"Failed to retrieve content of 1 pages:",
+		"- page-1 (skipped): not found",
+	}
+	if fmt.Sprint(texts) != fmt.Sprint(expected) {
+		t.Errorf("appended blocks = %q, want %q", texts, expected)
+	}
+
+	if len(pages.created) != 1 {
+		t.Fatalf("created %d summary pages, want 1", len(pages.created))
+	}
+	created := pages.created[0]
+	title, ok := created.Properties["Run"].(notionapi.TitleProperty)
+	if !ok || title.Title[0].Text.Content != "Sync 2024-05-01 09:30" {
+		t.Errorf("summary page properties = %#v", created.Properties)
+	}
+	if created.Parent.DatabaseID != "runs" || len(created.Children) != len(expected)-1 {
+		t.Errorf("summary page parent = %v with %d blocks", created.Parent, len(created.Children))
+	}
+}
+
+func TestSummaryBlocksLimitItems(t *testing.T) {
+	report := newRunReport()
+	for i := 0; i < maxSummaryItems+5; i++ {
+		report.AddExportedPage(fmt.Sprintf("content/blog/%d.md", i))
+	}
+
+	blocks := summaryBlocks(report)
+	if len(blocks) != maxSummaryItems+2 {
+		t.Fatalf("got %d blocks, want %d", len(blocks), maxSummaryItems+2)
+	}
+	if last := blockText(blocks[len(blocks)-1]); last != "- ... and 5 more" {
+		t.Errorf("last block = %q", last)
+	}
+}
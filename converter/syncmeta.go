@@ -0,0 +1,41 @@
+package converter
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+)
+
+// exportedAtPattern finds the exportedAt value in YAML frontmatter or a JSON AST file
+var exportedAtPattern = regexp.MustCompile(`"?exportedAt"?: "?(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z)`)
+
+// syncMetadataTime formats a time for exportedAt and sourceLastEdited: UTC to the second,
+// so the value only changes when the time does
+func syncMetadataTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// keepExportedAt returns data with the exportedAt of the file already at path when nothing
+// else changed, so exporting an unchanged page again does not produce a diff
+func keepExportedAt(data []byte, path, exportedAt string, config runConfig) []byte {
+	existing, err := config.Workspace.ReadFile(path)
+	if err != nil || exportedAt == "" {
+		return data
+	}
+	match := exportedAtPattern.FindSubmatch(existing)
+	if match == nil {
+		return data
+	}
+	if config.Navigation != nil {
+		// prev/next are written after the page, once the whole collection is known
+		if stripped, err := setAdjacentPosts(string(existing), "", ""); err == nil {
+			existing = []byte(stripped)
+		}
+	}
+
+	kept := bytes.ReplaceAll(data, []byte(exportedAt), match[1])
+	if !bytes.Equal(kept, existing) {
+		return data
+	}
+	return kept
+}
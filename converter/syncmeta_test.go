@@ -0,0 +1,39 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeepExportedAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "post.md")
+	existing := "---\ntitle: Post\nexportedAt: 2024-01-01T00:00:00Z\nsourceLastEdited: 2023-12-31T10:00:00Z\nnext: later\n---\n\nBody\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	page := func(body string) []byte {
+		return []byte("---\ntitle: Post\nexportedAt: 2025-06-01T12:00:00Z\nsourceLastEdited: 2023-12-31T10:00:00Z\n---\n\n" + body)
+	}
+	config := runConfig{Navigation: &Navigation{}}
+
+	if got := string(keepExportedAt(page("Body\n"), path, "2025-06-01T12:00:00Z", config)); !strings.Contains(got, "exportedAt: 2024-01-01T00:00:00Z") {
+		t.Errorf("unchanged page got a new exportedAt:\n%s", got)
+	}
+	if got := string(keepExportedAt(page("Edited\n"), path, "2025-06-01T12:00:00Z", config)); !strings.Contains(got, "exportedAt: 2025-06-01T12:00:00Z") {
+		t.Errorf("changed page kept the old exportedAt:\n%s", got)
+	}
+	missing := filepath.Join(t.TempDir(), "new.md")
+	if got := string(keepExportedAt(page("Body\n"), missing, "2025-06-01T12:00:00Z", config)); !strings.Contains(got, "exportedAt: 2025-06-01T12:00:00Z") {
+		t.Errorf("new page did not get exportedAt:\n%s", got)
+	}
+}
+
+func TestSyncMetadataTime(t *testing.T) {
+	edited := time.Date(2024, 3, 1, 9, 30, 15, 123000000, time.FixedZone("JST", 9*60*60))
+	if got := syncMetadataTime(edited); got != "2024-03-01T00:30:15Z" {
+		t.Errorf("syncMetadataTime() = %q, want 2024-03-01T00:30:15Z", got)
+	}
+}
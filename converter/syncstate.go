@@ -0,0 +1,326 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// PageSyncState records the last export of a page
+type PageSyncState struct {
+	LastEditedTime string `json:"lastEditedTime"`
+	ContentHash    string `json:"contentHash"` // SHA-256 of the written file
+	Settings       string `json:"settings"`    // Hash of the settings the page was converted with
+	Path           string `json:"path"`
+}
+
+// SyncRun records a run in the sync history shown on the stats dashboard
+type SyncRun struct {
+	Time      string `json:"time"`
+	Exported  int    `json:"exported"`
+	Unchanged int    `json:"unchanged"`
+}
+
+// maxSyncRuns is the number of runs kept in the sync history
+const maxSyncRuns = 50
+
+// SyncState remembers when each page was exported, so pages that did not change since the
+// last run are skipped. A nil *SyncState skips nothing.
+type SyncState struct {
+	Pages   map[string]PageSyncState `json:"pages"`
+	History []SyncRun                `json:"history,omitempty"` // Oldest first, kept with STATS_DASHBOARD_FILE
+
+	path      string
+	workspace *writer.Workspace // Workspace of the run the files are read from and saved to
+	mu        sync.Mutex
+	dirty     bool
+}
+
+// loadSyncState reads the sync state file at path through workspace. An empty path disables
+// incremental sync; a missing file yields an empty state.
+func loadSyncState(path string, workspace *writer.Workspace) (*SyncState, error) {
+	if path == "" {
+		return nil, nil
+	}
+	state := &SyncState{Pages: map[string]PageSyncState{}, path: path, workspace: workspace}
+
+	data, err := workspace.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state %s: %v", path, err)
+	}
+	if state.Pages == nil {
+		state.Pages = map[string]PageSyncState{}
+	}
+	return state, nil
+}
+
+// Unchanged returns the file of page when the page was not edited since it was written with
+// the same settings and the file was not modified or removed since
+func (s *SyncState) Unchanged(page notionapi.Page, settings string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	s.mu.Lock()
+	previous, ok := s.Pages[page.ID.String()]
+	s.mu.Unlock()
+	if !ok || previous.LastEditedTime != page.LastEditedTime.UTC().Format(time.RFC3339) || previous.Settings != settings {
+		return "", false
+	}
+	hash, err := fileSHA256(s.workspace, filepath.FromSlash(previous.Path))
+	if err != nil || hash != previous.ContentHash {
+		return "", false
+	}
+	return filepath.FromSlash(previous.Path), true
+}
+
+// Record stores the export of page to path
+func (s *SyncState) Record(page notionapi.Page, settings, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	hash, err := fileSHA256(s.workspace, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for sync state: %v", path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Pages[page.ID.String()] = PageSyncState{
+		LastEditedTime: page.LastEditedTime.UTC().Format(time.RFC3339),
+		ContentHash:    hash,
+		Settings:       settings,
+		Path:           filepath.ToSlash(filepath.Clean(path)),
+	}
+	s.dirty = true
+	return nil
+}
+
+// Rehash updates the content hash of the page exported to path after the file was rewritten
+func (s *SyncState) Rehash(pageID, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	hash, err := fileSHA256(s.workspace, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for sync state: %v", path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.Pages[pageID]
+	if !ok || previous.Path != filepath.ToSlash(filepath.Clean(path)) {
+		return nil
+	}
+	previous.ContentHash = hash
+	s.Pages[pageID] = previous
+	s.dirty = true
+	return nil
+}
+
+// RecordRun adds a run to the sync history, dropping the oldest runs beyond maxSyncRuns
+func (s *SyncState) RecordRun(now time.Time, exported, unchanged int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.History = append(s.History, SyncRun{Time: now.Format("2006-01-02 15:04"), Exported: exported, Unchanged: unchanged})
+	if len(s.History) > maxSyncRuns {
+		s.History = s.History[len(s.History)-maxSyncRuns:]
+	}
+	s.dirty = true
+}
+
+// RunHistory returns the recorded runs, oldest first
+func (s *SyncState) RunHistory() []SyncRun {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SyncRun(nil), s.History...)
+}
+
+// Save writes the sync state file if pages were exported
+func (s *SyncState) Save() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %v", err)
+	}
+	if err := s.workspace.WriteFile(s.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write sync state %s: %v", s.path, err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// pageSettings are the settings that change the files of a page. Settings of the run, such as
+// its limits, logging and state files, and the per-page values derived from the page itself are
+// left out, so only a change of the output exports unchanged pages again. The place of a page in
+// the page tree is kept: it moves the file without the page being edited.
+type pageSettings struct {
+	DatabaseType         string
+	DatabaseName         string
+	OutputDir            string
+	DataFile             string
+	Filename             string
+	SlugStrategy         string
+	CustomSlugStrategy   bool // Options.SlugStrategy, which can't be compared across runs
+	Properties           PropertyMapping
+	Required             []string
+	PageTreeDir          string
+	SectionIndex         bool
+	SidebarOrder         int
+	ImagesDir            string
+	ImagesURLPrefix      string
+	ImagesSubdir         string
+	ImagesPerPage        bool
+	ImagesColocated      bool
+	ImageFilename        string
+	ImageAltFallback     string
+	ImageCaptions        string
+	ImageFormat          string
+	ImageQuality         int
+	ImageMaxWidth        int
+	ImageMaxHeight       int
+	ImageDecodeCommand   string
+	CompressImages       bool
+	MaxDecodePixels      int
+	DedupeImages         bool
+	IncludeNotionURL     bool
+	CanonicalOutput      bool
+	LayoutMap            map[string]string
+	LayoutField          string
+	NoIndexField         string
+	CoverImageField      string
+	AuthorName           string
+	DiaryDateLocale      string
+	EmitJSONLD           bool
+	EmitContentHash      bool
+	EmitSyncMetadata     bool
+	EmitAdjacentPosts    bool
+	EmitSlug             bool
+	EmitIcon             bool
+	WriteStatsSidecar    bool
+	LineBreakStyle       string
+	EmptyParagraphs      string
+	NumberedListContinue bool
+	CalloutStyle         string
+	CalloutComponent     string
+	CalloutImport        string
+	OutputFormat         string
+	OutputProfile        string
+	RenderBreadcrumbs    bool
+	NormalizeHeadings    bool
+	StripTitleHeading    bool
+	FrontmatterOnly      bool
+	PostProcessFileCmd   string
+}
+
+// conversionSettings hashes the settings a page of the current database is converted with, so
+// changing the configuration exports unchanged pages again
+func conversionSettings(config runConfig) string {
+	slugStrategy := config.BlogSlugStrategy
+	if config.DatabaseType == "diary" {
+		slugStrategy = config.DiarySlugStrategy
+	}
+	settings := pageSettings{
+		DatabaseType:         config.DatabaseType,
+		DatabaseName:         config.DatabaseName,
+		OutputDir:            config.outputDir(),
+		DataFile:             config.DataFile,
+		Filename:             config.filenamePattern(),
+		SlugStrategy:         orDefault(slugStrategy, defaultSlugStrategy),
+		CustomSlugStrategy:   config.SlugStrategy != nil,
+		Properties:           config.properties(),
+		Required:             config.requiredProperties(),
+		PageTreeDir:          config.PageTreeDir,
+		SectionIndex:         config.SectionIndex,
+		SidebarOrder:         config.SidebarOrder,
+		ImagesDir:            config.ImagesDir,
+		ImagesURLPrefix:      config.ImagesURLPrefix,
+		ImagesSubdir:         config.imagesSubdir(),
+		ImagesPerPage:        config.ImagesPerPage,
+		ImagesColocated:      config.ImagesColocated,
+		ImageFilename:        config.ImageFilename,
+		ImageAltFallback:     config.ImageAltFallback,
+		ImageCaptions:        config.ImageCaptions,
+		ImageFormat:          config.ImageFormat,
+		ImageQuality:         config.ImageQuality,
+		ImageMaxWidth:        config.ImageMaxWidth,
+		ImageMaxHeight:       config.ImageMaxHeight,
+		ImageDecodeCommand:   config.ImageDecodeCommand,
+		CompressImages:       config.CompressImages,
+		MaxDecodePixels:      config.MaxDecodePixels,
+		DedupeImages:         config.DedupeImages,
+		IncludeNotionURL:     config.IncludeNotionURL,
+		CanonicalOutput:      config.CanonicalOutput,
+		LayoutMap:            config.LayoutMap,
+		LayoutField:          config.LayoutField,
+		NoIndexField:         config.NoIndexField,
+		CoverImageField:      config.CoverImageField,
+		AuthorName:           config.AuthorName,
+		DiaryDateLocale:      config.DiaryDateLocale,
+		EmitJSONLD:           config.EmitJSONLD,
+		EmitContentHash:      config.EmitContentHash,
+		EmitSyncMetadata:     config.EmitSyncMetadata,
+		EmitAdjacentPosts:    config.EmitAdjacentPosts,
+		EmitSlug:             config.EmitSlug,
+		EmitIcon:             config.EmitIcon,
+		WriteStatsSidecar:    config.WriteStatsSidecar,
+		LineBreakStyle:       config.LineBreakStyle,
+		EmptyParagraphs:      config.EmptyParagraphs,
+		NumberedListContinue: config.NumberedListContinue,
+		CalloutStyle:         config.CalloutStyle,
+		CalloutComponent:     config.CalloutComponent,
+		CalloutImport:        config.CalloutImport,
+		OutputFormat:         config.OutputFormat,
+		OutputProfile:        config.OutputProfile,
+		RenderBreadcrumbs:    config.RenderBreadcrumbs,
+		NormalizeHeadings:    config.NormalizeHeadings,
+		StripTitleHeading:    config.StripTitleHeading,
+		FrontmatterOnly:      config.FrontmatterOnly,
+		PostProcessFileCmd:   config.PostProcessFileCmd,
+	}
+	// Maps are encoded with sorted keys, so the same settings always have the same hash
+	data, _ := json.Marshal(settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path as workspace sees it
+func fileSHA256(workspace *writer.Workspace, path string) (string, error) {
+	data, err := workspace.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/notion"
+)
+
+func TestIncrementalSync(t *testing.T) {
+	dir := t.TempDir()
+	blocks := &countingBlockService{blocks: []notionapi.Block{paragraphBlock("Hello")}}
+	client := &notionapi.Client{Block: blocks}
+	page := titledPage("page", "Title")
+	page.LastEditedTime = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	statePath := filepath.Join(dir, "sync.json")
+	run := func(page *notionapi.Page, configure func(*runConfig)) int {
+		state, err := loadSyncState(statePath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := runConfig{DatabaseType: "blog", LineBreakStyle: "spaces", BlogOutputDir: dir, SyncState: state}
+		if configure != nil {
+			configure(&config)
+		}
+		blocks.requests = 0
+		processPage(client, *page, config)
+		if err := state.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return blocks.requests
+	}
+
+	if requests := run(page, nil); requests == 0 {
+		t.Fatal("first run did not convert the page")
+	}
+	if requests := run(page, nil); requests != 0 {
+		t.Errorf("unchanged page was converted again (%d requests)", requests)
+	}
+	if requests := run(page, func(c *runConfig) { c.Progress = newProgress("blog", 0, 1, nil) }); requests != 0 {
+		t.Errorf("progress logging converted the unchanged page again (%d requests)", requests)
+	}
+	perRun := func(c *runConfig) {
+		c.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		c.Notion = notion.NewTransport(nil, 3, 1, 0, c.Logger)
+		c.Stdout = io.Discard
+	}
+	if requests := run(page, perRun); requests != 0 {
+		t.Errorf("a new logger and transport converted the unchanged page again (%d requests)", requests)
+	}
+	if requests := run(page, func(c *runConfig) { c.Force = true }); requests == 0 {
+		t.Error("-force did not export the unchanged page")
+	}
+	if requests := run(page, func(c *runConfig) { c.CalloutStyle = "html" }); requests == 0 {
+		t.Error("changed settings did not export the page again")
+	}
+
+	edited := *page
+	edited.LastEditedTime = page.LastEditedTime.Add(time.Minute)
+	if requests := run(&edited, func(c *runConfig) { c.CalloutStyle = "html" }); requests == 0 {
+		t.Error("edited page was not exported again")
+	}
+
+	// A file changed or removed outside of the run is written again
+	if err := os.Remove(filepath.Join(dir, "Title.md")); err != nil {
+		t.Fatal(err)
+	}
+	if requests := run(&edited, func(c *runConfig) { c.CalloutStyle = "html" }); requests == 0 {
+		t.Error("removed file was not written again")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Title.md")); err != nil {
+		t.Errorf("page file not restored: %v", err)
+	}
+}
+
+func TestConversionSettings(t *testing.T) {
+	settings := func(layouts map[string]string) runConfig {
+		return runConfig{DatabaseType: "blog", BlogOutputDir: "blog", CalloutStyle: "html", LayoutMap: layouts}
+	}
+	base := conversionSettings(settings(map[string]string{"Post": "PostLayout", "Note": "NoteLayout"}))
+
+	// The same settings give the same hash, whatever the run and the page being converted
+	same := settings(map[string]string{"Note": "NoteLayout", "Post": "PostLayout"})
+	same.PageTitle, same.ImageCount = "Another page", new(int)
+	same.Manifest, same.Report, same.Logger = &Manifest{}, newRunReport(), slog.New(slog.NewTextHandler(io.Discard, nil))
+	same.Context, same.Force, same.Concurrency, same.ManifestFile = context.Background(), true, 8, "other.json"
+	same.DiaryOutputDir, same.DiaryFilename = "diary", "{date}"
+	if got := conversionSettings(same); got != base {
+		t.Errorf("conversionSettings() = %s, want %s for the same settings", got, base)
+	}
+
+	changed := settings(map[string]string{"Post": "PostLayout", "Note": "NoteLayout"})
+	changed.CalloutStyle = "blockquote"
+	if conversionSettings(changed) == base {
+		t.Error("changed output settings kept the hash")
+	}
+}
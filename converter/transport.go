@@ -0,0 +1,33 @@
+package converter
+
+import (
+	"fmt"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/notion"
+)
+
+// NotionAPIVersion is the Notion-Version header sent with every API request
+const NotionAPIVersion = notion.APIVersion
+
+// newNotionClient creates a Notion API client outside of a run, with the default limits of a run
+func newNotionClient(token string) *notionapi.Client {
+	return notion.NewClient(token)
+}
+
+// notionClient returns a Notion API client sending its requests through the transport of the run
+func (c runConfig) notionClient() *notionapi.Client {
+	if c.Notion == nil {
+		return newNotionClient(c.NotionAPIToken)
+	}
+	return c.Notion.Client(c.NotionAPIToken)
+}
+
+// budgetErr returns the error that stops the run once the request budget is used up, or nil
+func (c runConfig) budgetErr() error {
+	if !c.Notion.Exceeded() {
+		return nil
+	}
+	return &runError{code: exitBudget, err: fmt.Errorf("stopped after %d Notion API requests: %w; raise it or narrow the export, e.g. with -type or -pages-file", c.Notion.Sent(), notion.ErrRequestBudgetExceeded)}
+}
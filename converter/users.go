@@ -0,0 +1,108 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/writer"
+)
+
+// UserDirectory resolves Notion user names through the Users API. Names are cached for
+// the run and, when a cache file is configured, across runs.
+// A nil *UserDirectory only uses the names already present on the user objects.
+type UserDirectory struct {
+	mu        sync.Mutex
+	names     map[string]string
+	failed    map[string]bool
+	path      string
+	workspace *writer.Workspace // Workspace of the run the cache file is read from and saved to
+	logger    *slog.Logger      // Logger of the run
+	dirty     bool
+}
+
+// loadUserDirectory creates a user directory backed by the cache file at path, read through
+// workspace. An empty path keeps the cache in memory only; a missing file yields an empty cache.
+func loadUserDirectory(path string, workspace *writer.Workspace, logger *slog.Logger) (*UserDirectory, error) {
+	d := &UserDirectory{names: map[string]string{}, failed: map[string]bool{}, path: path, workspace: workspace, logger: logger}
+	if path == "" {
+		return d, nil
+	}
+
+	data, err := workspace.ReadFile(path)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user cache %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &d.names); err != nil {
+		return nil, fmt.Errorf("failed to parse user cache %s: %v", path, err)
+	}
+	return d, nil
+}
+
+// Name returns the display name of user, looking it up by ID when the object has no name
+func (d *UserDirectory) Name(ctx context.Context, client *notionapi.Client, user notionapi.User) string {
+	if d == nil {
+		return user.Name
+	}
+	id := user.ID.String()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if user.Name != "" {
+		if d.names[id] != user.Name {
+			d.names[id] = user.Name
+			d.dirty = true
+		}
+		return user.Name
+	}
+	if name, ok := d.names[id]; ok {
+		return name
+	}
+	if d.failed[id] || client == nil || client.User == nil {
+		return ""
+	}
+
+	logf(d.logger, slog.LevelDebug, "Looking up user %s", id)
+	resolved, err := client.User.Get(ctx, user.ID)
+	if err != nil {
+		// Remember the failure so the same user is not requested again in this run
+		logf(d.logger, slog.LevelWarn, "Failed to look up user %s: %v", id, err)
+		d.failed[id] = true
+		return ""
+	}
+	d.names[id] = resolved.Name
+	d.dirty = true
+	return resolved.Name
+}
+
+// Save writes the cache file if one is configured and new names were resolved
+func (d *UserDirectory) Save() error {
+	if d == nil || d.path == "" {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(d.names, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode user cache: %v", err)
+	}
+	if err := d.workspace.WriteFile(d.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write user cache %s: %v", d.path, err)
+	}
+	d.dirty = false
+	return nil
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// fakeUserService serves users from memory and counts lookups
+type fakeUserService struct {
+	notionapi.UserService
+	users map[notionapi.UserID]string
+	calls int
+}
+
+func (f *fakeUserService) Get(_ context.Context, id notionapi.UserID) (*notionapi.User, error) {
+	f.calls++
+	return &notionapi.User{ID: id, Name: f.users[id]}, nil
+}
+
+func TestUserDirectoryName(t *testing.T) {
+	service := &fakeUserService{users: map[notionapi.UserID]string{"u1": "Keisuke"}}
+	client := &notionapi.Client{User: service}
+	cachePath := filepath.Join(t.TempDir(), "users.json")
+
+	users, err := loadUserDirectory(cachePath, nil, nil)
+	if err != nil {
+		t.Fatalf("loadUserDirectory() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got := users.Name(context.Background(), client, notionapi.User{ID: "u1"}); got != "Keisuke" {
+			t.Errorf("Name() = %q, want %q", got, "Keisuke")
+		}
+	}
+	if got := users.Name(context.Background(), client, notionapi.User{ID: "u2", Name: "Named"}); got != "Named" {
+		t.Errorf("Name() = %q, want %q", got, "Named")
+	}
+	if service.calls != 1 {
+		t.Errorf("expected 1 Users API call, got %d", service.calls)
+	}
+
+	if err := users.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := loadUserDirectory(cachePath, nil, nil)
+	if err != nil {
+		t.Fatalf("loadUserDirectory() error = %v", err)
+	}
+	if got := reloaded.Name(context.Background(), client, notionapi.User{ID: "u1"}); got != "Keisuke" || service.calls != 1 {
+		t.Errorf("expected cached name from disk, got %q after %d calls", got, service.calls)
+	}
+}
@@ -0,0 +1,225 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/internal/mdrender"
+	"notion-to-astro-go/internal/notion"
+)
+
+// exportedBlockTypes are the block types mdrender.Renderer converts or deliberately leaves out.
+// The content of other blocks, such as synced blocks or files, is not exported.
+var exportedBlockTypes = map[string]bool{
+	"paragraph": true, "heading_1": true, "heading_2": true, "heading_3": true,
+	"bulleted_list_item": true, "numbered_list_item": true, "to_do": true, "toggle": true,
+	"code": true, "quote": true, "callout": true, "equation": true, "divider": true,
+	"table": true, "table_row": true, "column_list": true, "column": true,
+	"image": true, "video": true, "embed": true, "bookmark": true,
+	"breadcrumb": true, "child_page": true, "template": true,
+}
+
+var (
+	verifyLinkTarget = regexp.MustCompile(`\]\([^)]*\)`)
+	verifyHTMLTag    = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(\s[^>]*)?/?>`)
+)
+
+// verifyResult is what the file of an exported page lacks compared to the page in Notion
+type verifyResult struct {
+	Path        string
+	PageID      string
+	Missing     []string // Texts of blocks that are not in the file, prefixed with the block type
+	Unsupported []string // Types of blocks that are not exported, whose content may be lost
+}
+
+// Verify retrieves the page of each file in the manifest of the configuration of opts again and
+// checks that all text of the page is still in the file, to catch content that was silently
+// lost, e.g. in blocks that are not supported. The files with lost content are listed on w.
+// The returned error has the exit code 3 if some pages could not be retrieved, and 4 if some
+// files lost content.
+func Verify(ctx context.Context, opts Options, w io.Writer) error {
+	config, err := readConfig(opts, "all", nil)
+	if err != nil {
+		return err
+	}
+	if config.NotionAPIToken == "" {
+		return missingTokenError()
+	}
+	manifest, err := loadManifest(config.ManifestFile, nil)
+	if err != nil {
+		return err
+	}
+
+	files := verifiedFiles(manifest)
+	fmt.Fprintf(w, "Verifying %d exported files...\n", len(files))
+	client := config.notionClient()
+	pages := manifest.Pages()
+	lost, failed := 0, 0
+	for _, path := range files {
+		result, err := verifyPage(ctx, client, pages[path], path)
+		if err != nil {
+			fmt.Fprintf(w, "%s (page %s): %v\n", path, pages[path], err)
+			failed++
+			continue
+		}
+		if len(result.Missing) == 0 && len(result.Unsupported) == 0 {
+			continue
+		}
+		lost++
+		fmt.Fprintf(w, "%s (page %s):\n", path, pages[path])
+		for _, blockType := range result.Unsupported {
+			fmt.Fprintf(w, "  unsupported block: %s\n", blockType)
+		}
+		for _, text := range result.Missing {
+			fmt.Fprintf(w, "  missing %s\n", text)
+		}
+	}
+
+	fmt.Fprintf(w, "Verified %d files: %d with lost content, %d could not be checked\n", len(files), lost, failed)
+	switch {
+	case failed > 0:
+		return notionError("%d of %d files could not be checked", failed, len(files))
+	case lost > 0:
+		return &runError{code: exitPartial, err: fmt.Errorf("%d of %d files lost content", lost, len(files))}
+	}
+	return nil
+}
+
+// verifiedFiles returns the markdown files recorded in the manifest, sorted by path
+func verifiedFiles(manifest *Manifest) []string {
+	var files []string
+	for path := range manifest.Pages() {
+		switch filepath.Ext(path) {
+		case ".md", ".mdx":
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// verifyPage compares the blocks of a page in Notion with its exported file at path
+func verifyPage(ctx context.Context, client *notionapi.Client, pageID, path string) (verifyResult, error) {
+	result := verifyResult{Path: path, PageID: pageID}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result, fmt.Errorf("failed to read exported file: %v", err)
+	}
+	if err := verifyBlocks(ctx, client, notionapi.BlockID(pageID), verifyNormalize(string(data)), &result); err != nil {
+		return result, fmt.Errorf("failed to retrieve page content: %w", err)
+	}
+	return result, nil
+}
+
+// verifyBlocks adds the blocks below blockID whose text is not in content, and the blocks that
+// are not exported, to result. Nested blocks are followed, except those of child pages, which
+// are exported as files of their own.
+func verifyBlocks(ctx context.Context, client *notionapi.Client, blockID notionapi.BlockID, content string, result *verifyResult) error {
+	blocks, err := notion.FetchBlockChildren(ctx, client, blockID, nil)
+	if err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		blockType := string(block.GetType())
+		if blockType == "" {
+			// Button blocks and newer block types are returned without a type by the API client
+			blockType = "unsupported"
+		}
+		if !exportedBlockTypes[blockType] {
+			result.Unsupported = append(result.Unsupported, blockType)
+		}
+		if text := verifyBlockText(block); !strings.Contains(content, verifyNormalize(text)) {
+			result.Missing = append(result.Missing, fmt.Sprintf("%s: %q", blockType, verifyExcerpt(text)))
+		}
+
+		switch blockType {
+		case "child_page", "child_database", "template":
+			continue
+		}
+		if block.GetHasChildren() {
+			if err := verifyBlocks(ctx, client, block.GetID(), content, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// verifyBlockText returns the text of a block that its exported file should contain
+func verifyBlockText(block notionapi.Block) string {
+	switch b := block.(type) {
+	case *notionapi.ParagraphBlock:
+		return mdrender.PlainText(b.Paragraph.RichText)
+	case *notionapi.Heading1Block:
+		return mdrender.PlainText(b.Heading1.RichText)
+	case *notionapi.Heading2Block:
+		return mdrender.PlainText(b.Heading2.RichText)
+	case *notionapi.Heading3Block:
+		return mdrender.PlainText(b.Heading3.RichText)
+	case *notionapi.BulletedListItemBlock:
+		return mdrender.PlainText(b.BulletedListItem.RichText)
+	case *notionapi.NumberedListItemBlock:
+		return mdrender.PlainText(b.NumberedListItem.RichText)
+	case *notionapi.ToDoBlock:
+		return mdrender.PlainText(b.ToDo.RichText)
+	case *notionapi.ToggleBlock:
+		return mdrender.PlainText(b.Toggle.RichText)
+	case *notionapi.QuoteBlock:
+		return mdrender.PlainText(b.Quote.RichText)
+	case *notionapi.CalloutBlock:
+		return mdrender.PlainText(b.Callout.RichText)
+	case *notionapi.CodeBlock:
+		return mdrender.PlainText(b.Code.RichText)
+	case *notionapi.EquationBlock:
+		return b.Equation.Expression
+	case *notionapi.TableRowBlock:
+		var cells []string
+		for _, cell := range b.TableRow.Cells {
+			cells = append(cells, mdrender.PlainText(cell))
+		}
+		return strings.Join(cells, " ")
+	case *notionapi.ImageBlock:
+		return mdrender.PlainText(b.Image.Caption)
+	case *notionapi.VideoBlock:
+		return mdrender.PlainText(b.Video.Caption)
+	case *notionapi.EmbedBlock:
+		return mdrender.PlainText(b.Embed.Caption)
+	case *notionapi.BookmarkBlock:
+		return mdrender.PlainText(b.Bookmark.Caption)
+	}
+	return ""
+}
+
+// verifyNormalize reduces text to its lowercase letters and digits, so the text of a block is
+// found in its file regardless of markdown formatting, escaping, HTML tags, link targets and
+// line breaks
+func verifyNormalize(text string) string {
+	text = verifyLinkTarget.ReplaceAllString(text, "]")
+	text = html.UnescapeString(verifyHTMLTag.ReplaceAllString(text, " "))
+	var normalized strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			normalized.WriteRune(r)
+		}
+	}
+	return normalized.String()
+}
+
+// verifyExcerpt shortens text to its first 60 characters for the report
+func verifyExcerpt(text string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) > 60 {
+		return string(runes[:60]) + "..."
+	}
+	return string(runes)
+}
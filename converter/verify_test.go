@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestVerifyPage(t *testing.T) {
+	// The paragraph is formatted and linked in markdown; the synced block is not exported
+	linked := &notionapi.ParagraphBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeParagraph},
+		Paragraph: notionapi.Paragraph{RichText: []notionapi.RichText{
+			{PlainText: "Read "},
+			{PlainText: "the docs", Href: "https://example.com/docs", Annotations: &notionapi.Annotations{Bold: true}},
+			{PlainText: " & more"},
+		}},
+	}
+	synced := &notionapi.SyncedBlock{BasicBlock: notionapi.BasicBlock{ID: "synced", Type: notionapi.BlockTypeSyncedBlock, HasChildren: true}}
+	client := &notionapi.Client{Block: &fakeBlockService{children: map[notionapi.BlockID][]notionapi.Block{
+		"page":   {headingBlock("Setup"), linked, paragraphBlock("Line one\nline two"), synced},
+		"synced": {paragraphBlock("Shared footer")},
+	}}}
+
+	path := filepath.Join(t.TempDir(), "post.md")
+	content := "---\ntitle: Post\n---\n\n## Setup\n\nRead **[the docs](https://example.com/docs)** &amp; more\n\nLine one<br/>line two\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := verifyPage(context.Background(), client, "page", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Unsupported) != 1 || result.Unsupported[0] != "synced_block" {
+		t.Errorf("unsupported = %v, want [synced_block]", result.Unsupported)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != `paragraph: "Shared footer"` {
+		t.Errorf("missing = %v, want the paragraph of the synced block", result.Missing)
+	}
+}
+
+func TestVerifyNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"**Bold** and _italic_", "boldanditalic"},
+		{"[Link](https://example.com/a_b)", "link"},
+		{"<u>Under</u>line  \nnext", "underlinenext"},
+		{"日本語の**テキスト**。", "日本語のテキスト"},
+	}
+
+	for _, tt := range tests {
+		if got := verifyNormalize(tt.input); got != tt.expected {
+			t.Errorf("verifyNormalize(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestVerifyWithoutToken(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := Verify(context.Background(), Options{ConfigData: []byte("{}")}, io.Discard); ExitCode(err) != exitConfig {
+		t.Errorf("Verify() = %v, want a configuration error", err)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"notion-to-astro-go/converter"
+)
+
+// parseFlags returns the options of the conversion flags
+func parseFlags() converter.Options {
+	flags, options := conversionFlags()
+	flags.Parse(os.Args[1:])
+	return options()
+}
+
+// conversionFlags registers the conversion flags on a new flag set and returns it with a
+// function returning their options once the flags are parsed. Shell completion offers the flags
+// of the same set.
+func conversionFlags() (*flag.FlagSet, func() converter.Options) {
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	dbType := flags.String("type", "all", "Database type to process: 'blog', 'diary', 'pages', 'all' (default) or the name of a database in the config file")
+	configPath := flags.String("config", converter.DefaultConfigFile, "Path to the config file")
+	format := flags.String("format", "markdown", "Output format: 'markdown' (default) or 'json-ast'")
+	rootPage := flags.String("root-page", "", "Root page ID to export with its child pages (implies -type pages)")
+	singlePage := flags.String("page", "", "Export only the page with this ID")
+	pagesFile := flags.String("pages-file", "", "Export only the pages listed in this file, one page ID or URL per line ('-' reads stdin)")
+	output := flags.String("output", "", "Output file for -page; '-' prints the result to stdout")
+	refreshImages := flags.Bool("refresh-images", false, "Revalidate already downloaded external images")
+	prune := flags.Bool("prune", false, "Remove files of pages that are no longer exported")
+	syncDeletions := flags.Bool("sync-deletions", false, "Remove files of pages that were archived, deleted or unpublished in Notion")
+	checkLinks := flags.Bool("check-links", false, "Report broken and relative links in exported pages")
+	markPublished := flags.Bool("mark-published", false, "Check the published checkbox in Notion of each exported page")
+	force := flags.Bool("force", false, "Export all pages, also those that did not change since the last run")
+	concurrency := flags.Int("concurrency", 0, "Number of pages processed in parallel (default: CONCURRENCY or 1)")
+	maxRequests := flags.Int("max-requests", 0, "Stop the run after this many Notion API requests (default: NOTION_MAX_REQUESTS or no limit)")
+	skip := flags.String("skip", "", "Comma-separated page IDs or URLs that are never exported, in addition to SKIP_PAGES")
+	frontmatterOnly := flags.Bool("frontmatter-only", false, "Keep the bodies of exported pages and regenerate only their frontmatter")
+	verbose := flags.Bool("verbose", false, "Also log the debug messages of each block, image and step")
+	debug := flags.Bool("debug", false, "Same as -verbose")
+	quiet := flags.Bool("quiet", false, "Log only warnings and errors")
+	logFormat := flags.String("log-format", "", "Log format: 'text' or 'json' (default: LOG_FORMAT or text)")
+	stagingDir := flags.String("staging-dir", "", "Directory of the changes staged with STAGED_WRITES (default: STAGING_DIR or .notion-to-astro-staging)")
+
+	return flags, func() converter.Options {
+		return converter.Options{
+			Type:            *dbType,
+			ConfigFile:      *configPath,
+			Format:          *format,
+			RootPage:        *rootPage,
+			Page:            *singlePage,
+			PagesFile:       *pagesFile,
+			Output:          *output,
+			RefreshImages:   *refreshImages,
+			Prune:           *prune,
+			SyncDeletions:   *syncDeletions,
+			CheckLinks:      *checkLinks,
+			MarkPublished:   *markPublished,
+			Force:           *force,
+			FrontmatterOnly: *frontmatterOnly,
+			Concurrency:     *concurrency,
+			MaxRequests:     *maxRequests,
+			Skip:            []string{*skip},
+			Verbose:         *verbose || *debug,
+			Quiet:           *quiet,
+			LogFormat:       *logFormat,
+			StagingDir:      *stagingDir,
+		}
+	}
+}
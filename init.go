@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jomei/notionapi"
+
+	"notion-to-astro-go/converter"
+)
+
+// runInit runs the interactive setup wizard that writes the config file and a starter .env
+func runInit(args []string, _ map[string]string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	configPath := flags.String("config", converter.DefaultConfigFile, "Path of the config file to write")
+	envPath := flags.String("env", ".env", "Path of the .env file to write")
+	flags.Parse(args)
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Println("notion-to-astro setup")
+	fmt.Println()
+
+	// Reuse an existing token if one is already configured
+	env, err := loadEnv(*envPath)
+	if err != nil {
+		fmt.Println(err)
+	}
+	token := env["NOTION_API_TOKEN"]
+	fromLogin := false
+	if token == "" {
+		// An OAuth token saved by `login` works as well
+		stored, err := converter.StoredAccessToken(env)
+		if err != nil {
+			fmt.Println(err)
+		}
+		token = stored
+		if token != "" {
+			fmt.Println("Using the Notion token saved by login")
+			fromLogin = true
+		}
+	} else {
+		fmt.Println("Using NOTION_API_TOKEN from the environment")
+	}
+	if token == "" {
+		fmt.Println("Create an internal integration at https://www.notion.so/my-integrations and share your databases with it.")
+		token = promptLine(reader, "Notion API token", "")
+	}
+	if token == "" {
+		return errors.New("a Notion API token is required")
+	}
+
+	fmt.Println("Fetching databases shared with the integration...")
+	databases, err := converter.ListDatabases(context.Background(), token)
+	if err != nil {
+		return fmt.Errorf("failed to list databases: %w", err)
+	}
+	if len(databases) == 0 {
+		return errors.New("no databases are shared with this integration. Share a database from its ••• menu → Connections and try again")
+	}
+	for i, db := range databases {
+		fmt.Printf("  %d) %s (%s)\n", i+1, converter.DatabaseTitle(db), db.ID)
+	}
+	fmt.Println()
+
+	var fileConfig converter.FileConfig
+	if db := chooseDatabase(reader, databases, "Blog database"); db != nil {
+		fileConfig.Blog = configureDatabase(reader, db, "blog")
+	}
+	if db := chooseDatabase(reader, databases, "Diary database"); db != nil {
+		fileConfig.Diary = configureDatabase(reader, db, "diary")
+	}
+	if fileConfig.Blog.DatabaseID == "" && fileConfig.Diary.DatabaseID == "" {
+		return errors.New("at least one database must be selected")
+	}
+	fileConfig.ImagesDir = promptLine(reader, "Images directory", "./public/images")
+
+	if confirmOverwrite(reader, *configPath) {
+		if err := converter.SaveFileConfig(*configPath, fileConfig); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", *configPath)
+	}
+
+	// The token saved by login is refreshed in place, so it is not copied into .env
+	if !fromLogin && confirmOverwrite(reader, *envPath) {
+		if err := writeStarterEnv(*envPath, token); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *envPath, err)
+		}
+		fmt.Printf("Wrote %s\n", *envPath)
+	}
+
+	fmt.Println()
+	fmt.Println("Setup complete. Run `go run . -type all` to convert your pages.")
+	return nil
+}
+
+// chooseDatabase asks the user to pick a database by number; an empty answer skips it
+func chooseDatabase(reader *bufio.Reader, databases []*notionapi.Database, label string) *notionapi.Database {
+	for {
+		answer := promptLine(reader, label+" (number, empty to skip)", "")
+		if answer == "" {
+			return nil
+		}
+		index, err := strconv.Atoi(answer)
+		if err == nil && index >= 1 && index <= len(databases) {
+			return databases[index-1]
+		}
+		fmt.Printf("Please enter a number between 1 and %d\n", len(databases))
+	}
+}
+
+// configureDatabase asks for the output directory and property mapping of a database
+func configureDatabase(reader *bufio.Reader, db *notionapi.Database, dbType string) converter.DatabaseFileConfig {
+	fmt.Printf("\nProperties of %s:\n", converter.DatabaseTitle(db))
+	names := make([]string, 0, len(db.Properties))
+	for name := range db.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  - %s (%s)\n", name, db.Properties[name].GetType())
+	}
+
+	dbConfig := converter.DatabaseFileConfig{
+		DatabaseID: db.ID.String(),
+		OutputDir:  promptLine(reader, "Output directory", "./content/"+dbType),
+	}
+	dbConfig.Properties.Title = promptLine(reader, "Title property", guessProperty(db, notionapi.PropertyConfigTypeTitle))
+	dbConfig.Properties.Tags = promptLine(reader, "Tags property", guessProperty(db, notionapi.PropertyConfigTypeMultiSelect, "tags", "Tags"))
+	dbConfig.Properties.ID = promptLine(reader, "ID property (empty to use the page ID)", guessProperty(db, "", "ID", "id"))
+	if dbType == "diary" {
+		dbConfig.Properties.Weather = promptLine(reader, "Weather property", guessProperty(db, notionapi.PropertyConfigTypeRichText, "weather"))
+	}
+	return dbConfig
+}
+
+// guessProperty suggests a property by preferred name, then by type
+func guessProperty(db *notionapi.Database, propType notionapi.PropertyConfigType, names ...string) string {
+	for _, name := range names {
+		if prop, ok := db.Properties[name]; ok && (propType == "" || prop.GetType() == propType) {
+			return name
+		}
+	}
+	if propType == notionapi.PropertyConfigTypeTitle {
+		for name, prop := range db.Properties {
+			if prop.GetType() == propType {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// confirmOverwrite asks before replacing an existing file
+func confirmOverwrite(reader *bufio.Reader, path string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return true
+	}
+	answer := promptLine(reader, path+" already exists. Overwrite? (y/N)", "n")
+	return strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")
+}
+
+// writeStarterEnv writes a .env file containing the API token
+func writeStarterEnv(path, token string) error {
+	content := fmt.Sprintf(`# Notion API Token (required)
+# Get it from https://www.notion.so/my-integrations
+NOTION_API_TOKEN=%s
+
+# Database IDs, output directories and property names are stored in %s.
+# Any variable from .env.example set here overrides the config file.
+`, token, converter.DefaultConfigFile)
+	return os.WriteFile(path, []byte(content), 0600)
+}
+
+// promptLine prints a prompt and reads a line, returning defaultValue for an empty answer
+func promptLine(reader *bufio.Reader, label, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", label, defaultValue)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return defaultValue
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return defaultValue
+	}
+	return line
+}
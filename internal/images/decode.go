@@ -0,0 +1,40 @@
+package images
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// decoders are the commands HEIC and AVIF images are decoded to PNG with: heif-convert of
+// libheif and avifdec of libavif. Go has no decoders for these formats.
+var decoders = map[string]string{
+	"heic": "heif-convert",
+	"heif": "heif-convert",
+	"avif": "avifdec",
+}
+
+// Decoder returns the command decoding images with ext to PNG, or "" when it is not installed.
+// ok reports whether images with ext need to be decoded at all.
+func Decoder(ext string) (decoder string, ok bool) {
+	decoder, ok = decoders[ext]
+	if !ok {
+		return "", false
+	}
+	if _, err := exec.LookPath(decoder); err != nil {
+		return "", true
+	}
+	return decoder, true
+}
+
+// Decode writes the image at srcPath as a PNG to outputPath with decoder
+func Decode(srcPath, outputPath, decoder string) error {
+	if output, err := exec.Command(decoder, srcPath, outputPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", decoder, err, strings.TrimSpace(string(output)))
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		return fmt.Errorf("%s wrote no image: %v", decoder, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Request is the download of an image
+type Request struct {
+	URL          string
+	Headers      map[string]string // Headers sent with the request, e.g. a Referer some hosts require
+	UserAgent    string            // User-Agent of the request; empty uses the Go default
+	ETag         string            // Validators of the file saved before, for a conditional GET
+	LastModified string
+}
+
+// Download is an image downloaded to a temporary file
+type Download struct {
+	Path         string // Temporary file named with the extension of Ext; the caller removes it
+	Ext          string // Format of the image, detected from its data, Content-Type and URL
+	Content      string // Hex SHA-256 of the image data
+	ETag         string // Validators of the response, for conditional GETs on later runs
+	LastModified string
+}
+
+// Fetch downloads the image of req into a temporary file in dir. The image is streamed, so it
+// is never held in memory as a whole. A nil *Download without an error means the server
+// answered a conditional request with 304 Not Modified.
+func Fetch(req Request, dir string, logger *slog.Logger) (*Download, error) {
+	// Create a client with timeout
+	logf(logger, slog.LevelDebug, "Creating HTTP client with timeout...")
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	// Download the image
+	logf(logger, slog.LevelDebug, "Downloading image...")
+	httpReq, err := http.NewRequest(http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image request: %v", err)
+	}
+	if req.ETag != "" {
+		httpReq.Header.Set("If-None-Match", req.ETag)
+	}
+	if req.LastModified != "" {
+		httpReq.Header.Set("If-Modified-Since", req.LastModified)
+	}
+	// Some hosts refuse Go's default User-Agent or requests without a Referer
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	if req.UserAgent != "" {
+		httpReq.Header.Set("User-Agent", req.UserAgent)
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		logf(logger, slog.LevelWarn, "Error downloading image: %v", err)
+		return nil, fmt.Errorf("failed to download image: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+
+	// Check if the response is successful
+	if resp.StatusCode != http.StatusOK {
+		logf(logger, slog.LevelWarn, "HTTP status code %d when downloading image", resp.StatusCode)
+		return nil, fmt.Errorf("failed to download image, status code: %d", resp.StatusCode)
+	}
+	logf(logger, slog.LevelDebug, "Image downloaded successfully")
+
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	contentHasher := sha256.New()
+	bytesWritten, err := io.Copy(io.MultiWriter(tmp, contentHasher), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		logf(logger, slog.LevelWarn, "Error saving downloaded image: %v", err)
+		return nil, fmt.Errorf("failed to save downloaded image: %v", err)
+	}
+	logf(logger, slog.LevelDebug, "Downloaded %d bytes", bytesWritten)
+
+	// Name the image after its actual format; the URL may have no extension or a wrong one.
+	// Encoders and decoders tell the input format from the extension.
+	ext := DetectExt(tmpPath, resp.Header.Get("Content-Type"), URLExt(req.URL))
+	typedPath := tmpPath + "." + ext
+	if err := os.Rename(tmpPath, typedPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to save downloaded image: %v", err)
+	}
+	return &Download{
+		Path:         typedPath,
+		Ext:          ext,
+		Content:      hex.EncodeToString(contentHasher.Sum(nil)),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
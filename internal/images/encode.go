@@ -0,0 +1,53 @@
+package images
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// encoders are the commands IMAGE_FORMAT re-encodes images with: cwebp of libwebp and
+// avifenc of libavif. Go has no encoders for these formats.
+var encoders = map[string]string{
+	"webp": "cwebp",
+	"avif": "avifenc",
+}
+
+// ValidateFormat reports an unknown IMAGE_FORMAT or a missing encoder
+func ValidateFormat(format string) error {
+	if format == "original" {
+		return nil
+	}
+	encoder, ok := encoders[format]
+	if !ok {
+		return fmt.Errorf("%s. Must be 'original', 'webp' or 'avif'", format)
+	}
+	if _, err := exec.LookPath(encoder); err != nil {
+		return fmt.Errorf("%s requires %s to be installed", format, encoder)
+	}
+	return nil
+}
+
+// Encode re-encodes the image at srcPath into format at outputPath with the encoder of the
+// format. The encoder detects the input format from the extension of srcPath.
+func Encode(srcPath, outputPath, format string, quality int) error {
+	q := strconv.Itoa(quality)
+	var cmd *exec.Cmd
+	switch format {
+	case "webp":
+		cmd = exec.Command(encoders[format], "-quiet", "-q", q, srcPath, "-o", outputPath)
+	case "avif":
+		cmd = exec.Command(encoders[format], "-q", q, srcPath, outputPath)
+	default:
+		return fmt.Errorf("unsupported image format: %s", format)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", cmd.Args[0], err, strings.TrimSpace(string(output)))
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		return fmt.Errorf("%s wrote no image: %v", cmd.Args[0], err)
+	}
+	return nil
+}
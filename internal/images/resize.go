@@ -0,0 +1,81 @@
+package images
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"os"
+)
+
+// FitDimensions returns the size of a width×height image scaled down, keeping its aspect ratio,
+// to fit maxWidth and maxHeight. A limit of 0 is no limit; images are never enlarged.
+func FitDimensions(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = math.Min(scale, float64(maxWidth)/float64(width))
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = math.Min(scale, float64(maxHeight)/float64(height))
+	}
+	if scale == 1 {
+		return width, height
+	}
+	return max(1, int(math.Round(float64(width)*scale))), max(1, int(math.Round(float64(height)*scale)))
+}
+
+// Oversized reports whether the image at path exceeds maxWidth or maxHeight. Only the header is
+// read; a limit of 0 is no limit.
+func Oversized(path string, maxWidth, maxHeight int) bool {
+	if maxWidth <= 0 && maxHeight <= 0 {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	imgConfig, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return false
+	}
+	width, height := FitDimensions(imgConfig.Width, imgConfig.Height, maxWidth, maxHeight)
+	return width != imgConfig.Width || height != imgConfig.Height
+}
+
+// Resize scales img down to width×height, averaging the source pixels that fall on each
+// pixel of the result
+func Resize(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			// The pixels are premultiplied, so the channels are averaged independently
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[src.PixOffset(x0, sy):src.PixOffset(x1, sy)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			offset := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[offset+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,50 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFitDimensions(t *testing.T) {
+	tests := []struct {
+		width, height, maxWidth, maxHeight int
+		expectedWidth, expectedHeight      int
+	}{
+		{4000, 3000, 1600, 0, 1600, 1200},
+		{4000, 3000, 0, 600, 800, 600},
+		{4000, 3000, 1600, 600, 800, 600},
+		{800, 600, 1600, 1200, 800, 600}, // Never enlarged
+		{800, 600, 0, 0, 800, 600},
+		{3000, 1, 100, 0, 100, 1},
+	}
+
+	for _, tt := range tests {
+		width, height := FitDimensions(tt.width, tt.height, tt.maxWidth, tt.maxHeight)
+		if width != tt.expectedWidth || height != tt.expectedHeight {
+			t.Errorf("FitDimensions(%d, %d, %d, %d) = %dx%d, want %dx%d", tt.width, tt.height, tt.maxWidth, tt.maxHeight, width, height, tt.expectedWidth, tt.expectedHeight)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	// Black and white columns average to grey
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	resized := Resize(img, 2, 1)
+	if resized.Bounds().Dx() != 2 || resized.Bounds().Dy() != 1 {
+		t.Fatalf("unexpected size %v", resized.Bounds())
+	}
+	if c := resized.RGBAAt(1, 0); c.R != 128 || c.A != 255 {
+		t.Errorf("expected grey, got %v", c)
+	}
+}
@@ -0,0 +1,259 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Register image formats
+	_ "image/gif"
+)
+
+// Options are the settings downloaded images are saved with
+type Options struct {
+	Format          string // Format JPEG and PNG images are converted to: "original", "webp" or "avif"; "" is "original"
+	Quality         int    // Quality (1-100) of compressed and converted images; 0 uses the default of the format
+	Compress        bool   // Recompress JPEG and PNG images; otherwise they are saved as downloaded
+	MaxWidth        int    // Images are scaled down to this width, keeping their aspect ratio; 0 for no limit
+	MaxHeight       int    // Images are scaled down to this height, keeping their aspect ratio; 0 for no limit
+	MaxDecodePixels int    // Images with more pixels are saved as downloaded instead of being decoded; 0 disables the limit
+	Logger          *slog.Logger
+
+	// Decode replaces the default decoders of HEIC and AVIF images, writing the image at
+	// srcPath as a PNG to outputPath; nil uses heif-convert and avifdec
+	Decode func(srcPath, outputPath string) error
+}
+
+// Transcoded reports whether images downloaded with ext are decoded and saved in another
+// format. Browsers can't display HEIC, so it is always transcoded when a decoder is available;
+// AVIF is only transcoded into the WebP of Format "webp".
+func (o Options) Transcoded(ext string) bool {
+	if ext == "avif" && o.Format != "webp" {
+		return false
+	}
+	decoder, ok := Decoder(ext)
+	return ok && (o.Decode != nil || decoder != "")
+}
+
+// FormatExt returns the extension an image downloaded with ext is saved with. Format only
+// converts JPEG and PNG images, and transcoded HEIC and AVIF images; anything else is kept in
+// its format.
+func (o Options) FormatExt(ext string) string {
+	if o.Transcoded(ext) {
+		if o.Format == "" || o.Format == "original" {
+			return "jpg"
+		}
+		return o.Format
+	}
+	if o.Format == "" || o.Format == "original" || (ext != "jpg" && ext != "jpeg" && ext != "png") {
+		return ext
+	}
+	return o.Format
+}
+
+// quality returns Quality, or the default quality of format when it is not set: 50 for JPEG
+// and 75 for WebP and AVIF
+func (o Options) quality(format string) int {
+	if o.Quality > 0 {
+		return o.Quality
+	}
+	if format == "jpg" || format == "jpeg" {
+		return 50
+	}
+	return 75
+}
+
+// Save converts the image downloaded to srcPath as sourceExt into ext, the extension FormatExt
+// chose, and returns the file to move into place: srcPath itself when the image is kept as
+// downloaded, or a new file next to it that the caller removes. Only JPEG and PNG are
+// recompressed; images larger than the maximum dimensions are scaled down even without Compress.
+func Save(srcPath, sourceExt, ext string, opts Options) (string, error) {
+	if opts.Transcoded(sourceExt) {
+		logf(opts.Logger, slog.LevelDebug, "Transcoding %s image to %s", sourceExt, ext)
+		saved, err := transcode(srcPath, sourceExt, ext, opts)
+		if err != nil {
+			logf(opts.Logger, slog.LevelWarn, "Error transcoding image: %v", err)
+			return "", fmt.Errorf("failed to transcode image: %v", err)
+		}
+		return saved, nil
+	}
+	if ext != sourceExt {
+		logf(opts.Logger, slog.LevelDebug, "Converting image to %s", ext)
+		saved, err := convert(srcPath, opts)
+		if err != nil {
+			logf(opts.Logger, slog.LevelWarn, "Error converting image: %v", err)
+			return "", fmt.Errorf("failed to convert image: %v", err)
+		}
+		return saved, nil
+	}
+	if !(opts.Compress || Oversized(srcPath, opts.MaxWidth, opts.MaxHeight)) || (ext != "jpg" && ext != "jpeg" && ext != "png") || !Decodable(srcPath, opts.MaxDecodePixels, opts.Logger) {
+		logf(opts.Logger, slog.LevelDebug, "Saving original image for format: %s", ext)
+		return srcPath, nil
+	}
+	saved, err := compress(srcPath, ext, opts)
+	if err != nil {
+		logf(opts.Logger, slog.LevelWarn, "Error saving compressed image: %v", err)
+		return "", fmt.Errorf("failed to save compressed image: %v", err)
+	}
+	return saved, nil
+}
+
+// transcode decodes the HEIC or AVIF image at srcPath and saves it as ext: JPEG with Format
+// "original", or Format
+func transcode(srcPath, sourceExt, ext string, opts Options) (string, error) {
+	decoded := strings.TrimSuffix(srcPath, "."+sourceExt) + ".decoded.png"
+	defer os.Remove(decoded)
+	var err error
+	if opts.Decode != nil {
+		err = opts.Decode(srcPath, decoded)
+	} else {
+		decoder, _ := Decoder(sourceExt)
+		err = Decode(srcPath, decoded, decoder)
+	}
+	if err != nil {
+		return "", err
+	}
+	if ext == opts.Format {
+		return convert(decoded, opts)
+	}
+	return compress(decoded, ext, opts)
+}
+
+// convert re-encodes the image at srcPath into Format. An image larger than the maximum
+// dimensions is scaled down into a lossless PNG that is encoded instead.
+func convert(srcPath string, opts Options) (string, error) {
+	base := strings.TrimSuffix(srcPath, filepath.Ext(srcPath))
+	src := srcPath
+	if Oversized(srcPath, opts.MaxWidth, opts.MaxHeight) && Decodable(srcPath, opts.MaxDecodePixels, opts.Logger) {
+		src = base + ".resized.png"
+		defer os.Remove(src)
+		if err := writeResizedPNG(srcPath, src, opts); err != nil {
+			return "", err
+		}
+	}
+
+	encoded := base + "." + opts.Format
+	if err := Encode(src, encoded, opts.Format, opts.quality(opts.Format)); err != nil {
+		os.Remove(encoded)
+		return "", err
+	}
+	return encoded, nil
+}
+
+// compress decodes the image at srcPath, scales it down to the maximum dimensions and writes
+// it compressed as ext
+func compress(srcPath, ext string, opts Options) (string, error) {
+	img, err := decodeResized(srcPath, opts)
+	if err != nil {
+		return "", err
+	}
+
+	outputPath := strings.TrimSuffix(srcPath, filepath.Ext(srcPath)) + ".compressed." + ext
+	logf(opts.Logger, slog.LevelDebug, "Creating output file: %s", outputPath)
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %v", err)
+	}
+
+	// Compress and save the image based on its type
+	logf(opts.Logger, slog.LevelDebug, "Compressing and saving image as %s...", ext)
+	if ext == "png" {
+		// Compress PNG with best compression
+		logf(opts.Logger, slog.LevelDebug, "Using PNG best compression")
+		encoder := png.Encoder{CompressionLevel: png.BestCompression}
+		err = encoder.Encode(out, img)
+	} else {
+		// Compress JPEG with Quality (1-100, higher is better quality but larger file)
+		quality := opts.quality("jpeg")
+		logf(opts.Logger, slog.LevelDebug, "Using JPEG compression with quality %d", quality)
+		err = jpeg.Encode(out, img, &jpeg.Options{Quality: quality})
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputPath)
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// Decodable reports whether the image at path is small enough to be decoded for recompression.
+// Only the header is read; maxPixels <= 0 disables the limit.
+func Decodable(path string, maxPixels int, logger *slog.Logger) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	imgConfig, imgFormat, err := image.DecodeConfig(f)
+	if err != nil {
+		logf(logger, slog.LevelWarn, "Error reading image header: %v", err)
+		return false
+	}
+	if maxPixels > 0 && imgConfig.Width*imgConfig.Height > maxPixels {
+		logf(logger, slog.LevelWarn, "Image is %dx%d (%s), larger than IMAGE_MAX_DECODE_PIXELS; skipping compression", imgConfig.Width, imgConfig.Height, imgFormat)
+		return false
+	}
+	return true
+}
+
+// decodeResized decodes the image at path, scaled down to fit the maximum dimensions
+func decodeResized(path string, opts Options) (image.Image, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	logf(opts.Logger, slog.LevelDebug, "Decoding image...")
+	img, imgFormat, err := image.Decode(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+	logf(opts.Logger, slog.LevelDebug, "Image decoded successfully (format: %s)", imgFormat)
+
+	bounds := img.Bounds()
+	width, height := FitDimensions(bounds.Dx(), bounds.Dy(), opts.MaxWidth, opts.MaxHeight)
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return img, nil
+	}
+	logf(opts.Logger, slog.LevelDebug, "Resizing image from %dx%d to %dx%d", bounds.Dx(), bounds.Dy(), width, height)
+	return Resize(img, width, height), nil
+}
+
+// writeResizedPNG writes the image at srcPath scaled down to the maximum dimensions to dstPath
+func writeResizedPNG(srcPath, dstPath string, opts Options) error {
+	img, err := decodeResized(srcPath, opts)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(out, img); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// logf formats and logs a message through logger when level is enabled. A nil logger logs
+// through the default slog logger.
+func logf(logger *slog.Logger, level slog.Level, format string, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,22 @@
+package images
+
+import "testing"
+
+func TestTranscoded(t *testing.T) {
+	decode := func(srcPath, outputPath string) error { return nil }
+	opts := Options{Format: "original", Decode: decode}
+	if !opts.Transcoded("heic") || opts.Transcoded("avif") || opts.Transcoded("png") {
+		t.Error("expected only HEIC to be transcoded with Format original")
+	}
+	opts.Format = "webp"
+	if !opts.Transcoded("avif") || opts.FormatExt("avif") != "webp" {
+		t.Error("expected AVIF to be transcoded to WebP")
+	}
+
+	// Without a decoder HEIC is kept as downloaded
+	t.Setenv("PATH", t.TempDir())
+	opts.Decode = nil
+	if opts.Transcoded("heic") || opts.FormatExt("heic") != "heic" {
+		t.Error("expected HEIC to be kept without a decoder")
+	}
+}
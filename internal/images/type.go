@@ -0,0 +1,116 @@
+// Package images downloads, detects, resizes, decodes and re-encodes the images of a
+// conversion run. HEIC and AVIF are decoded and WebP and AVIF encoded with the commands of
+// libheif, libavif and libwebp, since Go has no codecs for these formats.
+package images
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// imageMIMEExtensions maps the media types of images to the extension they are saved with
+var imageMIMEExtensions = map[string]string{
+	"image/jpeg":               "jpg",
+	"image/png":                "png",
+	"image/gif":                "gif",
+	"image/webp":               "webp",
+	"image/avif":               "avif",
+	"image/heic":               "heic",
+	"image/heif":               "heif",
+	"image/svg+xml":            "svg",
+	"image/bmp":                "bmp",
+	"image/tiff":               "tiff",
+	"image/x-icon":             "ico",
+	"image/vnd.microsoft.icon": "ico",
+}
+
+// imageURLExtensions are the extensions the path of an image URL is trusted with
+var imageURLExtensions = map[string]bool{
+	"jpg": true, "jpeg": true, "png": true, "gif": true, "webp": true, "avif": true, "heic": true,
+	"heif": true, "svg": true, "bmp": true, "tiff": true, "tif": true, "ico": true,
+}
+
+// URLExt returns the lowercase extension of the path of imageURL when it is an image
+// extension, or "". The query and the host, such as those of signed S3 URLs, are never taken
+// for the extension.
+func URLExt(imageURL string) string {
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(parsed.Path), "."))
+	if !imageURLExtensions[ext] {
+		return ""
+	}
+	return ext
+}
+
+// SniffExt returns the extension of the image format data starts with, or "". On top of
+// the signatures of http.DetectContentType, it knows the ftyp brands of AVIF and HEIC, TIFF
+// and SVG.
+func SniffExt(data []byte) string {
+	if ext, ok := imageMIMEExtensions[http.DetectContentType(data)]; ok {
+		return ext
+	}
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		switch string(data[8:12]) {
+		case "avif", "avis":
+			return "avif"
+		case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+			return "heic"
+		}
+	}
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return "tiff"
+	}
+	if bytes.Contains(data, []byte("<svg")) {
+		return "svg"
+	}
+	return ""
+}
+
+// DetectExt returns the extension of the downloaded image at path from its first bytes,
+// then from the Content-Type of the response and then from the URL, defaulting to jpg
+func DetectExt(path, contentType, urlExt string) string {
+	if f, err := os.Open(path); err == nil {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		f.Close()
+		if ext := SniffExt(head[:n]); ext != "" {
+			return sameImageFormat(ext, urlExt)
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if ext, ok := imageMIMEExtensions[mediaType]; ok {
+			return sameImageFormat(ext, urlExt)
+		}
+	}
+	if urlExt != "" {
+		return urlExt
+	}
+	return "jpg"
+}
+
+// imageExtAliases are extensions of the same format as the extension they map to
+var imageExtAliases = map[string]string{"jpeg": "jpg", "tif": "tiff", "heif": "heic"}
+
+// sameImageFormat returns urlExt when it is another extension of the detected format, so
+// photo.jpeg keeps its extension, and ext otherwise
+func sameImageFormat(ext, urlExt string) string {
+	canonical := func(e string) string {
+		if alias, ok := imageExtAliases[e]; ok {
+			return alias
+		}
+		return e
+	}
+	if urlExt != "" && canonical(urlExt) == canonical(ext) {
+		return urlExt
+	}
+	return ext
+}
@@ -0,0 +1,45 @@
+package images
+
+import "testing"
+
+func TestURLImageExt(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/photo.PNG?size=large", "png"},
+		{"https://s3.us-west-2.amazonaws.com/secure.notion-static.com/1234/photo?X-Amz-Credential=a.b%2Fc", ""},
+		{"https://images.example.com/image", ""},
+		{"https://example.com/download.php?file=a.jpg", ""},
+		{"https://example.com/IMG_0001.heic", "heic"},
+	}
+
+	for _, tt := range tests {
+		if ext := URLExt(tt.url); ext != tt.expected {
+			t.Errorf("URLExt(%q) = %q, want %q", tt.url, ext, tt.expected)
+		}
+	}
+}
+
+func TestSniffImageExt(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"\x89PNG\r\n\x1a\n", "png"},
+		{"\xff\xd8\xff\xe0", "jpg"},
+		{"GIF89a", "gif"},
+		{"RIFF\x00\x00\x00\x00WEBPVP8 ", "webp"},
+		{"\x00\x00\x00\x1cftypavif\x00\x00\x00\x00", "avif"},
+		{"\x00\x00\x00\x18ftypheic\x00\x00\x00\x00", "heic"},
+		{"II*\x00", "tiff"},
+		{"<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\">", "svg"},
+		{"<html>", ""},
+	}
+
+	for _, tt := range tests {
+		if ext := SniffExt([]byte(tt.data)); ext != tt.expected {
+			t.Errorf("SniffExt(%q) = %q, want %q", tt.data, ext, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mdrender
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// CalloutIcon returns the emoji of a callout icon, or the URL of a custom icon image
+func CalloutIcon(icon *notionapi.Icon) string {
+	if icon == nil {
+		return ""
+	}
+	if icon.Emoji != nil {
+		return string(*icon.Emoji)
+	}
+	return icon.GetURL()
+}
+
+// Callout renders a callout as an admonition-style blockquote, an HTML aside or a Starlight aside.
+// The icon leads the admonition title, or becomes a data-icon attribute in HTML mode.
+func Callout(text, icon, style string) string {
+	if style == "aside" {
+		return starlightAside(text, icon)
+	}
+	if style == "html" {
+		attributes := ` class="callout"`
+		if icon != "" {
+			attributes += fmt.Sprintf(` data-icon="%s"`, html.EscapeString(icon))
+		}
+		// Three newlines leave a blank line after ProcessEmptyLines so the body is parsed as markdown
+		return fmt.Sprintf("<aside%s>\n\n\n%s  \n\n\n</aside>  \n\n", attributes, text)
+	}
+
+	title := "Note"
+	// Custom icon images cannot be shown in a title, so only emoji are kept
+	if icon != "" && !strings.Contains(icon, "://") {
+		title = icon + " " + title
+	}
+	return fmt.Sprintf("> **%s**  \n> %s  \n\n", title, strings.ReplaceAll(text, "\n", "\n> "))
+}
+
+// starlightAsideTypes maps callout emoji to Starlight aside types; other icons become "note"
+var starlightAsideTypes = map[string]string{
+	"💡":  "tip",
+	"✅":  "tip",
+	"⚠️": "caution",
+	"⚠":  "caution",
+	"🚧":  "caution",
+	"🚨":  "danger",
+	"❗":  "danger",
+	"⛔":  "danger",
+	"🔥":  "danger",
+}
+
+// starlightAside renders a callout as a Starlight aside (:::note ... :::).
+// The aside type is chosen from the callout icon.
+func starlightAside(text, icon string) string {
+	asideType, ok := starlightAsideTypes[icon]
+	if !ok {
+		asideType = "note"
+	}
+	// Three newlines leave a blank line after ProcessEmptyLines so the closing fence stands alone
+	return fmt.Sprintf(":::%s\n%s  \n:::\n\n\n", asideType, text)
+}
+
+// Details renders a collapsible <details> element with the summary HTML and the markdown body.
+// Three newlines leave a blank line after ProcessEmptyLines so the body and the next block are
+// parsed as markdown.
+func Details(summary, body string) string {
+	return fmt.Sprintf("<details>\n<summary>%s</summary>\n\n\n%s  \n\n\n</details>  \n\n\n", summary, body)
+}
+
+// CalloutComponent renders a callout as an MDX component with the icon as a prop,
+// e.g. <Callout icon="💡">
+func CalloutComponent(text, icon, component string) string {
+	attributes := ""
+	if icon != "" {
+		attributes = fmt.Sprintf(` icon="%s"`, html.EscapeString(icon))
+	}
+	// Three newlines leave a blank line after ProcessEmptyLines so the body is parsed as markdown
+	return fmt.Sprintf("<%s%s>\n\n\n%s  \n\n\n</%s>  \n\n", component, attributes, text, component)
+}
+
+// Table renders a table as a GitHub-flavored markdown table. Markdown tables always have
+// a header row, so it is left empty unless the table has a column header. Row headers are bold.
+func Table(table notionapi.Table, rows []notionapi.Block) string {
+	var cells [][]string
+	for _, block := range rows {
+		row, ok := block.(*notionapi.TableRowBlock)
+		if !ok {
+			continue
+		}
+		line := make([]string, table.TableWidth)
+		for i, cell := range row.TableRow.Cells {
+			if i < len(line) {
+				text := strings.ReplaceAll(RichText(cell), "|", "\\|")
+				line[i] = strings.ReplaceAll(text, "\n", "<br>")
+			}
+		}
+		cells = append(cells, line)
+	}
+
+	header := make([]string, table.TableWidth)
+	if table.HasColumnHeader && len(cells) > 0 {
+		header, cells = cells[0], cells[1:]
+	}
+	separator := make([]string, table.TableWidth)
+	for i := range separator {
+		separator[i] = "---"
+	}
+	if table.HasRowHeader {
+		for _, line := range cells {
+			if len(line) > 0 && line[0] != "" {
+				line[0] = "**" + line[0] + "**"
+			}
+		}
+	}
+
+	var markdown strings.Builder
+	for _, line := range append([][]string{header, separator}, cells...) {
+		markdown.WriteString("| " + strings.Join(line, " | ") + " |\n")
+	}
+	return markdown.String()
+}
+
+// Indent indents every non-empty line of text, e.g. to nest blocks under a list item
+func Indent(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,65 @@
+package mdrender
+
+import "testing"
+
+func TestCallout(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		icon     string
+		style    string
+		expected string
+	}{
+		{
+			name:     "Blockquote with emoji",
+			text:     "Be careful",
+			icon:     "⚠️",
+			style:    "blockquote",
+			expected: "> **⚠️ Note**  \n> Be careful  \n\n",
+		},
+		{
+			name:     "Blockquote with multiple lines",
+			text:     "First  \nSecond",
+			icon:     "💡",
+			style:    "blockquote",
+			expected: "> **💡 Note**  \n> First  \n> Second  \n\n",
+		},
+		{
+			name:     "Blockquote with custom icon image",
+			text:     "Tip",
+			icon:     "https://example.com/icon.png",
+			style:    "blockquote",
+			expected: "> **Note**  \n> Tip  \n\n",
+		},
+		{
+			name:     "HTML with emoji",
+			text:     "Idea",
+			icon:     "💡",
+			style:    "html",
+			expected: "<aside class=\"callout\" data-icon=\"💡\">\n\n\nIdea  \n\n\n</aside>  \n\n",
+		},
+		{
+			name:     "Starlight aside from emoji",
+			text:     "Be careful",
+			icon:     "⚠️",
+			style:    "aside",
+			expected: ":::caution\nBe careful  \n:::\n\n\n",
+		},
+		{
+			name:     "Starlight aside with unknown icon",
+			text:     "FYI",
+			icon:     "📌",
+			style:    "aside",
+			expected: ":::note\nFYI  \n:::\n\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Callout(tt.text, tt.icon, tt.style)
+			if result != tt.expected {
+				t.Errorf("Callout() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
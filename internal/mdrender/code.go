@@ -0,0 +1,70 @@
+package mdrender
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// highlightDirectivePattern matches a line highlight directive, "highlight: 3-5,7", given as the
+// caption of a code block or, behind a comment marker, on its first line
+var highlightDirectivePattern = regexp.MustCompile(`^(//|#|--|;|/\*|<!--)?\s*(?i:highlight):\s*(\d+(?:-\d+)?(?:\s*,\s*\d+(?:-\d+)?)*)\s*(?:\*/|-->)?$`)
+
+// highlightRanges returns the line ranges of a highlight directive, e.g. "3-5,7", or "" if
+// line is not one. A directive inside the code must be a comment, so that code such as
+// YAML with a highlight key is left alone.
+func highlightRanges(line string, comment bool) string {
+	match := highlightDirectivePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil || (comment && match[1] == "") {
+		return ""
+	}
+	return strings.Join(strings.Fields(strings.ReplaceAll(match[2], ",", " ")), ",")
+}
+
+// Code renders a code block as a fenced block. A highlight directive in the caption
+// or on the first line becomes the {3-5} meta that Expressive Code and Shiki highlight lines
+// with; a directive on the first line is removed, so line numbers count from the line after it.
+// Patches are fenced as diff without the trailing spaces other lines get.
+func Code(code notionapi.Code) string {
+	// Code is shown verbatim, so annotations and links inside it are not rendered
+	text := PlainText(code.RichText)
+	info := code.Language
+	diff := isDiffBlock(code)
+	if diff {
+		info = "diff"
+		// Unlike PlainText, only surrounding line breaks are dropped: the last context line of
+		// a patch may be a single space
+		var patch strings.Builder
+		for _, rt := range code.RichText {
+			patch.WriteString(rt.PlainText)
+		}
+		text = strings.Trim(patch.String(), "\n")
+	}
+
+	ranges := highlightRanges(PlainText(code.Caption), false)
+	if ranges == "" {
+		firstLine, rest, _ := strings.Cut(text, "\n")
+		if ranges = highlightRanges(firstLine, true); ranges != "" {
+			text = rest
+		}
+	}
+	if ranges != "" {
+		info += " {" + ranges + "}"
+	}
+
+	if diff {
+		return "```" + info + "\n" + text + "\n```  \n\n"
+	}
+	return "```" + info + "  \n" + text + "  \n```  \n\n"
+}
+
+// isDiffBlock reports whether a code block holds a patch: its language is diff, or its caption
+// is "diff" or "patch" or names a .diff or .patch file
+func isDiffBlock(code notionapi.Code) bool {
+	if code.Language == "diff" {
+		return true
+	}
+	caption := strings.ToLower(strings.TrimSpace(PlainText(code.Caption)))
+	return caption == "diff" || caption == "patch" || strings.HasSuffix(caption, ".diff") || strings.HasSuffix(caption, ".patch")
+}
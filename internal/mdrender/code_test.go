@@ -0,0 +1,77 @@
+package mdrender
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     notionapi.Code
+		expected string
+	}{
+		{
+			name:     "Plain",
+			code:     notionapi.Code{Language: "go", RichText: richText("fmt.Println()")},
+			expected: "```go  \nfmt.Println()  \n```  \n\n",
+		},
+		{
+			name:     "Caption",
+			code:     notionapi.Code{Language: "js", RichText: richText("a()\nb()"), Caption: richText("highlight: 2, 4-5")},
+			expected: "```js {2,4-5}  \na()\nb()  \n```  \n\n",
+		},
+		{
+			name:     "First line comment",
+			code:     notionapi.Code{Language: "python", RichText: richText("# highlight: 2\nimport os\nos.exit()")},
+			expected: "```python {2}  \nimport os\nos.exit()  \n```  \n\n",
+		},
+		{
+			name:     "Block comment",
+			code:     notionapi.Code{Language: "html", RichText: richText("<!-- highlight: 1 -->\n<p>Hi</p>")},
+			expected: "```html {1}  \n<p>Hi</p>  \n```  \n\n",
+		},
+		{
+			name:     "Code that only looks like a directive",
+			code:     notionapi.Code{Language: "yaml", RichText: richText("highlight: 3\ncolor: red")},
+			expected: "```yaml  \nhighlight: 3\ncolor: red  \n```  \n\n",
+		},
+		{
+			name:     "Other caption",
+			code:     notionapi.Code{Language: "sh", RichText: richText("ls"), Caption: richText("List files")},
+			expected: "```sh  \nls  \n```  \n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := Code(tt.code); result != tt.expected {
+				t.Errorf("Code() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffCodeBlock(t *testing.T) {
+	patch := "--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n \n-old  \n+new\n "
+	for name, code := range map[string]notionapi.Code{
+		"Language": {Language: "diff", RichText: richText(patch)},
+		"Caption":  {Language: "plain text", RichText: richText(patch), Caption: richText("fix.patch")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			markdown := Code(code)
+			if expected := "```diff\n" + patch + "\n```  \n\n"; markdown != expected {
+				t.Errorf("Code() = %q, want %q", markdown, expected)
+			}
+			// Blank context lines survive the empty line processing
+			if result := ProcessEmptyLines("Intro  \n\n" + markdown + "Outro  \n"); result != "Intro  \n```diff\n"+patch+"\n```  \nOutro  " {
+				t.Errorf("ProcessEmptyLines() = %q", result)
+			}
+		})
+	}
+}
+
+func richText(text string) []notionapi.RichText {
+	return []notionapi.RichText{{PlainText: text}}
+}
@@ -0,0 +1,118 @@
+package mdrender
+
+import (
+	"regexp"
+	"strings"
+)
+
+// headingPattern matches a markdown heading line
+var headingPattern = regexp.MustCompile(`^(#{1,6}) `)
+
+// NormalizeHeadings shifts the headings of a page body so the first one is h2 (the page title
+// is the h1) and no level is skipped, e.g. h1, h3, h2 become h2, h3, h3. Headings inside code
+// blocks, lists and quotes are left alone.
+func NormalizeHeadings(markdown string) string {
+	type heading struct{ original, normalized int }
+	var parents []heading
+	inCode := false
+
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		match := headingPattern.FindStringSubmatch(line)
+		if inCode || match == nil {
+			continue
+		}
+
+		level := len(match[1])
+		for len(parents) > 0 && parents[len(parents)-1].original >= level {
+			parents = parents[:len(parents)-1]
+		}
+		normalized := 2
+		if len(parents) > 0 {
+			normalized = min(parents[len(parents)-1].normalized+1, 6)
+		}
+		parents = append(parents, heading{level, normalized})
+		lines[i] = strings.Repeat("#", normalized) + line[level:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StripTitleHeading removes the first heading of a page body if it only repeats the page
+// title, which the site already shows from the frontmatter
+func StripTitleHeading(markdown, title string) string {
+	rest := strings.TrimLeft(markdown, " \n")
+	line, remainder, _ := strings.Cut(rest, "\n")
+	match := headingPattern.FindString(line)
+	if match == "" {
+		return markdown
+	}
+	text := inlineFormattingPattern.ReplaceAllString(LinksToPlainText(line[len(match):]), "")
+	if !strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(title)) {
+		return markdown
+	}
+	return strings.TrimLeft(remainder, " \n")
+}
+
+// FirstImage returns the path of the first image in the markdown, if any
+func FirstImage(markdown string) string {
+	re := regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)\)`)
+	if match := re.FindStringSubmatch(markdown); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// ProcessEmptyLines processes the content to handle empty lines according to requirements:
+// - Remove single empty lines between sentences
+// - If there are multiple consecutive empty lines, keep just one
+func ProcessEmptyLines(content string) string {
+	// Split content by newline
+	lines := strings.Split(content, "\n")
+
+	// Process lines
+	var result []string
+	emptyLineCount := 0
+	inDiff := false
+
+	for i, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+
+		if inDiff || strings.HasPrefix(trimmedLine, "```diff") {
+			// Context lines of a patch may consist of a single space, so diff blocks are kept as they are
+			result = append(result, line)
+			if !inDiff {
+				inDiff = true
+			} else if trimmedLine == "```" {
+				inDiff = false
+			}
+			emptyLineCount = 0
+		} else if trimmedLine == "" {
+			// This is an empty line
+			emptyLineCount++
+
+			// Skip single empty lines
+			if emptyLineCount == 1 {
+				// Keep the first empty line after frontmatter
+				if i > 0 && strings.TrimSpace(lines[i-1]) == "---" {
+					result = append(result, line)
+				}
+				// Otherwise, skip it
+			} else if emptyLineCount == 2 {
+				// For multiple consecutive empty lines, keep one
+				result = append(result, line)
+			}
+			// Skip any additional empty lines
+		} else {
+			// This is a non-empty line
+			result = append(result, line)
+			emptyLineCount = 0
+		}
+	}
+
+	// Join lines back together
+	return strings.Join(result, "\n")
+}
@@ -0,0 +1,180 @@
+package mdrender
+
+import "testing"
+
+func TestNormalizeHeadings(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "First heading becomes h2",
+			markdown: "# Intro  \n\nText  \n\n## Detail  \n",
+			expected: "## Intro  \n\nText  \n\n### Detail  \n",
+		},
+		{
+			name:     "Skipped levels are closed",
+			markdown: "# A  \n### B  \n## C  \n# D  \n",
+			expected: "## A  \n### B  \n### C  \n## D  \n",
+		},
+		{
+			name:     "Code and nested content are left alone",
+			markdown: "### A  \n```sh  \n# comment  \n```  \n> # quoted  \n",
+			expected: "## A  \n```sh  \n# comment  \n```  \n> # quoted  \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := NormalizeHeadings(tt.markdown); result != tt.expected {
+				t.Errorf("NormalizeHeadings() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripTitleHeading(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{name: "Repeated title", markdown: "# My Post  \n\nBody  \n", expected: "Body  \n"},
+		{name: "Formatted title", markdown: "\n## **my post**  \nBody  \n", expected: "Body  \n"},
+		{name: "Different heading", markdown: "# Intro  \n\nBody  \n", expected: "# Intro  \n\nBody  \n"},
+		{name: "Title not first", markdown: "Body  \n\n# My Post  \n", expected: "Body  \n\n# My Post  \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := StripTitleHeading(tt.markdown, "My Post"); result != tt.expected {
+				t.Errorf("StripTitleHeading() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFirstImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "No images",
+			input:    "Just text with a [link](https://example.com).",
+			expected: "",
+		},
+		{
+			name:     "First of several images",
+			input:    "Intro  \n\n![Image](/images/a.jpg)  \n\n![Image](/images/b.png)",
+			expected: "/images/a.jpg",
+		},
+		{
+			name:     "External image",
+			input:    "![photo](https://example.com/photo.jpg)",
+			expected: "https://example.com/photo.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FirstImage(tt.input)
+			if result != tt.expected {
+				t.Errorf("FirstImage() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessEmptyLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "Single empty line between paragraphs",
+			input: `---
+title: Test
+---
+
+First paragraph.
+
+Second paragraph.
+
+Third paragraph.`,
+			expected: `---
+title: Test
+---
+
+First paragraph.
+Second paragraph.
+Third paragraph.`,
+		},
+		{
+			name: "Multiple empty lines between paragraphs",
+			input: `---
+title: Test
+---
+
+First paragraph.
+
+
+Second paragraph.
+
+
+
+Third paragraph.`,
+			expected: `---
+title: Test
+---
+
+First paragraph.
+
+Second paragraph.
+
+Third paragraph.`,
+		},
+		{
+			name: "Mixed single and multiple empty lines",
+			input: `---
+title: Test
+---
+
+First paragraph.
+
+Second paragraph.
+
+
+Third paragraph.
+
+
+
+Fourth paragraph.
+
+Fifth paragraph.`,
+			expected: `---
+title: Test
+---
+
+First paragraph.
+Second paragraph.
+
+Third paragraph.
+
+Fourth paragraph.
+Fifth paragraph.`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ProcessEmptyLines(tt.input)
+			if result != tt.expected {
+				t.Errorf("ProcessEmptyLines() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
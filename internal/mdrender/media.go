@@ -0,0 +1,254 @@
+package mdrender
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// youTubeIDPattern matches the 11 character ID of a YouTube video
+var youTubeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// vimeoIDPattern matches the numeric ID of a Vimeo video
+var vimeoIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// videoPlayerURL returns the embeddable player URL of a YouTube or Vimeo video, or "" for other URLs
+func videoPlayerURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	var id string
+	switch host {
+	case "youtube.com", "m.youtube.com", "youtube-nocookie.com":
+		if parsed.Path == "/watch" {
+			id = parsed.Query().Get("v")
+		} else if len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts" || segments[0] == "live") {
+			id = segments[1]
+		}
+	case "youtu.be":
+		id = segments[0]
+	case "vimeo.com", "player.vimeo.com":
+		// vimeo.com/<id> or player.vimeo.com/video/<id>
+		if id := segments[len(segments)-1]; vimeoIDPattern.MatchString(id) {
+			return "https://player.vimeo.com/video/" + id
+		}
+		return ""
+	}
+	if !youTubeIDPattern.MatchString(id) {
+		return ""
+	}
+	return "https://www.youtube.com/embed/" + id
+}
+
+// renderIframe renders an embedded player, followed by the caption when there is one
+func renderIframe(src string, caption []notionapi.RichText) string {
+	title := PlainText(caption)
+	if title == "" {
+		title = "Embedded content"
+	}
+	iframe := fmt.Sprintf("<iframe src=\"%s\" title=\"%s\" width=\"560\" height=\"315\" loading=\"lazy\" allow=\"autoplay; encrypted-media; picture-in-picture; fullscreen\" allowfullscreen></iframe>  \n\n",
+		html.EscapeString(src), html.EscapeString(title))
+	if len(caption) > 0 {
+		iframe += RichText(caption) + "  \n\n"
+	}
+	return iframe
+}
+
+// renderLinkBlock renders a bookmark, or an embed that cannot be framed, as a markdown link
+// using the caption as the link text when there is one
+func renderLinkBlock(target string, caption []notionapi.RichText) string {
+	text := PlainText(caption)
+	if text == "" {
+		text = target
+	}
+	text = strings.NewReplacer("[", "\\[", "]", "\\]").Replace(text)
+	return fmt.Sprintf("[%s](%s)  \n\n", text, markdownLinkURL(target))
+}
+
+// markdownLinkURL escapes the characters that would end a markdown link destination
+func markdownLinkURL(target string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(target)
+}
+
+// video renders a video block: YouTube and Vimeo videos as players, uploaded videos as
+// <video> elements pointing to the file saved by File, and other external videos as links
+func (r Renderer) video(video notionapi.Video) string {
+	caption := video.Caption
+	switch video.Type {
+	case notionapi.FileTypeExternal:
+		if video.External == nil {
+			return ""
+		}
+		if player := videoPlayerURL(video.External.URL); player != "" {
+			return renderIframe(player, caption)
+		}
+		return renderLinkBlock(video.External.URL, caption)
+	case notionapi.FileTypeFile:
+		if video.File == nil {
+			return ""
+		}
+		// Uploaded files are served from expiring URLs, so the video is saved next to the images
+		src, err := r.File(video.File.URL, false)
+		if err != nil {
+			logf(r.Logger, slog.LevelWarn, "Failed to download video: %v", err)
+			return renderLinkBlock(video.File.URL, caption)
+		}
+		rendered := fmt.Sprintf("<video src=\"%s\" controls preload=\"metadata\"></video>  \n\n", html.EscapeString(src))
+		if len(caption) > 0 {
+			rendered += RichText(caption) + "  \n\n"
+		}
+		return rendered
+	}
+	return ""
+}
+
+// imageHints are the layout directives given at the end of an image caption, such as
+// "Sunset |wide |w=800"
+type imageHints struct {
+	align  string // "left", "right" or "center"
+	size   string // "wide" or "full"
+	width  int
+	height int
+}
+
+// empty reports whether no directive was given
+func (h imageHints) empty() bool {
+	return h == imageHints{}
+}
+
+// imageCaptionHints splits the trailing |directives off an image caption. Directives are read
+// from the end; the first segment that is not a directive ends them, so a caption that
+// merely contains | is kept as it is.
+func imageCaptionHints(caption string) (string, imageHints) {
+	var hints imageHints
+	segments := strings.Split(caption, "|")
+	for len(segments) > 1 {
+		directive := strings.ToLower(strings.TrimSpace(segments[len(segments)-1]))
+		if !hints.set(directive) {
+			break
+		}
+		segments = segments[:len(segments)-1]
+	}
+	return strings.TrimSpace(strings.Join(segments, "|")), hints
+}
+
+// set applies a single directive and reports whether it is one
+func (h *imageHints) set(directive string) bool {
+	switch directive {
+	case "left", "right", "center":
+		h.align = directive
+		return true
+	case "wide", "full":
+		h.size = directive
+		return true
+	}
+	name, value, ok := strings.Cut(directive, "=")
+	if !ok {
+		return false
+	}
+	pixels, err := strconv.Atoi(strings.TrimSuffix(value, "px"))
+	if err != nil || pixels <= 0 {
+		return false
+	}
+	switch name {
+	case "w", "width":
+		h.width = pixels
+	case "h", "height":
+		h.height = pixels
+	default:
+		return false
+	}
+	return true
+}
+
+// altText returns the alt text of an image: its caption, or the ImageAltFallback text when it
+// has none
+func (r Renderer) altText(caption, imageURL string) string {
+	alt := caption
+	if alt == "" {
+		switch r.ImageAltFallback {
+		case "empty":
+			// Decorative images are skipped by screen readers
+			return ""
+		case "title":
+			alt = r.Title
+		case "filename":
+			name := path.Base(imageURL)
+			if parsed, err := url.Parse(imageURL); err == nil {
+				name = path.Base(parsed.Path)
+			}
+			if unescaped, err := url.PathUnescape(name); err == nil {
+				name = unescaped
+			}
+			alt = strings.TrimSuffix(name, path.Ext(name))
+		default:
+			alt = "Image"
+		}
+	}
+	// Brackets and line breaks would end the alt text early
+	alt = strings.Join(strings.Fields(alt), " ")
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(alt)
+}
+
+// renderImage renders an image with the markdown alt text from altText as markdown, or as
+// an <img> element carrying the caption directives as image-* classes and width/height attributes
+func renderImage(src, alt string, hints imageHints) string {
+	if hints.empty() {
+		return "![" + alt + "](" + src + ")  \n\n"
+	}
+	return imageElement(src, alt, hints) + "  \n\n"
+}
+
+// renderImageBlock renders an image followed by its caption as IMAGE_CAPTIONS asks: "text" adds
+// the caption as a paragraph below the image, "figure" wraps both in <figure> and <figcaption>.
+// caption is the caption without directives.
+func renderImageBlock(src, alt, caption string, richCaption []notionapi.RichText, hints imageHints, style string) string {
+	if caption == "" {
+		return renderImage(src, alt, hints)
+	}
+	switch style {
+	case "text":
+		text := html.EscapeString(caption)
+		if hints.empty() {
+			// Without directives the caption keeps its links and formatting
+			text = RichText(richCaption)
+		}
+		return renderImage(src, alt, hints) + text + "  \n\n"
+	case "figure":
+		return "<figure>\n" + imageElement(src, alt, hints) + "\n<figcaption>" + html.EscapeString(caption) + "</figcaption>\n</figure>  \n\n"
+	}
+	return renderImage(src, alt, hints)
+}
+
+// imageElement renders an <img> element for the markdown alt text from altText
+func imageElement(src, alt string, hints imageHints) string {
+	alt = strings.NewReplacer("\\[", "[", "\\]", "]").Replace(alt)
+	var classes []string
+	for _, name := range []string{hints.align, hints.size} {
+		if name != "" {
+			classes = append(classes, "image-"+name)
+		}
+	}
+	attributes := fmt.Sprintf(" src=\"%s\" alt=\"%s\"", html.EscapeString(src), html.EscapeString(alt))
+	if len(classes) > 0 {
+		attributes += fmt.Sprintf(" class=\"%s\"", strings.Join(classes, " "))
+	}
+	if hints.width > 0 {
+		attributes += fmt.Sprintf(" width=\"%d\"", hints.width)
+	}
+	if hints.height > 0 {
+		attributes += fmt.Sprintf(" height=\"%d\"", hints.height)
+	}
+	return "<img" + attributes + " />"
+}
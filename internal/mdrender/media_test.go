@@ -0,0 +1,107 @@
+package mdrender
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestVideoPlayerURL(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=10", "https://www.youtube.com/embed/dQw4w9WgXcQ"},
+		{"https://youtu.be/dQw4w9WgXcQ", "https://www.youtube.com/embed/dQw4w9WgXcQ"},
+		{"https://youtube.com/shorts/dQw4w9WgXcQ", "https://www.youtube.com/embed/dQw4w9WgXcQ"},
+		{"https://vimeo.com/76979871", "https://player.vimeo.com/video/76979871"},
+		{"https://player.vimeo.com/video/76979871", "https://player.vimeo.com/video/76979871"},
+		{"https://www.youtube.com/channel/UC123", ""},
+		{"https://vimeo.com/channels/staffpicks", ""},
+		{"https://example.com/video.mp4", ""},
+	}
+	for _, tt := range tests {
+		if got := videoPlayerURL(tt.url); got != tt.want {
+			t.Errorf("videoPlayerURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestImageAltText(t *testing.T) {
+	const imageURL = "https://example.com/files/My%20Photo.final.png?X-Amz-Signature=abc"
+	tests := []struct {
+		name     string
+		caption  string
+		fallback string
+		expected string
+	}{
+		{name: "Caption wins", caption: "A [cat]\non a mat", fallback: "empty", expected: `A \[cat\] on a mat`},
+		{name: "Default text", fallback: "image", expected: "Image"},
+		{name: "Decorative", fallback: "empty", expected: ""},
+		{name: "Page title", fallback: "title", expected: "Trip report"},
+		{name: "File name", fallback: "filename", expected: "My Photo.final"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := Renderer{ImageAltFallback: tt.fallback, Title: "Trip report"}
+			if alt := renderer.altText(tt.caption, imageURL); alt != tt.expected {
+				t.Errorf("altText() = %q, want %q", alt, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImageCaptionHints(t *testing.T) {
+	tests := []struct {
+		caption, wantCaption string
+		want                 imageHints
+	}{
+		{"Sunset |wide", "Sunset", imageHints{size: "wide"}},
+		{"Sunset | Right |w=400px| h=300", "Sunset", imageHints{align: "right", width: 400, height: 300}},
+		{"|center", "", imageHints{align: "center"}},
+		{"Input | output |full", "Input | output", imageHints{size: "full"}},
+		{"Input | output", "Input | output", imageHints{}},
+		{"Zoomed |w=0", "Zoomed |w=0", imageHints{}},
+	}
+	for _, tt := range tests {
+		caption, hints := imageCaptionHints(tt.caption)
+		if caption != tt.wantCaption || hints != tt.want {
+			t.Errorf("imageCaptionHints(%q) = %q, %+v; want %q, %+v", tt.caption, caption, hints, tt.wantCaption, tt.want)
+		}
+	}
+
+	if got, want := renderImage("/images/a.png", `A \[cat\]`, imageHints{}), "![A \\[cat\\]](/images/a.png)  \n\n"; got != want {
+		t.Errorf("renderImage() = %q, want %q", got, want)
+	}
+	got := renderImage("/images/a.png", `A \[cat\] & "dog"`, imageHints{align: "left", size: "wide", width: 400})
+	want := "<img src=\"/images/a.png\" alt=\"A [cat] &amp; &#34;dog&#34;\" class=\"image-left image-wide\" width=\"400\" />  \n\n"
+	if got != want {
+		t.Errorf("renderImage() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderImageBlock(t *testing.T) {
+	caption := []notionapi.RichText{
+		{PlainText: "Photo by "},
+		{PlainText: "Ann", Href: "https://example.com/ann"},
+	}
+	tests := []struct {
+		name, style, caption string
+		hints                imageHints
+		expected             string
+	}{
+		{name: "No caption line", style: "none", caption: "Photo by Ann", expected: "![Photo by Ann](/images/a.png)  \n\n"},
+		{name: "Text", style: "text", caption: "Photo by Ann", expected: "![Photo by Ann](/images/a.png)  \n\nPhoto by [Ann](https://example.com/ann)  \n\n"},
+		{name: "Text with directives", style: "text", caption: "Photo by Ann", hints: imageHints{size: "wide"}, expected: "<img src=\"/images/a.png\" alt=\"Photo by Ann\" class=\"image-wide\" />  \n\nPhoto by Ann  \n\n"},
+		{name: "Figure", style: "figure", caption: "Photo by Ann", expected: "<figure>\n<img src=\"/images/a.png\" alt=\"Photo by Ann\" />\n<figcaption>Photo by Ann</figcaption>\n</figure>  \n\n"},
+		{name: "Figure without caption", style: "figure", expected: "![Image](/images/a.png)  \n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alt := Renderer{ImageAltFallback: "image"}.altText(tt.caption, "/images/a.png")
+			if result := renderImageBlock("/images/a.png", alt, tt.caption, caption, tt.hints, tt.style); result != tt.expected {
+				t.Errorf("renderImageBlock() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
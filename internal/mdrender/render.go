@@ -0,0 +1,348 @@
+package mdrender
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// Renderer converts the blocks of a page to markdown. What takes more than the blocks
+// themselves, fetching nested blocks, saving files and building breadcrumbs, is left to its
+// hooks, so the renderer neither calls Notion nor writes files.
+type Renderer struct {
+	LineBreakStyle       string // "spaces" (trailing double space) or "br" (<br/>) for newlines inside a block
+	EmptyParagraphs      string // "br" renders empty paragraphs as <br/>
+	NumberedListContinue bool   // Continue numbering when a numbered list resumes after other blocks
+	CalloutStyle         string // "blockquote", "html", "aside" or "component", see Callout and CalloutComponent
+	CalloutComponent     string // Name of the MDX component of CalloutStyle "component"
+	ImageCaptions        string // Visible image captions: "none", "text" (paragraph below the image) or "figure" (<figcaption>)
+	ImageAltFallback     string // Alt text of images without a caption: "image" ("Image"), "empty" (decorative), "title" or "filename"
+	Title                string // Title of the page, the alt text of ImageAltFallback "title"
+	ChildPages           bool   // Child pages are exported as files of their own; otherwise they are counted as unsupported
+	Logger               *slog.Logger
+
+	// Children returns the nested blocks of block
+	Children func(block notionapi.Block) ([]notionapi.Block, error)
+	// File saves an image or an uploaded video and returns the URL it is linked with
+	File func(fileURL string, external bool) (string, error)
+	// Breadcrumb returns the titles of the ancestors of the page followed by its own, root
+	// first; nil leaves breadcrumb blocks out
+	Breadcrumb func() ([]string, error)
+	// Unsupported is called with the type of each block that is left out; nil ignores them
+	Unsupported func(blockType string)
+}
+
+// Blocks converts sibling blocks to markdown, and the blocks nested in them
+func (r Renderer) Blocks(blocks []notionapi.Block) (string, error) {
+	var markdown strings.Builder
+	currentList := ""
+	listNumber := 0
+	for i, block := range blocks {
+		// Process each block based on its type
+		blockType := block.GetType()
+		logf(r.Logger, slog.LevelDebug, "Processing block %d of %d (type: %s)", i+1, len(blocks), blockType)
+
+		// Surround each list group with a blank line so it is not merged with neighbouring
+		// paragraphs. Two newlines are needed because ProcessEmptyLines drops single empty lines.
+		if kind := listKind(blockType); kind != currentList {
+			if currentList != "" {
+				markdown.WriteString("\n\n")
+			} else if markdown.Len() > 0 {
+				markdown.WriteString("\n")
+			}
+			if kind == "numbered" && !r.NumberedListContinue {
+				listNumber = 0
+			}
+			currentList = kind
+		}
+
+		// A resumed numbered list only continues within the same section
+		switch blockType {
+		case notionapi.BlockTypeHeading1, notionapi.BlockTypeHeading2, notionapi.BlockTypeHeading3, notionapi.BlockTypeDivider:
+			listNumber = 0
+		}
+
+		switch blockType {
+		case "paragraph":
+			if paragraph, ok := block.(*notionapi.ParagraphBlock); ok {
+				text := HardBreaks(RichText(paragraph.Paragraph.RichText), r.LineBreakStyle)
+				if text == "" && r.EmptyParagraphs == "br" {
+					// Keep the spacing the author added, even where blank lines are collapsed
+					text = "<br/>"
+				}
+				markdown.WriteString(text + "  \n\n")
+			}
+		case "heading_1", "heading_2", "heading_3":
+			var heading notionapi.Heading
+			level := 0
+			switch b := block.(type) {
+			case *notionapi.Heading1Block:
+				heading, level = b.Heading1, 1
+			case *notionapi.Heading2Block:
+				heading, level = b.Heading2, 2
+			case *notionapi.Heading3Block:
+				heading, level = b.Heading3, 3
+			}
+			if level == 0 {
+				break
+			}
+			nested, err := r.children(block)
+			if err != nil {
+				return "", err
+			}
+			if !heading.IsToggleable {
+				markdown.WriteString(strings.Repeat("#", level) + " " + RichText(heading.RichText) + "  \n\n")
+				if nested != "" {
+					markdown.WriteString(nested + "  \n\n")
+				}
+				break
+			}
+			// Toggle headings keep their content collapsed under the heading
+			summary := fmt.Sprintf("<h%d>%s</h%d>", level, html.EscapeString(PlainText(heading.RichText)), level)
+			markdown.WriteString(Details(summary, nested))
+		case "bulleted_list_item":
+			if item, ok := block.(*notionapi.BulletedListItemBlock); ok {
+				text := HardBreaks(RichText(item.BulletedListItem.RichText), r.LineBreakStyle)
+				nested, err := r.children(block)
+				if err != nil {
+					return "", err
+				}
+				markdown.WriteString("- " + text + "  \n")
+				if nested != "" {
+					markdown.WriteString(Indent(nested, "  ") + "  \n")
+				}
+			}
+		case "numbered_list_item":
+			if item, ok := block.(*notionapi.NumberedListItemBlock); ok {
+				text := HardBreaks(RichText(item.NumberedListItem.RichText), r.LineBreakStyle)
+				listNumber++
+				nested, err := r.children(block)
+				if err != nil {
+					return "", err
+				}
+				marker := fmt.Sprintf("%d. ", listNumber)
+				markdown.WriteString(marker + text + "  \n")
+				if nested != "" {
+					// Nested content is aligned with the text after the number
+					markdown.WriteString(Indent(nested, strings.Repeat(" ", len(marker))) + "  \n")
+				}
+			}
+		case "to_do":
+			if todo, ok := block.(*notionapi.ToDoBlock); ok {
+				text := HardBreaks(RichText(todo.ToDo.RichText), r.LineBreakStyle)
+				nested, err := r.children(block)
+				if err != nil {
+					return "", err
+				}
+				if todo.ToDo.Checked {
+					markdown.WriteString("- [x] " + text + "  \n")
+				} else {
+					markdown.WriteString("- [ ] " + text + "  \n")
+				}
+				if nested != "" {
+					markdown.WriteString(Indent(nested, "  ") + "  \n")
+				}
+			}
+		case "code":
+			if code, ok := block.(*notionapi.CodeBlock); ok {
+				markdown.WriteString(Code(code.Code))
+			}
+		case "quote":
+			if quote, ok := block.(*notionapi.QuoteBlock); ok {
+				text := HardBreaks(RichText(quote.Quote.RichText), r.LineBreakStyle)
+				nested, err := r.children(block)
+				if err != nil {
+					return "", err
+				}
+				if nested != "" {
+					text += "  \n\n" + nested
+				}
+				// Keep every line of a multi-line quote inside the blockquote
+				text = strings.ReplaceAll(text, "\n", "\n> ")
+				markdown.WriteString("> " + text + "  \n\n")
+			}
+		case "callout":
+			if callout, ok := block.(*notionapi.CalloutBlock); ok {
+				text := HardBreaks(RichText(callout.Callout.RichText), r.LineBreakStyle)
+				nested, err := r.children(block)
+				if err != nil {
+					return "", err
+				}
+				if nested != "" {
+					text += "  \n\n" + nested
+				}
+				if r.CalloutStyle == "component" {
+					markdown.WriteString(CalloutComponent(text, CalloutIcon(callout.Callout.Icon), r.CalloutComponent))
+				} else {
+					markdown.WriteString(Callout(text, CalloutIcon(callout.Callout.Icon), r.CalloutStyle))
+				}
+			}
+		case "toggle":
+			if toggle, ok := block.(*notionapi.ToggleBlock); ok {
+				nested, err := r.children(block)
+				if err != nil {
+					return "", err
+				}
+				markdown.WriteString(Details(html.EscapeString(PlainText(toggle.Toggle.RichText)), nested))
+			}
+		case "table":
+			if table, ok := block.(*notionapi.TableBlock); ok {
+				rows, err := r.Children(block)
+				if err != nil {
+					return "", fmt.Errorf("failed to retrieve rows of table %s: %w", block.GetID(), err)
+				}
+				// The blank line keeps the table from being read as part of the previous paragraph
+				if markdown.Len() > 0 {
+					markdown.WriteString("\n")
+				}
+				markdown.WriteString(Table(table.Table, rows) + "\n\n")
+			}
+		case "column_list", "column":
+			// Columns are rendered one after another below
+		case "divider":
+			markdown.WriteString("---  \n\n")
+		case "breadcrumb":
+			if r.Breadcrumb == nil {
+				logf(r.Logger, slog.LevelDebug, "Ignoring breadcrumb block %s", block.GetID())
+				break
+			}
+			trail, err := r.Breadcrumb()
+			if err != nil {
+				logf(r.Logger, slog.LevelWarn, "Failed to build breadcrumb trail: %v", err)
+				break
+			}
+			markdown.WriteString(strings.Join(trail, " / ") + "  \n\n")
+		case "child_page":
+			if r.ChildPages {
+				// Exported as a file of its own
+				logf(r.Logger, slog.LevelDebug, "Child page %s is exported separately", block.GetID())
+			} else {
+				logf(r.Logger, slog.LevelDebug, "Skipping child page %s: only exported in pages mode", block.GetID())
+				r.unsupported(string(blockType))
+			}
+		case "template":
+			// Template buttons only make sense inside Notion
+			logf(r.Logger, slog.LevelDebug, "Skipping template block %s", block.GetID())
+			r.unsupported(string(blockType))
+		case "image":
+			if image, ok := block.(*notionapi.ImageBlock); ok {
+				var imageURL string
+				external := image.Image.Type == "external"
+				if external {
+					imageURL = image.Image.External.URL
+				} else if image.Image.Type == "file" {
+					imageURL = image.Image.File.URL
+				}
+
+				if imageURL != "" {
+					// Directives such as |wide or |w=400 are stripped from the caption
+					caption, hints := imageCaptionHints(PlainText(image.Image.Caption))
+					alt := r.altText(caption, imageURL)
+					// Save the image and link it where it is served from
+					src, err := r.File(imageURL, external)
+					if err != nil {
+						logf(r.Logger, slog.LevelWarn, "Failed to download image: %v", err)
+						// If download fails, use the original URL
+						src = imageURL
+					}
+					markdown.WriteString(renderImageBlock(src, alt, caption, image.Image.Caption, hints, r.ImageCaptions))
+				}
+			}
+		case "equation":
+			if equation, ok := block.(*notionapi.EquationBlock); ok && strings.TrimSpace(equation.Equation.Expression) != "" {
+				// Display math for remark-math and rehype-katex
+				markdown.WriteString("$$\n" + strings.TrimSpace(equation.Equation.Expression) + "\n$$  \n\n")
+			}
+		case "video":
+			if video, ok := block.(*notionapi.VideoBlock); ok {
+				markdown.WriteString(r.video(video.Video))
+			}
+		case "embed":
+			if embed, ok := block.(*notionapi.EmbedBlock); ok && embed.Embed.URL != "" {
+				// Only video players are framed; most other sites refuse to be embedded
+				if player := videoPlayerURL(embed.Embed.URL); player != "" {
+					markdown.WriteString(renderIframe(player, embed.Embed.Caption))
+				} else {
+					markdown.WriteString(renderLinkBlock(embed.Embed.URL, embed.Embed.Caption))
+				}
+			}
+		case "bookmark":
+			if bookmark, ok := block.(*notionapi.BookmarkBlock); ok && bookmark.Bookmark.URL != "" {
+				markdown.WriteString(renderLinkBlock(bookmark.Bookmark.URL, bookmark.Bookmark.Caption))
+			}
+		case "unsupported", "":
+			// Button blocks and newer block types are returned without a type by the API client
+			logf(r.Logger, slog.LevelDebug, "Skipping unsupported block %s (button or other interactive block)", block.GetID())
+			r.unsupported("unsupported")
+		default:
+			logf(r.Logger, slog.LevelDebug, "Skipping %s block %s: not supported", blockType, block.GetID())
+			r.unsupported(string(blockType))
+		}
+
+		// Nested blocks of paragraphs and columns follow their parent without indentation
+		switch blockType {
+		case notionapi.BlockTypeParagraph, notionapi.BlockTypeColumnList, notionapi.BlockTypeColumn:
+			nested, err := r.children(block)
+			if err != nil {
+				return "", err
+			}
+			if nested != "" {
+				markdown.WriteString(nested + "  \n\n")
+			}
+		}
+	}
+
+	return markdown.String(), nil
+}
+
+// children fetches and converts the nested blocks of block, or returns "" if it has none.
+// Trailing whitespace is trimmed so the caller decides how the nested content ends.
+func (r Renderer) children(block notionapi.Block) (string, error) {
+	if !block.GetHasChildren() {
+		return "", nil
+	}
+	logf(r.Logger, slog.LevelDebug, "Fetching nested blocks of %s", block.GetID())
+	children, err := r.Children(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve nested blocks of %s: %w", block.GetID(), err)
+	}
+	markdown, err := r.Blocks(children)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(markdown, " \n"), nil
+}
+
+// unsupported reports a block that is left out
+func (r Renderer) unsupported(blockType string) {
+	if r.Unsupported != nil {
+		r.Unsupported(blockType)
+	}
+}
+
+// listKind returns the list a block belongs to ("bulleted" or "numbered"), or "" for non-list blocks
+func listKind(blockType notionapi.BlockType) string {
+	switch blockType {
+	case notionapi.BlockTypeBulletedListItem, notionapi.BlockTypeToDo:
+		return "bulleted"
+	case notionapi.BlockTypeNumberedListItem:
+		return "numbered"
+	}
+	return ""
+}
+
+// logf formats and logs a message through logger when level is enabled. A nil logger logs
+// through the default slog logger.
+func logf(logger *slog.Logger, level slog.Level, format string, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,66 @@
+package mdrender
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestRendererHooks(t *testing.T) {
+	item := &notionapi.BulletedListItemBlock{
+		BasicBlock:       notionapi.BasicBlock{ID: "item", Type: notionapi.BlockTypeBulletedListItem, HasChildren: true},
+		BulletedListItem: notionapi.ListItem{RichText: richText("Parent")},
+	}
+	nested := &notionapi.ParagraphBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeParagraph},
+		Paragraph:  notionapi.Paragraph{RichText: richText("Child")},
+	}
+	saved := &notionapi.ImageBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeImage},
+		Image:      notionapi.Image{Type: "external", External: &notionapi.FileObject{URL: "https://example.com/a.png"}},
+	}
+	failed := &notionapi.ImageBlock{
+		BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeImage},
+		Image:      notionapi.Image{Type: "file", File: &notionapi.FileObject{URL: "https://example.com/b.png"}},
+	}
+	breadcrumb := &notionapi.BreadcrumbBlock{BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeBreadcrumb}}
+	template := &notionapi.TemplateBlock{BasicBlock: notionapi.BasicBlock{Type: notionapi.BlockTypeTemplate}}
+
+	var unsupported []string
+	renderer := Renderer{
+		LineBreakStyle:   "spaces",
+		ImageAltFallback: "image",
+		Children: func(block notionapi.Block) ([]notionapi.Block, error) {
+			if block.GetID() != "item" {
+				t.Errorf("Children(%s) called", block.GetID())
+			}
+			return []notionapi.Block{nested}, nil
+		},
+		File: func(fileURL string, external bool) (string, error) {
+			if !external {
+				return "", errors.New("expired")
+			}
+			return "/images/a.png", nil
+		},
+		Unsupported: func(blockType string) { unsupported = append(unsupported, blockType) },
+	}
+	blocks := []notionapi.Block{item, saved, failed, breadcrumb, template}
+	markdown, err := renderer.Blocks(blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "- Parent  \n  Child  \n\n\n![Image](/images/a.png)  \n\n![Image](https://example.com/b.png)  \n\n"
+	if markdown != expected {
+		t.Errorf("Blocks() = %q, want %q", markdown, expected)
+	}
+	if len(unsupported) != 1 || unsupported[0] != "template" {
+		t.Errorf("unsupported blocks = %v, want [template]", unsupported)
+	}
+
+	// Breadcrumbs are only rendered with a hook
+	renderer.Breadcrumb = func() ([]string, error) { return []string{"Home", "Post"}, nil }
+	if markdown, err := renderer.Blocks([]notionapi.Block{breadcrumb}); err != nil || markdown != "Home / Post  \n\n" {
+		t.Errorf("Blocks() = %q, %v; want the trail", markdown, err)
+	}
+}
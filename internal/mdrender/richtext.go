@@ -0,0 +1,134 @@
+// Package mdrender renders Notion rich text and blocks as Markdown and post-processes the
+// Markdown of a page body. It neither fetches from Notion nor writes files; a Renderer walks
+// the blocks of a page and leaves fetching nested blocks and saving images to the hooks the
+// converter package gives it.
+package mdrender
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jomei/notionapi"
+)
+
+// RichText extracts text from rich text, preserving links
+func RichText(richText []notionapi.RichText) string {
+	var text strings.Builder
+	for _, rt := range mergeRichText(richText) {
+		if rt.Equation != nil {
+			// Inline math for remark-math; annotations do not apply inside it
+			text.WriteString(inlineEquation(rt.Equation.Expression))
+			continue
+		}
+		formatted := formatAnnotations(rt.PlainText, rt.Annotations)
+		// Check if this rich text has a link
+		if rt.Href != "" {
+			// Format as markdown link: [text](url)
+			text.WriteString(fmt.Sprintf("[%s](%s)", formatted, rt.Href))
+		} else {
+			text.WriteString(formatted)
+		}
+	}
+	return text.String()
+}
+
+// inlineEquation renders a LaTeX expression as $...$ inline math
+func inlineEquation(expression string) string {
+	return "$" + strings.TrimSpace(expression) + "$"
+}
+
+// mergeRichText joins neighbouring runs with the same annotations and link, so a bold phrase
+// split into several runs by Notion becomes one **phrase** instead of **a****b**
+func mergeRichText(richText []notionapi.RichText) []notionapi.RichText {
+	var merged []notionapi.RichText
+	for _, rt := range richText {
+		if n := len(merged); n > 0 && merged[n-1].Href == rt.Href && sameAnnotations(merged[n-1].Annotations, rt.Annotations) &&
+			merged[n-1].Equation == nil && rt.Equation == nil {
+			merged[n-1].PlainText += rt.PlainText
+			continue
+		}
+		merged = append(merged, rt)
+	}
+	return merged
+}
+
+// sameAnnotations reports whether two runs are formatted the same way in markdown (colors are not rendered)
+func sameAnnotations(a, b *notionapi.Annotations) bool {
+	var x, y notionapi.Annotations
+	if a != nil {
+		x = *a
+	}
+	if b != nil {
+		y = *b
+	}
+	x.Color, y.Color = "", ""
+	return x == y
+}
+
+// formatAnnotations wraps text in the markdown for its bold, italic, strikethrough, underline
+// and code annotations. Markdown emphasis must not start or end with a space, so surrounding
+// whitespace is kept outside the markers.
+func formatAnnotations(text string, annotations *notionapi.Annotations) string {
+	core := strings.TrimSpace(text)
+	if annotations == nil || core == "" {
+		return text
+	}
+	leading := text[:strings.Index(text, core)]
+	trailing := text[len(leading)+len(core):]
+
+	if annotations.Code {
+		if strings.Contains(core, "`") {
+			core = "`` " + core + " ``"
+		} else {
+			core = "`" + core + "`"
+		}
+	}
+	if annotations.Strikethrough {
+		core = "~~" + core + "~~"
+	}
+	if annotations.Italic {
+		core = "*" + core + "*"
+	}
+	if annotations.Bold {
+		core = "**" + core + "**"
+	}
+	if annotations.Underline {
+		// Markdown has no underline
+		core = "<u>" + core + "</u>"
+	}
+	return leading + core + trailing
+}
+
+// inlineFormattingPattern matches the markers written by formatAnnotations
+var inlineFormattingPattern = regexp.MustCompile("\\*+|~~|`+|</?u>")
+
+// PlainText concatenates the plain text of rich text
+func PlainText(richText []notionapi.RichText) string {
+	var text strings.Builder
+	for _, rt := range richText {
+		text.WriteString(rt.PlainText)
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// StripFormatting removes the bold, italic, strikethrough, underline and code markers of
+// RichText from text
+func StripFormatting(text string) string {
+	return inlineFormattingPattern.ReplaceAllString(text, "")
+}
+
+// HardBreaks turns newlines inside a block (shift-enter in Notion) into markdown hard breaks
+func HardBreaks(text, style string) string {
+	if style == "br" {
+		return strings.ReplaceAll(text, "\n", "<br/>\n")
+	}
+	return strings.ReplaceAll(text, "\n", "  \n")
+}
+
+// LinksToPlainText converts markdown links [text](url) to plain text (text only)
+func LinksToPlainText(text string) string {
+	// Regular expression to match markdown links: [text](url)
+	re := regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	return re.ReplaceAllString(text, "$1")
+}
@@ -0,0 +1,141 @@
+package mdrender
+
+import (
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+func TestRichTextAnnotations(t *testing.T) {
+	annotated := func(text string, annotations notionapi.Annotations) notionapi.RichText {
+		return notionapi.RichText{PlainText: text, Annotations: &annotations}
+	}
+	tests := []struct {
+		name     string
+		richText []notionapi.RichText
+		expected string
+	}{
+		{
+			name: "Each annotation",
+			richText: []notionapi.RichText{
+				annotated("bold", notionapi.Annotations{Bold: true}), {PlainText: ", "},
+				annotated("italic", notionapi.Annotations{Italic: true}), {PlainText: ", "},
+				annotated("gone", notionapi.Annotations{Strikethrough: true}), {PlainText: ", "},
+				annotated("under", notionapi.Annotations{Underline: true}), {PlainText: ", "},
+				annotated("go test", notionapi.Annotations{Code: true}),
+			},
+			expected: "**bold**, *italic*, ~~gone~~, <u>under</u>, `go test`",
+		},
+		{
+			name:     "Whitespace stays outside the markers",
+			richText: []notionapi.RichText{{PlainText: "a"}, annotated(" b ", notionapi.Annotations{Bold: true, Italic: true}), {PlainText: "c"}},
+			expected: "a ***b*** c",
+		},
+		{
+			name: "Runs with the same annotations are merged",
+			richText: []notionapi.RichText{
+				annotated("one ", notionapi.Annotations{Bold: true, Color: "red"}),
+				annotated("two", notionapi.Annotations{Bold: true}),
+			},
+			expected: "**one two**",
+		},
+		{
+			name:     "Code containing backticks and links",
+			richText: []notionapi.RichText{annotated("a`b", notionapi.Annotations{Code: true}), {PlainText: " "}, {PlainText: "site", Href: "https://example.com", Annotations: &notionapi.Annotations{Bold: true}}},
+			expected: "`` a`b `` [**site**](https://example.com)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := RichText(tt.richText); result != tt.expected {
+				t.Errorf("RichText() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHardBreaks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		style    string
+		expected string
+	}{
+		{
+			name:     "No newlines",
+			input:    "One line",
+			style:    "spaces",
+			expected: "One line",
+		},
+		{
+			name:     "Trailing spaces style",
+			input:    "First line\nSecond line",
+			style:    "spaces",
+			expected: "First line  \nSecond line",
+		},
+		{
+			name:     "Br style",
+			input:    "一行目\n二行目\n三行目",
+			style:    "br",
+			expected: "一行目<br/>\n二行目<br/>\n三行目",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := HardBreaks(tt.input, tt.style)
+			if result != tt.expected {
+				t.Errorf("HardBreaks() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLinksToPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "No markdown links",
+			input:    "This is a text without markdown links.",
+			expected: "This is a text without markdown links.",
+		},
+		{
+			name:     "Single markdown link",
+			input:    "[aaa](https://www.kechiiiiin.com/)は〇〇だ",
+			expected: "aaaは〇〇だ",
+		},
+		{
+			name:     "Multiple markdown links",
+			input:    "[aaa](https://www.kechiiiiin.com/)は[bbb](https://example.com)だ",
+			expected: "aaaはbbbだ",
+		},
+		{
+			name:     "Markdown link with Japanese text",
+			input:    "[日本語](https://example.jp/)のテキスト",
+			expected: "日本語のテキスト",
+		},
+		{
+			name:     "Text with brackets but not a markdown link",
+			input:    "This [is] not a markdown link.",
+			expected: "This [is] not a markdown link.",
+		},
+		{
+			name:     "Text with parentheses but not a markdown link",
+			input:    "This (is) not a markdown link.",
+			expected: "This (is) not a markdown link.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := LinksToPlainText(tt.input)
+			if result != tt.expected {
+				t.Errorf("LinksToPlainText() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package notion
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrRequestBudgetExceeded is returned for the requests refused by the request budget of a
+// Transport
+var ErrRequestBudgetExceeded = errors.New("NOTION_MAX_REQUESTS reached")
+
+// requestBudget caps the Notion API requests of a run (NOTION_MAX_REQUESTS), so a misconfigured
+// export of a huge workspace stops early instead of using up the rate limit for hours. It sends
+// up to limit requests and refuses the requests after them; every retry counts. A zero limit
+// does not limit requests.
+type requestBudget struct {
+	base  http.RoundTripper
+	limit int
+
+	mu       sync.Mutex
+	sent     int
+	exceeded bool // A request was refused
+}
+
+// Sent returns the number of requests sent so far
+func (b *requestBudget) Sent() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sent
+}
+
+// Exceeded reports whether a request was refused
+func (b *requestBudget) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exceeded
+}
+
+// RoundTrip sends req unless the limit has been reached
+func (b *requestBudget) RoundTrip(req *http.Request) (*http.Response, error) {
+	b.mu.Lock()
+	if b.limit > 0 && b.sent >= b.limit {
+		b.exceeded = true
+		b.mu.Unlock()
+		return nil, ErrRequestBudgetExceeded
+	}
+	b.sent++
+	b.mu.Unlock()
+	return b.base.RoundTrip(req)
+}
@@ -0,0 +1,48 @@
+package notion
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestBudget(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The retries of a request count against the budget and stop when it is used up
+	budget := &requestBudget{base: http.DefaultTransport, limit: 2}
+	client := &http.Client{Transport: &retryTransport{base: budget, maxAttempts: 5, baseDelay: time.Millisecond}}
+	if budget.Exceeded() {
+		t.Error("expected the budget not to be exceeded before the limit is reached")
+	}
+	_, err := client.Get(server.URL + "/v1/blocks/abc/children")
+	if !errors.Is(err, ErrRequestBudgetExceeded) {
+		t.Errorf("error = %v, want the budget error", err)
+	}
+	if requests != 2 || budget.Sent() != 2 {
+		t.Errorf("sent %d requests (counted %d), want 2", requests, budget.Sent())
+	}
+	if !budget.Exceeded() {
+		t.Error("Exceeded() = false after a refused request")
+	}
+
+	// Without a limit every request is sent
+	budget = &requestBudget{base: http.DefaultTransport}
+	for i := 0; i < 3; i++ {
+		resp, err := (&http.Client{Transport: budget}).Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if budget.Sent() != 3 || budget.Exceeded() {
+		t.Errorf("sent %d requests (exceeded: %v), want 3 within the budget", budget.Sent(), budget.Exceeded())
+	}
+}
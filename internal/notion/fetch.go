@@ -0,0 +1,59 @@
+package notion
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jomei/notionapi"
+)
+
+// FetchBlockChildren returns the child blocks of a page or block, following next_cursor
+// since the API returns at most 100 blocks per request
+func FetchBlockChildren(ctx context.Context, client *notionapi.Client, blockID notionapi.BlockID, logger *slog.Logger) ([]notionapi.Block, error) {
+	var blocks []notionapi.Block
+	pagination := &notionapi.Pagination{PageSize: 100}
+	for {
+		resp, err := client.Block.GetChildren(ctx, blockID, pagination)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, resp.Results...)
+		if !resp.HasMore || resp.NextCursor == "" {
+			return blocks, nil
+		}
+		logf(logger, slog.LevelDebug, "Fetched %d blocks of %s; fetching more", len(blocks), blockID)
+		pagination.StartCursor = notionapi.Cursor(resp.NextCursor)
+	}
+}
+
+// StreamDatabasePages queries all result pages of a database in the background, following
+// the cursor, and sends each page as soon as its result page is fetched. The next result
+// page is requested while the previous one is being processed. Canceling ctx stops the query.
+func StreamDatabasePages(ctx context.Context, client *notionapi.Client, databaseID notionapi.DatabaseID, query *notionapi.DatabaseQueryRequest, logger *slog.Logger) (<-chan notionapi.Page, <-chan error) {
+	pages := make(chan notionapi.Page, query.PageSize)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(pages)
+
+		request := *query
+		for {
+			resp, err := client.Database.Query(ctx, databaseID, &request)
+			if err != nil {
+				errs <- err
+				return
+			}
+			logf(logger, slog.LevelDebug, "Fetched %d pages from database %s", len(resp.Results), databaseID)
+			for _, page := range resp.Results {
+				pages <- page
+			}
+			if !resp.HasMore || resp.NextCursor == "" {
+				return
+			}
+			request.StartCursor = resp.NextCursor
+		}
+	}()
+
+	return pages, errs
+}
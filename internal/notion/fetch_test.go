@@ -0,0 +1,86 @@
+package notion
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// fakeDatabaseService returns the query results in pages of two, following the cursor
+type fakeDatabaseService struct {
+	notionapi.DatabaseService
+	pages   []notionapi.Page
+	cursors []notionapi.Cursor
+}
+
+func (f *fakeDatabaseService) Query(_ context.Context, _ notionapi.DatabaseID, request *notionapi.DatabaseQueryRequest) (*notionapi.DatabaseQueryResponse, error) {
+	f.cursors = append(f.cursors, request.StartCursor)
+	start := 0
+	if request.StartCursor != "" {
+		start, _ = strconv.Atoi(string(request.StartCursor))
+	}
+	end := start + 2
+	if end >= len(f.pages) {
+		return &notionapi.DatabaseQueryResponse{Results: f.pages[start:]}, nil
+	}
+	return &notionapi.DatabaseQueryResponse{Results: f.pages[start:end], HasMore: true, NextCursor: notionapi.Cursor(strconv.Itoa(end))}, nil
+}
+
+func TestStreamDatabasePages(t *testing.T) {
+	database := &fakeDatabaseService{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		database.pages = append(database.pages, notionapi.Page{ID: notionapi.ObjectID(id)})
+	}
+	client := &notionapi.Client{Database: database}
+
+	pages, errs := StreamDatabasePages(context.Background(), client, "db", &notionapi.DatabaseQueryRequest{PageSize: 2}, nil)
+	var ids []string
+	for page := range pages {
+		ids = append(ids, page.ID.String())
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(ids, "") != "abcde" {
+		t.Errorf("streamed pages = %v, want a..e in order", ids)
+	}
+	if !reflect.DeepEqual(database.cursors, []notionapi.Cursor{"", "2", "4"}) {
+		t.Errorf("query cursors = %v", database.cursors)
+	}
+}
+
+// pagedBlockService returns the children in pages of two, following the cursor
+type pagedBlockService struct {
+	notionapi.BlockService
+	blocks  []notionapi.Block
+	cursors []notionapi.Cursor
+}
+
+func (f *pagedBlockService) GetChildren(_ context.Context, _ notionapi.BlockID, pagination *notionapi.Pagination) (*notionapi.GetChildrenResponse, error) {
+	f.cursors = append(f.cursors, pagination.StartCursor)
+	start, _ := strconv.Atoi(string(pagination.StartCursor))
+	end := start + 2
+	if end >= len(f.blocks) {
+		return &notionapi.GetChildrenResponse{Results: f.blocks[start:]}, nil
+	}
+	return &notionapi.GetChildrenResponse{Results: f.blocks[start:end], HasMore: true, NextCursor: strconv.Itoa(end)}, nil
+}
+
+func TestFetchBlockChildrenPagination(t *testing.T) {
+	service := &pagedBlockService{blocks: []notionapi.Block{&notionapi.ParagraphBlock{}, &notionapi.ParagraphBlock{}, &notionapi.ParagraphBlock{}}}
+	blocks, err := FetchBlockChildren(context.Background(), &notionapi.Client{Block: service}, "page", nil)
+	if err != nil {
+		t.Fatalf("FetchBlockChildren() error = %v", err)
+	}
+	if len(blocks) != 3 {
+		t.Errorf("fetched %d blocks, want 3", len(blocks))
+	}
+	if !reflect.DeepEqual(service.cursors, []notionapi.Cursor{"", "2"}) {
+		t.Errorf("request cursors = %v", service.cursors)
+	}
+}
@@ -0,0 +1,48 @@
+package notion
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimitedTransport sends requests no more often than once per interval, so the pages of a
+// run processed in parallel stay within the API rate limit. A zero interval does not limit
+// requests.
+type rateLimitedTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest time the next request may be sent
+}
+
+// wait reserves the next request slot and returns how long to wait for it
+func (t *rateLimitedTransport) wait() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.interval == 0 {
+		return 0
+	}
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	return delay
+}
+
+// RoundTrip sends the request once its slot has come
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if delay := t.wait(); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	return t.base.RoundTrip(req)
+}
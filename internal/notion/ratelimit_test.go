@@ -0,0 +1,32 @@
+package notion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport := &rateLimitedTransport{base: http.DefaultTransport, interval: time.Second / 50}
+	client := &http.Client{Transport: transport}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("4 requests at 50 per second took %v, want at least 60ms", elapsed)
+	}
+
+	transport = &rateLimitedTransport{base: http.DefaultTransport}
+	if delay := transport.wait(); delay != 0 {
+		t.Errorf("wait() without a limit = %v, want 0", delay)
+	}
+}
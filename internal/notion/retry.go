@@ -0,0 +1,142 @@
+package notion
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrRateLimited is the cause of a request still answered with 429 Too Many Requests after
+// all attempts
+var ErrRateLimited = errors.New("rate limited by the Notion API")
+
+// rateLimitedError is err of a request given up on 429 responses, matching ErrRateLimited with
+// errors.Is. Its message is the message of err.
+type rateLimitedError struct {
+	err error
+}
+
+func (e *rateLimitedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rateLimitedError) Unwrap() []error {
+	return []error{e.err, ErrRateLimited}
+}
+
+// maxRetryDelay caps the backoff between two attempts of a request
+const maxRetryDelay = 30 * time.Second
+
+// retryTransport retries requests that fail with 429, a 5xx gateway or availability error, or a
+// network error. It waits as long as Retry-After asks, or backs off exponentially with jitter,
+// and returns an error naming the last failure once maxAttempts are used up.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int // Less than 1 sends a request once
+	baseDelay   time.Duration
+	logger      *slog.Logger // Receives the retries; nil logs through the default slog logger
+}
+
+// RoundTrip sends req until it succeeds, fails permanently or runs out of attempts
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxAttempts, baseDelay := max(t.maxAttempts, 1), t.baseDelay
+	if req.Body != nil && req.GetBody == nil {
+		// The body cannot be sent again
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil && (req.Context().Err() != nil || errors.Is(err, ErrRequestBudgetExceeded)) {
+			return nil, err
+		}
+		var failure string
+		if err != nil {
+			failure = err.Error()
+		} else if retryableStatus(resp.StatusCode) {
+			failure = resp.Status
+		} else {
+			return resp, nil
+		}
+
+		if attempt >= maxAttempts {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			err := fmt.Errorf("Notion API request %s %s failed after %d attempts: %s", req.Method, req.URL.Path, attempt, failure)
+			if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+				err = &rateLimitedError{err: err}
+			}
+			return nil, err
+		}
+
+		delay := backoffDelay(baseDelay, attempt)
+		if resp != nil {
+			if retryAfter, ok := retryAfterDelay(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		logf(t.logger, slog.LevelDebug, "Retrying %s %s in %v (attempt %d of %d): %s", req.Method, req.URL.Path, delay, attempt+1, maxAttempts, failure)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoffDelay returns the wait before the attempt after attempt: baseDelay doubled for each
+// failed attempt, capped at maxRetryDelay, with the upper half randomized so parallel
+// requests do not retry in lockstep
+func backoffDelay(baseDelay time.Duration, attempt int) time.Duration {
+	delay := baseDelay << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfterDelay parses a Retry-After header given in seconds or as an HTTP date
+func retryAfterDelay(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
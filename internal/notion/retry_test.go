@@ -0,0 +1,94 @@
+package notion
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var bodies []string
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		status := statuses[0]
+		statuses = statuses[1:]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, maxAttempts: 3, baseDelay: time.Millisecond}}
+	resp, err := client.Post(server.URL+"/v1/databases/db/query", "application/json", bytes.NewBufferString(`{"page_size":100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if len(bodies) != 3 || bodies[2] != `{"page_size":100}` {
+		t.Errorf("request bodies = %q, want the body sent 3 times", bodies)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &retryTransport{base: http.DefaultTransport, maxAttempts: 2, baseDelay: time.Millisecond}}
+	_, err := client.Get(server.URL + "/v1/blocks/abc/children")
+	if err == nil || !strings.Contains(err.Error(), "failed after 2 attempts: 502 Bad Gateway") {
+		t.Errorf("error = %v, want the last failure after 2 attempts", err)
+	}
+	if requests != 2 || errors.Is(err, ErrRateLimited) {
+		t.Errorf("sent %d requests (rate limited: %v), want 2 failing for another cause", requests, errors.Is(err, ErrRateLimited))
+	}
+
+	// Giving up on 429 responses is reported as rate limiting
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	_, err = client.Get(server.URL + "/v1/blocks/abc/children")
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "failed after 2 attempts: 429 Too Many Requests") {
+		t.Errorf("error = %v, want ErrRateLimited", err)
+	}
+
+	// Client errors are returned as they are
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	requests = 0
+	resp, err := client.Get(server.URL + "/v1/pages/missing")
+	if err != nil || resp.StatusCode != http.StatusNotFound || requests != 1 {
+		t.Errorf("404 response = %v, %v after %d requests; want it returned without retrying", resp, err, requests)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: maxRetryDelay} {
+		if delay := backoffDelay(time.Second, attempt); delay < want/2 || delay > want {
+			t.Errorf("backoffDelay(1s, %d) = %v, want between %v and %v", attempt, delay, want/2, want)
+		}
+	}
+	if delay, ok := retryAfterDelay("7"); !ok || delay != 7*time.Second {
+		t.Errorf("retryAfterDelay(7) = %v, %v", delay, ok)
+	}
+	if _, ok := retryAfterDelay("soon"); ok {
+		t.Error("retryAfterDelay accepted an invalid value")
+	}
+}
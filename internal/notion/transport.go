@@ -0,0 +1,88 @@
+// Package notion sends the requests of a conversion run to the Notion API and fetches what
+// spans several requests: the children of a block and the pages of a database. Requests are
+// retried, counted against a request budget and spaced out by a rate limit.
+package notion
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// APIVersion is the Notion-Version header sent with every API request
+const APIVersion = "2022-06-28"
+
+// Transport sends the Notion API requests of a run. Each request is retried, counted against
+// the request budget and spaced out by the rate limit, in that order. Every run has a
+// transport of its own, so runs in parallel do not share their limits.
+type Transport struct {
+	retry  *retryTransport
+	budget *requestBudget
+}
+
+// NewTransport returns the transport of a run sending up to perSecond requests per second
+// through base, each up to maxAttempts times, and refusing the requests after the first
+// maxRequests. Zero perSecond and maxRequests do not limit requests; a nil base uses
+// http.DefaultTransport.
+func NewTransport(base http.RoundTripper, perSecond, maxAttempts, maxRequests int, logger *slog.Logger) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	limiter := &rateLimitedTransport{base: base}
+	if perSecond > 0 {
+		limiter.interval = time.Second / time.Duration(perSecond)
+	}
+	budget := &requestBudget{base: limiter, limit: maxRequests}
+	return &Transport{
+		retry:  &retryTransport{base: budget, maxAttempts: maxAttempts, baseDelay: time.Second, logger: logger},
+		budget: budget,
+	}
+}
+
+// RoundTrip sends req with the retries and limits of the transport
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.retry.RoundTrip(req)
+}
+
+// Client creates a Notion API client pinned to APIVersion sending its requests through t
+func (t *Transport) Client(token string) *notionapi.Client {
+	return notionapi.NewClient(notionapi.Token(token), notionapi.WithVersion(APIVersion),
+		notionapi.WithHTTPClient(&http.Client{Transport: t}))
+}
+
+// Sent returns the number of requests sent so far
+func (t *Transport) Sent() int {
+	if t == nil {
+		return 0
+	}
+	return t.budget.Sent()
+}
+
+// Exceeded reports whether a request was refused because the request budget was used up
+func (t *Transport) Exceeded() bool {
+	if t == nil {
+		return false
+	}
+	return t.budget.Exceeded()
+}
+
+// NewClient creates a Notion API client outside of a run, with the default limits of a run
+func NewClient(token string) *notionapi.Client {
+	return NewTransport(nil, 3, 5, 0, nil).Client(token)
+}
+
+// logf formats and logs a message through logger when level is enabled. A nil logger logs
+// through the default slog logger.
+func logf(logger *slog.Logger, level slog.Level, format string, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,389 @@
+// Package writer writes the files of a conversion run. Files are written atomically, so a path
+// never holds a partly written file, and a Workspace stages all changes of a run until the whole
+// run succeeded.
+package writer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Workspace stages the changes a run makes to generated files, images and state files, and
+// moves them into place once the whole run succeeded, so a failed run leaves the content
+// directory as it was. Reads see the staged changes. A nil *Workspace writes directly.
+type Workspace struct {
+	dir    string       // Directory of the run, created in parent
+	parent string       // Directory shared by the workspaces of all runs (STAGING_DIR)
+	logger *slog.Logger // Logger of the run
+
+	mu    sync.Mutex
+	files map[string]string // Target path → staged file, or "" once the target is removed
+	slots int
+	done  bool // Commit was called, or the workspace was discarded
+}
+
+// New creates the workspace of a run in a new directory under parent, so runs in parallel,
+// also those of other processes, never share one. The directory of a run that failed to
+// commit is left as it is for its staged changes to be recovered.
+func New(parent string, logger *slog.Logger) (*Workspace, error) {
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", parent, err)
+	}
+	dir, err := os.MkdirTemp(parent, "run-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a workspace in %s: %v", parent, err)
+	}
+	return &Workspace{dir: dir, parent: filepath.Clean(parent), logger: logger, files: map[string]string{}}, nil
+}
+
+// Dir returns the directory holding the staged changes
+func (w *Workspace) Dir() string {
+	return w.dir
+}
+
+// remove removes the directory of the workspace, and parent once no other run uses it
+func (w *Workspace) remove() error {
+	if err := os.RemoveAll(w.dir); err != nil {
+		return err
+	}
+	// Fails while the workspace of another run is in parent
+	os.Remove(w.parent)
+	return nil
+}
+
+// lookup returns the staged file of path; ok is false when path was not changed by the run
+func (w *Workspace) lookup(path string) (staged string, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	staged, ok = w.files[filepath.Clean(path)]
+	return staged, ok
+}
+
+// stage returns the staged file that receives the new content of path. The file keeps the
+// name of path so that commands run on it see the usual extension.
+func (w *Workspace) stage(path string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := filepath.Clean(path)
+	if staged := w.files[key]; staged != "" {
+		return staged, nil
+	}
+	w.slots++
+	slot := filepath.Join(w.dir, strconv.Itoa(w.slots))
+	if err := os.MkdirAll(slot, 0755); err != nil {
+		return "", err
+	}
+	staged := filepath.Join(slot, filepath.Base(key))
+	w.files[key] = staged
+	return staged, nil
+}
+
+// Path returns the file holding the current content of path
+func (w *Workspace) Path(path string) string {
+	if w == nil {
+		return path
+	}
+	if staged, ok := w.lookup(path); ok && staged != "" {
+		return staged
+	}
+	return path
+}
+
+// resolve returns the file to read path from, or a not-exist error if the run removed it
+func (w *Workspace) resolve(op, path string) (string, error) {
+	if w == nil {
+		return path, nil
+	}
+	staged, ok := w.lookup(path)
+	if !ok {
+		return path, nil
+	}
+	if staged == "" {
+		return "", &fs.PathError{Op: op, Path: path, Err: fs.ErrNotExist}
+	}
+	return staged, nil
+}
+
+// ReadFile is os.ReadFile seeing the staged changes
+func (w *Workspace) ReadFile(path string) ([]byte, error) {
+	file, err := w.resolve("open", path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(file)
+}
+
+// Open is os.Open seeing the staged changes
+func (w *Workspace) Open(path string) (*os.File, error) {
+	file, err := w.resolve("open", path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(file)
+}
+
+// Stat is os.Stat seeing the staged changes
+func (w *Workspace) Stat(path string) (os.FileInfo, error) {
+	file, err := w.resolve("stat", path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(file)
+}
+
+// WriteFile is the package WriteFile; with a workspace the content is staged
+func (w *Workspace) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if w == nil {
+		return WriteFile(path, data, perm)
+	}
+	staged, err := w.stage(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(staged, data, perm)
+}
+
+// Create is the package Create; with a workspace the file is staged
+func (w *Workspace) Create(path string) (*File, error) {
+	if w == nil {
+		return Create(path, 0644)
+	}
+	staged, err := w.stage(path)
+	if err != nil {
+		return nil, err
+	}
+	return Create(staged, 0644)
+}
+
+// MkdirAll is os.MkdirAll; with a workspace directories are created when the run is committed
+func (w *Workspace) MkdirAll(path string, perm os.FileMode) error {
+	if w == nil {
+		return os.MkdirAll(path, perm)
+	}
+	return nil
+}
+
+// TempDir returns the directory for temporary files that end up at a path in dir
+func (w *Workspace) TempDir(dir string) string {
+	if w == nil {
+		return dir
+	}
+	return w.dir
+}
+
+// Remove is os.Remove; with a workspace the removal is staged
+func (w *Workspace) Remove(path string) error {
+	if w == nil {
+		return os.Remove(path)
+	}
+	if _, err := w.Stat(path); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := filepath.Clean(path)
+	if staged := w.files[key]; staged != "" {
+		os.Remove(staged)
+	}
+	w.files[key] = ""
+	return nil
+}
+
+// Rename is os.Rename; with a workspace the move is staged. A temporary file created in
+// TempDir is moved into the workspace, while a target file is copied and staged for removal.
+func (w *Workspace) Rename(oldpath, newpath string) error {
+	if w == nil {
+		return os.Rename(oldpath, newpath)
+	}
+	src, err := w.resolve("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	temporary := strings.HasPrefix(filepath.Clean(oldpath), w.dir+string(filepath.Separator))
+	staged, err := w.stage(newpath)
+	if err != nil {
+		return err
+	}
+	if src != filepath.Clean(oldpath) || temporary {
+		err = os.Rename(src, staged)
+	} else {
+		err = copyFile(src, staged)
+	}
+	if err != nil {
+		return err
+	}
+	if !temporary {
+		w.mu.Lock()
+		w.files[filepath.Clean(oldpath)] = ""
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// Commit moves the staged files into place, applies the staged removals and removes the workspace
+func (w *Workspace) Commit() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+
+	targets := make([]string, 0, len(w.files))
+	for target := range w.files {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	var errs []string
+	for _, target := range targets {
+		staged := w.files[target]
+		var err error
+		if staged == "" {
+			if err = os.Remove(target); os.IsNotExist(err) {
+				err = nil
+			}
+		} else if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+			err = moveFile(staged, target)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		// Keep the workspace so nothing staged is lost
+		return fmt.Errorf("failed to apply %d staged changes: %s", len(errs), strings.Join(errs, "; "))
+	}
+	logf(w.logger, slog.LevelDebug, "Applied %d staged changes", len(targets))
+	return w.remove()
+}
+
+// Discard removes the workspace without applying the staged changes. It does nothing once
+// Commit was called, so the staged files of a failed commit are kept and Discard may be
+// deferred.
+func (w *Workspace) Discard() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return
+	}
+	w.done = true
+	if err := w.remove(); err != nil {
+		logf(w.logger, slog.LevelWarn, "Failed to remove %s: %v", w.dir, err)
+	}
+}
+
+// moveFile renames src to dst, copying it when they are on different file systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies the content of src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := Create(dst, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Commit()
+}
+
+// File is written under a temporary name next to its path and renamed to the path by
+// Commit, so the path never holds a partly written file, even when the process dies while
+// writing. Close without Commit removes the temporary file and leaves the path as it was.
+type File struct {
+	*os.File
+	path      string
+	perm      os.FileMode
+	committed bool
+}
+
+// Create creates the temporary file of path
+func Create(path string, perm os.FileMode) (*File, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: f, path: path, perm: perm}, nil
+}
+
+// Commit closes the file and moves it to its path
+func (f *File) Commit() error {
+	if f.committed {
+		return nil
+	}
+	f.committed = true
+	err := f.File.Close()
+	if err == nil {
+		err = os.Chmod(f.Name(), f.perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Close discards the file unless it was committed
+func (f *File) Close() error {
+	if f.committed {
+		return nil
+	}
+	f.committed = true
+	f.File.Close()
+	return os.Remove(f.Name())
+}
+
+// WriteFile is os.WriteFile through a File
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := Create(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// logf formats and logs a message through logger when level is enabled. A nil logger logs
+// through the default slog logger.
+func logf(logger *slog.Logger, level slog.Level, format string, args ...any) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if !logger.Enabled(context.Background(), level) {
+		return
+	}
+	logger.Log(context.Background(), level, fmt.Sprintf(format, args...))
+}
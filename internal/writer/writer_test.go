@@ -0,0 +1,208 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	post := filepath.Join(dir, "content", "blog", "post.md")
+	old := filepath.Join(dir, "content", "blog", "old.md")
+	if err := os.MkdirAll(filepath.Dir(old), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(old, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace, err := New(filepath.Join(dir, "staging"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.WriteFile(post, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := workspace.ReadFile(post); err != nil || string(data) != "new" {
+		t.Errorf("ReadFile() = %q, %v; want the staged content", data, err)
+	}
+	trashed := filepath.Join(dir, "trash", "old.md")
+	if err := workspace.Rename(old, trashed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := workspace.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("Stat() of a moved file = %v, want not exist", err)
+	}
+
+	// A download is moved from the temporary directory into place
+	tmp, err := os.CreateTemp(workspace.TempDir(dir), ".download-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp.WriteString("png")
+	tmp.Close()
+	image := filepath.Join(dir, "public", "images", "a.png")
+	if err := workspace.Rename(tmp.Name(), image); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing reaches the content directory before the commit
+	if _, err := os.Stat(post); !os.IsNotExist(err) {
+		t.Errorf("staged file exists before commit: %v", err)
+	}
+	if data, err := os.ReadFile(old); err != nil || string(data) != "old" {
+		t.Errorf("moved file changed before commit: %q, %v", data, err)
+	}
+
+	if err := workspace.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{post: "new", trashed: "old", image: "png"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v after commit; want %q", path, data, err, want)
+		}
+	}
+	for _, path := range []string{old, filepath.Join(dir, "staging")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s exists after commit: %v", path, err)
+		}
+	}
+}
+
+func TestWorkspaceDiscard(t *testing.T) {
+	dir := t.TempDir()
+	post := filepath.Join(dir, "post.md")
+	if err := os.WriteFile(post, []byte("published"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workspace, err := New(filepath.Join(dir, "staging"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.WriteFile(post, []byte("half-updated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.Remove(post); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.Remove(post); !os.IsNotExist(err) {
+		t.Errorf("second Remove() = %v, want not exist", err)
+	}
+	workspace.Discard()
+
+	if data, err := os.ReadFile(post); err != nil || string(data) != "published" {
+		t.Errorf("post = %q, %v after discard; want it unchanged", data, err)
+	}
+
+	// A nil workspace writes directly
+	var direct *Workspace
+	if err := direct.WriteFile(post, []byte("direct"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(post); string(data) != "direct" {
+		t.Errorf("post = %q, want the direct write", data)
+	}
+
+	// The staged files of a failed commit are kept for good
+	failed, err := New(filepath.Join(dir, "staging"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := failed.WriteFile(filepath.Join(post, "nested.md"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := failed.Commit(); err == nil {
+		t.Fatal("Commit() below a file succeeded")
+	}
+	failed.Discard()
+	if _, err := os.Stat(failed.Dir()); err != nil {
+		t.Errorf("workspace of the failed commit: %v", err)
+	}
+}
+
+func TestWorkspacesInParallel(t *testing.T) {
+	dir := t.TempDir()
+	parent := filepath.Join(dir, "staging")
+	first, err := New(parent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New(parent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Dir() == second.Dir() {
+		t.Fatalf("both runs stage in %s", first.Dir())
+	}
+
+	// Each run keeps its staged changes while the other one starts, commits or discards
+	a, b := filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")
+	if err := first.WriteFile(a, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.WriteFile(b, []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	third, err := New(parent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third.Discard()
+	if err := second.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := first.ReadFile(a); err != nil || string(data) != "a" {
+		t.Errorf("staged file = %q, %v; want it kept", data, err)
+	}
+	if err := first.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{a: "a", b: "b"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v after commit; want %q", path, data, err, want)
+		}
+	}
+	if _, err := os.Stat(parent); !os.IsNotExist(err) {
+		t.Errorf("%s exists after the last run: %v", parent, err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	post := filepath.Join(dir, "post.md")
+	if err := WriteFile(post, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file that is not committed, e.g. because encoding failed, leaves the path as it was
+	f, err := Create(post, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(post); err != nil || string(data) != "old" {
+		t.Errorf("ReadFile() = %q, %v; want the previous content", data, err)
+	}
+
+	if err := WriteFile(post, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(post); err != nil || string(data) != "new" {
+		t.Errorf("ReadFile() = %q, %v; want the new content", data, err)
+	}
+	if info, err := os.Stat(post); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("Stat() = %v, %v; want mode 0644", info, err)
+	}
+
+	// No temporary files are left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only post.md, got %d entries", len(entries))
+	}
+}
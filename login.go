@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"notion-to-astro-go/converter"
+)
+
+// runLogin handles the `login` subcommand: the OAuth authorization code flow of a public integration
+func runLogin(args []string, env map[string]string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	tokenFile := flags.String("token-file", converter.TokenFile(env), "Path to store the OAuth token")
+	port := flags.Int("port", 8976, "Local port for the OAuth redirect (register http://localhost:<port>/callback as the redirect URI)")
+	noBrowser := flags.Bool("no-browser", false, "Print the authorization URL instead of opening a browser")
+	flags.Parse(args)
+
+	redirectURI := fmt.Sprintf("http://localhost:%d/callback", *port)
+	state, err := randomState()
+	if err != nil {
+		return fmt.Errorf("failed to create OAuth state: %w", err)
+	}
+	authorizeURL, err := converter.AuthorizeURL(env, redirectURI, state)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", *port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", *port, err)
+	}
+
+	fmt.Println("Connect notion-to-astro to your Notion workspace in the browser:")
+	fmt.Println(authorizeURL)
+	if !*noBrowser {
+		if err := openBrowser(authorizeURL); err != nil {
+			fmt.Println("Could not open a browser; open the URL above manually")
+		}
+	}
+
+	code, err := waitForAuthorizationCode(listener, state)
+	if err != nil {
+		return fmt.Errorf("authorization failed: %w", err)
+	}
+	token, err := converter.SaveAuthorizationCode(env, code, redirectURI, *tokenFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Connected to %s. Token saved to %s\n", token.WorkspaceName, *tokenFile)
+	return nil
+}
+
+// waitForAuthorizationCode serves the OAuth redirect and returns the authorization code
+func waitForAuthorizationCode(listener net.Listener, state string) (string, error) {
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	// Only the first callback is answered; repeated callbacks, e.g. a reloaded page, must not
+	// block their handler and with it the shutdown of the server
+	send := func(res result) {
+		select {
+		case results <- res:
+		default:
+		}
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		switch {
+		case query.Get("state") != state:
+			http.Error(w, "Invalid state", http.StatusBadRequest)
+			send(result{err: errors.New("state mismatch")})
+		case query.Get("error") != "":
+			fmt.Fprintln(w, "Notion was not connected. You can close this window.")
+			send(result{err: fmt.Errorf("access denied: %s", query.Get("error"))})
+		default:
+			fmt.Fprintln(w, "Notion connected. You can close this window and return to the terminal.")
+			send(result{code: query.Get("code")})
+		}
+	})}
+	go server.Serve(listener)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	select {
+	case res := <-results:
+		return res.code, res.err
+	case <-time.After(5 * time.Minute):
+		return "", errors.New("timed out waiting for the browser")
+	}
+}
+
+// randomState returns a random value protecting the OAuth redirect against CSRF
+func randomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// openBrowser opens url in the default browser
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
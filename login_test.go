@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitForAuthorizationCodeRepeatedCallbacks(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan string)
+	go func() {
+		code, _ := waitForAuthorizationCode(listener, "state")
+		done <- code
+	}()
+
+	// Callbacks after the first are answered without blocking the shutdown of the server
+	callback := "http://" + listener.Addr().String() + "/callback?state=state&code=abc"
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, err := http.Get(callback); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	select {
+	case code := <-done:
+		if code != "abc" {
+			t.Errorf("code = %q, want abc", code)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("waitForAuthorizationCode did not return")
+	}
+	wg.Wait()
+}
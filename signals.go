@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// signalContext returns a context canceled by the first SIGINT or SIGTERM, so the run starts no
+// new page, finishes the pages in progress, saves the manifest of the pages written so far and
+// stops. After the first signal the default handling is restored and a second Ctrl-C ends the
+// process at once. stop releases the signals.
+func signalContext(logger *slog.Logger) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			logger.Warn(fmt.Sprintf("Received %v; finishing the pages in progress and saving the pages written so far (press Ctrl-C again to stop at once)", sig))
+			cancel(fmt.Errorf("received %v", sig))
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
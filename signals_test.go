@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSignalContext(t *testing.T) {
+	var out bytes.Buffer
+	ctx, stop := signalContext(slog.New(slog.NewTextHandler(&out, nil)))
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("cannot send an interrupt: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context was not canceled by the interrupt")
+	}
+	if cause := context.Cause(ctx); cause == nil || cause.Error() != "received interrupt" {
+		t.Errorf("context.Cause() = %v, want the interrupt", cause)
+	}
+	if out.Len() == 0 {
+		t.Error("expected a warning about the interrupt")
+	}
+}
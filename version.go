@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+
+	"notion-to-astro-go/converter"
+)
+
+// Build information, overridable with -ldflags "-X main.version=... -X main.commit=..."
+var (
+	version = ""
+	commit  = ""
+)
+
+// buildVersion returns the module version and VCS commit, preferring ldflags values
+func buildVersion() (string, string, bool) {
+	moduleVersion, revision, modified := version, commit, false
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if moduleVersion == "" {
+			moduleVersion = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if revision == "" {
+					revision = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+	}
+	if moduleVersion == "" {
+		moduleVersion = "(devel)"
+	}
+	if revision == "" {
+		revision = "unknown"
+	}
+	return moduleVersion, revision, modified
+}
+
+// runVersion handles the `version` subcommand
+func runVersion(args []string, env map[string]string) error {
+	moduleVersion, revision, modified := buildVersion()
+	if modified {
+		revision += " (modified)"
+	}
+	fmt.Printf("notion-to-astro-go %s\n", moduleVersion)
+	fmt.Printf("commit:         %s\n", revision)
+	fmt.Printf("notion-version: %s\n", converter.NotionAPIVersion)
+	fmt.Printf("go:             %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return nil
+}
+
+func init() {
+	// The completion script lists commands, so the command is registered once commands exists
+	commands["completion"] = runCompletion
+}
+
+// completionCommands returns the subcommands offered by shell completion: those of commands,
+// sorted, so a new subcommand is completed without listing it again
+func completionCommands() []string {
+	return slices.Sorted(maps.Keys(commands))
+}
+
+// completionTypes lists the values offered for -type
+var completionTypes = []string{"all", "blog", "diary", "pages"}
+
+// completionFileFlags are the flags whose value is completed with a path
+var completionFileFlags = []string{"config", "pages-file", "output"}
+
+// runCompletion handles the `completion` subcommand
+func runCompletion(args []string, env map[string]string) error {
+	if len(args) != 1 {
+		return errors.New("usage: notion-to-astro-go completion bash|zsh|fish")
+	}
+
+	script, err := completionScript(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// completionScript returns the completion script of shell. The flags offered are those of
+// conversionFlags, so a new flag is completed without changing the scripts.
+func completionScript(shell string) (string, error) {
+	flags, _ := conversionFlags()
+	var names []string
+	flags.VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	fileFlags := make([]string, len(completionFileFlags))
+	for i, name := range completionFileFlags {
+		fileFlags[i] = "-" + name + "|--" + name
+	}
+
+	commands := strings.Join(completionCommands(), " ")
+	types := strings.Join(completionTypes, " ")
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(bashCompletion, commands, types, strings.Join(names, " "), strings.Join(fileFlags, "|")), nil
+	case "zsh":
+		return fmt.Sprintf(zshCompletion, commands, types, strings.Join(names, " "), strings.Join(fileFlags, "|")), nil
+	case "fish":
+		return fmt.Sprintf(fishCompletion, commands, types) + fishFlagCompletions(flags), nil
+	}
+	return "", fmt.Errorf("unsupported shell: %s. Must be 'bash', 'zsh', or 'fish'", shell)
+}
+
+// fishFlagCompletions returns a fish completion with the usage of each flag other than -type
+func fishFlagCompletions(flags *flag.FlagSet) string {
+	var lines strings.Builder
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "type" {
+			return
+		}
+		line := "complete -c notion-to-astro-go -o " + f.Name
+		if slices.Contains(completionFileFlags, f.Name) {
+			line += " -r -F"
+		} else if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !boolFlag.IsBoolFlag() {
+			line += " -x"
+		}
+		line += " -d " + strconv.Quote(f.Usage)
+		lines.WriteString(line + "\n")
+	})
+	return lines.String()
+}
+
+const bashCompletion = `# bash completion for notion-to-astro-go
+_notion_to_astro_go() {
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+        -type|--type)
+            COMPREPLY=($(compgen -W "%[2]s" -- "$cur"))
+            return ;;
+        %[4]s)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return ;;
+        completion)
+            COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+            return ;;
+        schema)
+            COMPREPLY=($(compgen -W "dump" -- "$cur"))
+            return ;;
+        scaffold)
+            COMPREPLY=($(compgen -W "astro" -- "$cur"))
+            return ;;
+    esac
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "%[1]s %[3]s" -- "$cur"))
+    else
+        COMPREPLY=($(compgen -W "%[3]s" -- "$cur"))
+    fi
+}
+complete -F _notion_to_astro_go notion-to-astro-go
+`
+
+const zshCompletion = `#compdef notion-to-astro-go
+# zsh completion for notion-to-astro-go
+_notion_to_astro_go() {
+    case "${words[CURRENT-1]}" in
+        -type|--type) compadd %[2]s; return ;;
+        %[4]s) _files; return ;;
+        completion) compadd bash zsh fish; return ;;
+        schema) compadd dump; return ;;
+        scaffold) compadd astro; return ;;
+    esac
+    if (( CURRENT == 2 )); then
+        compadd -- %[1]s %[3]s
+    else
+        compadd -- %[3]s
+    fi
+}
+compdef _notion_to_astro_go notion-to-astro-go
+`
+
+const fishCompletion = `# fish completion for notion-to-astro-go
+complete -c notion-to-astro-go -f
+complete -c notion-to-astro-go -n "not __fish_seen_subcommand_from %[1]s" -a "%[1]s"
+complete -c notion-to-astro-go -o type -x -a "%[2]s"
+complete -c notion-to-astro-go -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c notion-to-astro-go -n "__fish_seen_subcommand_from schema" -a "dump"
+complete -c notion-to-astro-go -n "__fish_seen_subcommand_from scaffold" -a "astro"
+`
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScript(t *testing.T) {
+	flags, _ := conversionFlags()
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		script, err := completionScript(shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every conversion flag is offered, also those added after the scripts were written
+		flags.VisitAll(func(f *flag.Flag) {
+			name := "-" + f.Name
+			if shell == "fish" {
+				name = "-o " + f.Name
+			}
+			if !strings.Contains(script, name+" ") && !strings.Contains(script, name+`"`) && !strings.Contains(script, name+"\n") {
+				t.Errorf("%s completion does not offer -%s", shell, f.Name)
+			}
+		})
+		// Every subcommand is offered, in the order of their names
+		if want := "clean completion init login scaffold schema verify version"; !strings.Contains(script, want) {
+			t.Errorf("%s completion does not offer the subcommands %q", shell, want)
+		}
+	}
+
+	if _, err := completionScript("powershell"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}